@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// ActionsFile is a YAML or JSON document listing actions to be checked in a batch, for example:
+//
+//	actions:
+//	- verb: create
+//	  resource: pods
+//	  subResource: exec
+//	- verb: get
+//	  resource: secrets
+//	  namespace: prod
+//	  allowed:
+//	  - kind: Group
+//	    name: db-admins
+//
+// An action without namespace is checked in all namespaces.
+type ActionsFile struct {
+	Actions []ActionSpec `json:"actions"`
+}
+
+// ActionSpec is an Action listed in an ActionsFile along with the subjects which are expected to be allowed to
+// perform it. A nil Allowed list means that the action is only checked, whereas an empty one asserts that no subject
+// is allowed to perform it.
+type ActionSpec struct {
+	Action
+	Allowed []rbac.Subject `json:"allowed,omitempty"`
+}
+
+// ReadActionsFile reads and validates the actions listed in the file with the specified path.
+func ReadActionsFile(path string) ([]Action, error) {
+	specs, err := ReadActionSpecs(path)
+	if err != nil {
+		return nil, err
+	}
+	return actionsOf(specs), nil
+}
+
+// ReadActionSpecs reads and validates the actions, along with the subjects expected to be allowed to perform them,
+// listed in the file with the specified path.
+func ReadActionSpecs(path string) ([]ActionSpec, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading actions file: %v", err)
+	}
+	return parseActionSpecs(data)
+}
+
+func parseActions(data []byte) ([]Action, error) {
+	specs, err := parseActionSpecs(data)
+	if err != nil {
+		return nil, err
+	}
+	return actionsOf(specs), nil
+}
+
+func actionsOf(specs []ActionSpec) []Action {
+	actions := make([]Action, len(specs))
+	for i, spec := range specs {
+		actions[i] = spec.Action
+	}
+	return actions
+}
+
+func parseActionSpecs(data []byte) ([]ActionSpec, error) {
+	var file ActionsFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing actions file: %v", err)
+	}
+	if len(file.Actions) == 0 {
+		return nil, errors.New("parsing actions file: no actions specified")
+	}
+
+	for i := range file.Actions {
+		action := &file.Actions[i].Action
+		if action.Verb == "" {
+			return nil, fmt.Errorf("parsing actions file: action #%d: verb is required", i+1)
+		}
+		if (action.Resource == "") == (action.NonResourceURL == "") {
+			return nil, fmt.Errorf("parsing actions file: action #%d: exactly one of resource or nonResourceURL is required", i+1)
+		}
+		if action.Namespace == core.NamespaceAll && !action.multiNamespace() {
+			action.AllNamespaces = true
+		}
+	}
+
+	return file.Actions, nil
+}
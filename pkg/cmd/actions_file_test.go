@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbac "k8s.io/api/rbac/v1"
+)
+
+func TestParseActions(t *testing.T) {
+	testCases := []struct {
+		scenario string
+		data     string
+
+		expectedActions []Action
+		expectedError   error
+	}{
+		{
+			scenario: "Should parse actions",
+			data: `actions:
+- verb: create
+  resource: pods
+  subResource: exec
+- verb: get
+  resource: secrets
+  namespace: prod
+- verb: get
+  nonResourceURL: /logs
+`,
+			expectedActions: []Action{
+				{Verb: "create", Resource: "pods", SubResource: "exec", AllNamespaces: true},
+				{Verb: "get", Resource: "secrets", Namespace: "prod"},
+				{Verb: "get", NonResourceURL: "/logs", AllNamespaces: true},
+			},
+		},
+		{
+			scenario:      "Should return error when no actions are specified",
+			data:          "actions: []",
+			expectedError: errors.New("parsing actions file: no actions specified"),
+		},
+		{
+			scenario:      "Should return error when verb is missing",
+			data:          "actions:\n- resource: pods",
+			expectedError: errors.New("parsing actions file: action #1: verb is required"),
+		},
+		{
+			scenario:      "Should return error when both resource and nonResourceURL are specified",
+			data:          "actions:\n- verb: get\n  resource: pods\n  nonResourceURL: /logs",
+			expectedError: errors.New("parsing actions file: action #1: exactly one of resource or nonResourceURL is required"),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.scenario, func(t *testing.T) {
+			actions, err := parseActions([]byte(tt.data))
+			assert.Equal(t, tt.expectedError, err)
+			assert.Equal(t, tt.expectedActions, actions)
+		})
+	}
+}
+
+func TestParseActionSpecs(t *testing.T) {
+	specs, err := parseActionSpecs([]byte(`actions:
+- verb: get
+  resource: secrets
+  namespace: prod
+  allowed:
+  - kind: Group
+    name: db-admins
+  - kind: ServiceAccount
+    name: backup
+    namespace: prod
+- verb: delete
+  resource: secrets
+  allowed: []
+- verb: list
+  resource: pods
+`))
+
+	require.NoError(t, err)
+	assert.Equal(t, []ActionSpec{
+		{
+			Action: Action{Verb: "get", Resource: "secrets", Namespace: "prod"},
+			Allowed: []rbac.Subject{
+				{Kind: rbac.GroupKind, Name: "db-admins"},
+				{Kind: rbac.ServiceAccountKind, Name: "backup", Namespace: "prod"},
+			},
+		},
+		{Action: Action{Verb: "delete", Resource: "secrets", AllNamespaces: true}, Allowed: []rbac.Subject{}},
+		{Action: Action{Verb: "list", Resource: "pods", AllNamespaces: true}},
+	}, specs)
+}
@@ -0,0 +1,421 @@
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	clioptions "k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+const (
+	assertUsage = `assert --config FILE [-f FILENAME] [-o json|junit]`
+	assertLong  = `Checks who can perform each action listed in the config file and asserts that only the allowed subjects
+can perform it.
+
+The config file is a YAML document listing the actions to be checked, for example:
+
+  actions:
+  - verb: get
+    resource: secrets
+    namespace: prod
+    allowed:
+    - kind: Group
+      name: db-admins
+    - kind: ServiceAccount
+      name: backup
+      namespace: prod
+  - verb: create
+    resource: pods
+    subResource: exec
+
+An action without namespace is checked in each existing namespace, so that the subjects granted it through
+RoleBindings are asserted as well as the ones granted it through ClusterRoleBindings. An action without the allowed list is only checked,
+whereas an empty allowed list asserts that no subject can perform it.
+
+The command fails if any subject which is not allowed can perform an action, or if an action cannot be checked.`
+	assertExample = `  # Assert who can perform the actions listed in actions.yaml
+  kubectl who-can assert --config actions.yaml
+
+  # Assert who can perform the actions against RBAC manifests and write a JUnit XML report for CI
+  kubectl who-can assert --config actions.yaml -f manifests/ -o junit > who-can.xml`
+)
+
+const (
+	outputJUnit = "junit"
+)
+
+// Assertion statuses
+const (
+	AssertionPassed  = "PASS"
+	AssertionFailed  = "FAIL"
+	AssertionError   = "ERROR"
+	AssertionSkipped = "SKIP"
+)
+
+// AssertionResult is the result of checking an ActionSpec.
+type AssertionResult struct {
+	Spec ActionSpec
+	// Subjects holds all subjects allowed to perform the action along with the bindings granting it.
+	Subjects []bindingSubject
+	// Unexpected holds the subjects allowed to perform the action which are not listed in the ActionSpec.
+	Unexpected []bindingSubject
+	// Warnings holds the warnings returned by CheckAPIAccess, if any.
+	Warnings []string
+	// Notes holds the notes about the API groups of the resource returned by WhoCan.ResolveResource, if any. They
+	// don't affect the status of the assertion.
+	Notes    []string
+	Err      error
+	Duration time.Duration
+}
+
+// Status returns the status of the assertion. An assertion with unexpected subjects fails even if the list of
+// subjects might not be complete, whereas an assertion which passed with warnings is skipped.
+func (r AssertionResult) Status() string {
+	switch {
+	case r.Err != nil:
+		return AssertionError
+	case len(r.Unexpected) > 0:
+		return AssertionFailed
+	case len(r.Warnings) > 0:
+		return AssertionSkipped
+	}
+	return AssertionPassed
+}
+
+// Asserter checks a batch of ActionSpecs.
+type Asserter struct {
+	whoCan         *WhoCan
+	checkAPIAccess bool
+}
+
+// NewAsserter constructs a new Asserter with the given WhoCan checker. If checkAPIAccess is true, the access to the
+// API server is checked before each action and the missing permissions are reported as warnings.
+func NewAsserter(whoCan *WhoCan, checkAPIAccess bool) *Asserter {
+	return &Asserter{
+		whoCan:         whoCan,
+		checkAPIAccess: checkAPIAccess,
+	}
+}
+
+// Run checks the given ActionSpecs in order.
+func (a *Asserter) Run(specs []ActionSpec) []AssertionResult {
+	results := make([]AssertionResult, len(specs))
+	for i, spec := range specs {
+		start := time.Now()
+		results[i] = a.assert(spec)
+		results[i].Duration = time.Since(start)
+	}
+	return results
+}
+
+func (a *Asserter) assert(spec ActionSpec) AssertionResult {
+	result := AssertionResult{Spec: spec}
+
+	if a.checkAPIAccess {
+		warnings, err := a.whoCan.CheckAPIAccess(spec.Action)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		result.Warnings = warnings
+	}
+
+	action, notes, err := a.whoCan.withResolvedResource(spec.Action)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Notes = notes
+
+	action, err = a.whoCan.expandAllNamespaces(action)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	roleBindings, clusterRoleBindings, err := a.whoCan.Check(action)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Subjects = bindingSubjectsOf(roleBindings, clusterRoleBindings)
+	if spec.Allowed != nil {
+		for _, bs := range result.Subjects {
+			if !containsSubject(spec.Allowed, bs.Subject) {
+				result.Unexpected = append(result.Unexpected, bs)
+			}
+		}
+	}
+	return result
+}
+
+// countFailed returns the number of failed or errored assertions.
+func countFailed(results []AssertionResult) int {
+	failed := 0
+	for _, r := range results {
+		if status := r.Status(); status == AssertionFailed || status == AssertionError {
+			failed++
+		}
+	}
+	return failed
+}
+
+// bindingSubjectString returns a human readable representation of the given binding subject, e.g.
+// `User alice through ClusterRoleBinding admins (ClusterRole admin)`.
+func bindingSubjectString(bs bindingSubject) string {
+	binding := bs.Binding
+	if bs.Namespace != "" {
+		binding = bs.Namespace + "/" + bs.Binding
+	}
+	return fmt.Sprintf("%s through %s %s (%s %s)", subjectString(bs.Subject), bs.BindingKind, binding, bs.RoleRef.Kind, bs.RoleRef.Name)
+}
+
+// PrintAssertions prints the given assertion results as a table followed by the details of the failed ones.
+func (p *Printer) PrintAssertions(results []AssertionResult) {
+	wr := new(tabwriter.Writer)
+	wr.Init(p.out, 0, 8, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(wr, "STATUS\tACTION\tSUBJECTS\tUNEXPECTED")
+	for _, r := range results {
+		_, _ = fmt.Fprintf(wr, "%s\t%s\t%d\t%d\n", r.Status(), actionLabel(r.Spec.Action), len(r.Subjects), len(r.Unexpected))
+	}
+	_ = wr.Flush()
+
+	for _, r := range results {
+		switch r.Status() {
+		case AssertionError:
+			_, _ = fmt.Fprintf(p.out, "\n%s: %v\n", actionLabel(r.Spec.Action), r.Err)
+		case AssertionFailed:
+			_, _ = fmt.Fprintf(p.out, "\n%s: unexpected subject(s):\n", actionLabel(r.Spec.Action))
+			for _, bs := range r.Unexpected {
+				_, _ = fmt.Fprintf(p.out, "\t%s\n", bindingSubjectString(bs))
+			}
+		}
+		if len(r.Warnings) > 0 {
+			_, _ = fmt.Fprintf(p.out, "\n%s: the list might not be complete due to missing permission(s):\n", actionLabel(r.Spec.Action))
+			for _, warning := range r.Warnings {
+				_, _ = fmt.Fprintf(p.out, "\t%s\n", warning)
+			}
+		}
+		if len(r.Notes) > 0 {
+			_, _ = fmt.Fprintf(p.out, "\n%s: resolving the API groups of the resource:\n", actionLabel(r.Spec.Action))
+			for _, note := range r.Notes {
+				_, _ = fmt.Fprintf(p.out, "\t%s\n", note)
+			}
+		}
+	}
+}
+
+type assertionData struct {
+	Action     Action           `json:"action"`
+	Status     string           `json:"status"`
+	Subjects   []bindingSubject `json:"subjects"`
+	Unexpected []bindingSubject `json:"unexpected"`
+	Warnings   []string         `json:"warnings,omitempty"`
+	Notes      []string         `json:"notes,omitempty"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// ExportAssertions exports the given assertion results as JSON.
+func (p *Printer) ExportAssertions(results []AssertionResult) {
+	data := make([]assertionData, len(results))
+	for i, r := range results {
+		data[i] = assertionData{
+			Action:     r.Spec.Action,
+			Status:     r.Status(),
+			Subjects:   append([]bindingSubject{}, r.Subjects...),
+			Unexpected: append([]bindingSubject{}, r.Unexpected...),
+			Warnings:   r.Warnings,
+			Notes:      r.Notes,
+		}
+		if r.Err != nil {
+			data[i].Error = r.Err.Error()
+		}
+	}
+
+	encoder := json.NewEncoder(p.out)
+	encoder.SetIndent("", "    ")
+	_ = encoder.Encode(data)
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+	SystemErr string        `xml:"system-err,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// PrintJUnit prints the given assertion results as a JUnit XML report with a test case per action. Unexpected
+// subjects are reported as failures, actions which cannot be checked as errors, and actions which cannot be fully
+// checked due to missing permissions as skipped.
+func (p *Printer) PrintJUnit(results []AssertionResult) {
+	suite := junitTestSuite{
+		Name:  toolName,
+		Tests: len(results),
+		Cases: []junitTestCase{},
+	}
+	var total time.Duration
+	for _, r := range results {
+		total += r.Duration
+		tc := junitTestCase{
+			Name:      actionLabel(r.Spec.Action),
+			ClassName: toolName,
+			Time:      junitTime(r.Duration),
+		}
+
+		var subjects []string
+		for _, bs := range r.Subjects {
+			subjects = append(subjects, bindingSubjectString(bs))
+		}
+		if len(subjects) > 0 {
+			tc.SystemOut = strings.Join(subjects, "\n")
+		}
+		if len(r.Notes) > 0 {
+			tc.SystemErr = strings.Join(r.Notes, "\n")
+		}
+
+		switch r.Status() {
+		case AssertionError:
+			suite.Errors++
+			tc.Error = &junitMessage{Message: r.Err.Error()}
+		case AssertionFailed:
+			suite.Failures++
+			var unexpected []string
+			for _, bs := range r.Unexpected {
+				unexpected = append(unexpected, bindingSubjectString(bs))
+			}
+			tc.Failure = &junitMessage{
+				Message: fmt.Sprintf("%d unexpected subject(s) allowed to %s", len(r.Unexpected), r.Spec.Action),
+				Type:    "UnexpectedSubjects",
+				Text:    strings.Join(unexpected, "\n"),
+			}
+		case AssertionSkipped:
+			suite.Skipped++
+			tc.Skipped = &junitMessage{
+				Message: "The list might not be complete due to missing permission(s)",
+				Text:    strings.Join(r.Warnings, "\n"),
+			}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Time = junitTime(total)
+
+	_, _ = fmt.Fprint(p.out, xml.Header)
+	encoder := xml.NewEncoder(p.out)
+	encoder.Indent("", "  ")
+	_ = encoder.Encode(junitTestSuites{Suites: []junitTestSuite{suite}})
+	_, _ = fmt.Fprintln(p.out)
+}
+
+// NewAssertCommand constructs the assert command with the specified IOStreams and ConfigFlags.
+func NewAssertCommand(streams clioptions.IOStreams, configFlags *clioptions.ConfigFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          assertUsage,
+		Short:        "Assert who can perform a batch of actions",
+		Long:         assertLong,
+		Example:      assertExample,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := cmd.Flags().GetString(configFlag)
+			if err != nil {
+				return err
+			}
+			files, err := cmd.Flags().GetStringSlice(fromFileFlag)
+			if err != nil {
+				return err
+			}
+			output, err := cmd.Flags().GetString(outputFlag)
+			if err != nil {
+				return err
+			}
+			output = strings.ToLower(output)
+			if output != "" && output != outputJson && output != outputJUnit {
+				return fmt.Errorf("invalid output format: %v", output)
+			}
+
+			specs, err := ReadActionSpecs(config)
+			if err != nil {
+				return err
+			}
+
+			var asserter *Asserter
+			if len(files) > 0 {
+				manifests, err := LoadManifests(files)
+				if err != nil {
+					return err
+				}
+				o, err := newManifestsWhoCan(manifests)
+				if err != nil {
+					return err
+				}
+				asserter = NewAsserter(o, false)
+			} else {
+				o, err := NewWhoCan(configFlags, nil)
+				if err != nil {
+					return err
+				}
+				asserter = NewAsserter(o, true)
+			}
+
+			results := asserter.Run(specs)
+
+			printer := NewPrinter(streams.Out, false)
+			switch output {
+			case outputJson:
+				printer.ExportAssertions(results)
+			case outputJUnit:
+				printer.PrintJUnit(results)
+			default:
+				printer.PrintAssertions(results)
+			}
+
+			if failed := countFailed(results); failed > 0 {
+				return fmt.Errorf("%d of %d assertion(s) failed", failed, len(results))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String(configFlag, "", "Path to the file listing the actions to be checked")
+	cmd.Flags().StringSliceP(fromFileFlag, "f", nil, "Check RBAC objects defined in manifest files or directories instead of the cluster")
+	cmd.Flags().StringP(outputFlag, "o", "", "Output format. One of: json, junit.")
+	_ = cmd.MarkFlagRequired(configFlag)
+
+	return cmd
+}
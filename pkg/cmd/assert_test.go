@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	auditorsSubject = bindingSubject{
+		BindingKind: ClusterRoleBindingKind,
+		Binding:     "read-secrets",
+		RoleRef:     rbac.RoleRef{Kind: ClusterRoleKind, Name: "read-secrets"},
+		Subject:     rbac.Subject{Kind: rbac.GroupKind, Name: "auditors"},
+	}
+	aliceSubject = bindingSubject{
+		BindingKind: RoleBindingKind,
+		Binding:     "admins",
+		Namespace:   "foo",
+		RoleRef:     rbac.RoleRef{Kind: ClusterRoleKind, Name: "admin"},
+		Subject:     rbac.Subject{Kind: rbac.UserKind, Name: "alice"},
+	}
+
+	assertionResults = []AssertionResult{
+		{
+			Spec:     ActionSpec{Action: Action{Verb: "get", Resource: "secrets"}, Allowed: []rbac.Subject{{Kind: rbac.GroupKind, Name: "auditors"}}},
+			Subjects: []bindingSubject{auditorsSubject},
+			Duration: 10 * time.Millisecond,
+		},
+		{
+			Spec:       ActionSpec{Action: Action{Verb: "delete", Resource: "secrets", Namespace: "foo"}, Allowed: []rbac.Subject{}},
+			Subjects:   []bindingSubject{aliceSubject},
+			Unexpected: []bindingSubject{aliceSubject},
+			Duration:   20 * time.Millisecond,
+		},
+		{
+			Spec:     ActionSpec{Action: Action{Verb: "get", Resource: "foo"}},
+			Err:      errors.New("resolving resource: the server doesn't have a resource type \"foo\""),
+			Duration: 5 * time.Millisecond,
+		},
+		{
+			Spec:     ActionSpec{Action: Action{Verb: "list", Resource: "pods", Namespace: "bar"}},
+			Warnings: []string{"The user is not allowed to list rolebindings in the bar namespace"},
+			Duration: 2 * time.Millisecond,
+		},
+	}
+)
+
+func TestAssertionResult_Status(t *testing.T) {
+	assert.Equal(t, AssertionPassed, assertionResults[0].Status())
+	assert.Equal(t, AssertionFailed, assertionResults[1].Status())
+	assert.Equal(t, AssertionError, assertionResults[2].Status())
+	assert.Equal(t, AssertionSkipped, assertionResults[3].Status())
+	assert.Equal(t, 2, countFailed(assertionResults))
+}
+
+func TestAsserter_Run(t *testing.T) {
+	// given
+	source, err := NewStaticRBACSource(
+		&rbac.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "read-secrets"},
+			Rules:      []rbac.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}}},
+		},
+		&rbac.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "read-secrets"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "read-secrets"},
+			Subjects: []rbac.Subject{
+				{Kind: rbac.GroupKind, Name: "auditors"},
+				{Kind: rbac.ServiceAccountKind, Name: "backup", Namespace: "foo"},
+			},
+		},
+		&rbac.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "delete-secrets", Namespace: "prod"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "delete-secrets"},
+			Subjects:   []rbac.Subject{{Kind: rbac.UserKind, Name: "mallory"}},
+		},
+		&rbac.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "delete-secrets"},
+			Rules:      []rbac.PolicyRule{{Verbs: []string{"delete"}, APIGroups: []string{""}, Resources: []string{"secrets"}}},
+		},
+	)
+	require.NoError(t, err)
+	asserter := NewAsserter(NewOfflineWhoCan(source), false)
+	get := Action{Verb: "get", Resource: "secrets", AllNamespaces: true}
+
+	// when
+	results := asserter.Run([]ActionSpec{
+		{Action: get},
+		{Action: get, Allowed: []rbac.Subject{
+			{Kind: rbac.GroupKind, Name: "auditors"},
+			{Kind: rbac.ServiceAccountKind, Name: "backup", Namespace: "bar"},
+		}},
+		{Action: Action{Verb: "delete", Resource: "secrets", AllNamespaces: true}, Allowed: []rbac.Subject{}},
+	})
+
+	// then
+	require.Len(t, results, 3)
+
+	assert.Equal(t, AssertionPassed, results[0].Status())
+	assert.Len(t, results[0].Subjects, 2)
+	assert.Empty(t, results[0].Unexpected)
+
+	assert.Equal(t, AssertionFailed, results[1].Status())
+	require.Len(t, results[1].Unexpected, 1)
+	assert.Equal(t, rbac.Subject{Kind: rbac.ServiceAccountKind, Name: "backup", Namespace: "foo"}, results[1].Unexpected[0].Subject)
+
+	assert.Equal(t, AssertionFailed, results[2].Status())
+	require.Len(t, results[2].Unexpected, 1)
+	assert.Equal(t, "prod", results[2].Unexpected[0].Namespace)
+	assert.Equal(t, rbac.Subject{Kind: rbac.UserKind, Name: "mallory"}, results[2].Unexpected[0].Subject)
+}
+
+func TestAsserter_Run_SkippedAPIGroups(t *testing.T) {
+	// given
+	source, err := NewStaticRBACSource()
+	require.NoError(t, err)
+	note := "Skipping the API group metrics.k8s.io/v1beta1 of the resource pods: getting API groups: not found"
+	resourceResolver := new(resourceResolverMock)
+	resourceResolver.On("Resolve", "list", "pods", "", true).Return([]schema.GroupResource{{Resource: "pods"}}, []string{note}, nil).Once()
+	whoCan := NewOfflineWhoCan(source)
+	whoCan.resourceResolver = resourceResolver
+
+	// when
+	results := NewAsserter(whoCan, false).Run([]ActionSpec{
+		{Action: Action{Verb: "list", Resource: "pods", Namespace: "foo", AllAPIGroups: true}, Allowed: []rbac.Subject{}},
+	})
+
+	// then
+	require.Len(t, results, 1)
+	assert.Equal(t, AssertionPassed, results[0].Status())
+	assert.Empty(t, results[0].Warnings)
+	assert.Equal(t, []string{note}, results[0].Notes)
+	resourceResolver.AssertExpectations(t)
+
+	// when
+	var buf bytes.Buffer
+	NewPrinter(&buf, false).PrintAssertions(results)
+
+	// then
+	assert.Contains(t, buf.String(), "\nlist pods -n foo: resolving the API groups of the resource:\n\t"+note+"\n")
+}
+
+func TestPrinter_PrintAssertions(t *testing.T) {
+	var buf bytes.Buffer
+	NewPrinter(&buf, false).PrintAssertions(assertionResults)
+
+	assert.Equal(t, `STATUS  ACTION                 SUBJECTS  UNEXPECTED
+PASS    get secrets            1         0
+FAIL    delete secrets -n foo  1         1
+ERROR   get foo                0         0
+SKIP    list pods -n bar       0         0
+
+delete secrets -n foo: unexpected subject(s):
+	User alice through RoleBinding foo/admins (ClusterRole admin)
+
+get foo: resolving resource: the server doesn't have a resource type "foo"
+
+list pods -n bar: the list might not be complete due to missing permission(s):
+	The user is not allowed to list rolebindings in the bar namespace
+`, buf.String())
+}
+
+func TestPrinter_ExportAssertions(t *testing.T) {
+	var buf bytes.Buffer
+	NewPrinter(&buf, false).ExportAssertions(assertionResults[1:3])
+
+	assert.JSONEq(t, `[
+  {
+    "action": {"verb": "delete", "resource": "secrets", "namespace": "foo"},
+    "status": "FAIL",
+    "subjects": [{"bindingKind": "RoleBinding", "binding": "admins", "namespace": "foo", "roleRef": {"apiGroup": "", "kind": "ClusterRole", "name": "admin"}, "subject": {"kind": "User", "name": "alice"}}],
+    "unexpected": [{"bindingKind": "RoleBinding", "binding": "admins", "namespace": "foo", "roleRef": {"apiGroup": "", "kind": "ClusterRole", "name": "admin"}, "subject": {"kind": "User", "name": "alice"}}]
+  },
+  {
+    "action": {"verb": "get", "resource": "foo"},
+    "status": "ERROR",
+    "subjects": [],
+    "unexpected": [],
+    "error": "resolving resource: the server doesn't have a resource type \"foo\""
+  }
+]`, buf.String())
+}
+
+func TestPrinter_PrintJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	NewPrinter(&buf, false).PrintJUnit(assertionResults)
+
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="kubectl-who-can" tests="4" failures="1" errors="1" skipped="1" time="0.037">
+    <testcase name="get secrets" classname="kubectl-who-can" time="0.010">
+      <system-out>Group auditors through ClusterRoleBinding read-secrets (ClusterRole read-secrets)</system-out>
+    </testcase>
+    <testcase name="delete secrets -n foo" classname="kubectl-who-can" time="0.020">
+      <failure message="1 unexpected subject(s) allowed to delete secrets" type="UnexpectedSubjects">User alice through RoleBinding foo/admins (ClusterRole admin)</failure>
+      <system-out>User alice through RoleBinding foo/admins (ClusterRole admin)</system-out>
+    </testcase>
+    <testcase name="get foo" classname="kubectl-who-can" time="0.005">
+      <error message="resolving resource: the server doesn&#39;t have a resource type &#34;foo&#34;"></error>
+    </testcase>
+    <testcase name="list pods -n bar" classname="kubectl-who-can" time="0.002">
+      <skipped message="The list might not be complete due to missing permission(s)">The user is not allowed to list rolebindings in the bar namespace</skipped>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, buf.String())
+}
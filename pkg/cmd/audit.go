@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	rbac "k8s.io/api/rbac/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	auditEventKind       = "Event"
+	auditAPIVersion      = "audit.k8s.io/v1"
+	auditStageComplete   = "ResponseComplete"
+	serviceAccountPrefix = "system:serviceaccount:"
+)
+
+// maxAuditEventSize is the maximum size of a line of an audit log file. Events with request and response bodies may
+// be much longer than the default limit of bufio.Scanner.
+const maxAuditEventSize = 16 * 1024 * 1024
+
+// AuditEvent is the subset of an audit.k8s.io/v1 Event describing who performed which request.
+type AuditEvent struct {
+	Kind             string             `json:"kind"`
+	APIVersion       string             `json:"apiVersion"`
+	Stage            string             `json:"stage"`
+	RequestURI       string             `json:"requestURI"`
+	Verb             string             `json:"verb"`
+	User             AuditUser          `json:"user"`
+	ImpersonatedUser *AuditUser         `json:"impersonatedUser,omitempty"`
+	ObjectRef        *AuditObjectRef    `json:"objectRef,omitempty"`
+	ResponseStatus   *AuditResponseCode `json:"responseStatus,omitempty"`
+}
+
+// AuditUser is the user information of an audit Event.
+type AuditUser struct {
+	Username string   `json:"username"`
+	Groups   []string `json:"groups,omitempty"`
+}
+
+// AuditObjectRef is the object reference of an audit Event.
+type AuditObjectRef struct {
+	Resource    string `json:"resource,omitempty"`
+	Namespace   string `json:"namespace,omitempty"`
+	Name        string `json:"name,omitempty"`
+	APIGroup    string `json:"apiGroup,omitempty"`
+	Subresource string `json:"subresource,omitempty"`
+}
+
+// AuditResponseCode is the response status of an audit Event.
+type AuditResponseCode struct {
+	Code int `json:"code"`
+}
+
+// ReadAuditLog reads the apiserver audit log files with the specified paths. Each line of a file is expected to be a
+// JSON encoded audit.k8s.io/v1 Event. Only events of the ResponseComplete stage are returned so that every request is
+// reported once, and lines which are not audit events are skipped.
+func ReadAuditLog(paths []string) ([]AuditEvent, error) {
+	var events []AuditEvent
+	for _, path := range paths {
+		fileEvents, err := readAuditLogFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading audit log: %v", err)
+		}
+		events = append(events, fileEvents...)
+	}
+	return events, nil
+}
+
+func readAuditLogFile(path string) ([]AuditEvent, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var events []AuditEvent
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxAuditEventSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := strings.TrimSpace(scanner.Text())
+		if data == "" {
+			continue
+		}
+		var event AuditEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		if event.Kind != auditEventKind || event.APIVersion != auditAPIVersion {
+			klog.V(4).Infof("Ignoring %s %s in %s:%d", event.APIVersion, event.Kind, path, line)
+			continue
+		}
+		if event.Stage != auditStageComplete {
+			continue
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s:%d: %v", path, line+1, err)
+	}
+	return events, nil
+}
+
+// Action returns the Action requested by the event. The resource is qualified by the API group, e.g.
+// `deployments.apps`, and the namespace is empty for cluster-wide requests.
+func (e AuditEvent) Action() Action {
+	action := Action{Verb: e.Verb}
+	if e.ObjectRef == nil || e.ObjectRef.Resource == "" {
+		action.NonResourceURL = e.RequestURI
+		if u, err := url.Parse(e.RequestURI); err == nil {
+			action.NonResourceURL = u.Path
+		}
+		return action
+	}
+
+	action.Resource = e.ObjectRef.Resource
+	if e.ObjectRef.APIGroup != "" {
+		action.Resource += "." + e.ObjectRef.APIGroup
+	}
+	action.SubResource = e.ObjectRef.Subresource
+	action.ResourceName = e.ObjectRef.Name
+	action.Namespace = e.ObjectRef.Namespace
+	action.AllNamespaces = action.Namespace == ""
+	return action
+}
+
+// EffectiveUser returns the impersonated user if the request was impersonated, or the authenticated user otherwise.
+func (e AuditEvent) EffectiveUser() AuditUser {
+	if e.ImpersonatedUser != nil {
+		return *e.ImpersonatedUser
+	}
+	return e.User
+}
+
+// PerformedBy returns true if the request was performed by the given User or ServiceAccount, or by a member of the
+// given Group.
+func (e AuditEvent) PerformedBy(subject rbac.Subject) bool {
+	user := e.EffectiveUser()
+	switch subject.Kind {
+	case rbac.GroupKind:
+		for _, group := range user.Groups {
+			if group == subject.Name {
+				return true
+			}
+		}
+		return false
+	case rbac.ServiceAccountKind:
+		return user.Username == serviceAccountPrefix+subject.Namespace+":"+subject.Name
+	default:
+		return user.Username == subject.Name
+	}
+}
+
+// Allowed returns true unless the request was rejected because the user was not authenticated or not authorized.
+func (e AuditEvent) Allowed() bool {
+	if e.ResponseStatus == nil {
+		return true
+	}
+	return e.ResponseStatus.Code != http.StatusUnauthorized && e.ResponseStatus.Code != http.StatusForbidden
+}
+
+// SubjectOf returns the ServiceAccount or User which performed the request.
+func (e AuditEvent) SubjectOf() rbac.Subject {
+	username := e.EffectiveUser().Username
+	if strings.HasPrefix(username, serviceAccountPrefix) {
+		tokens := strings.SplitN(strings.TrimPrefix(username, serviceAccountPrefix), ":", 2)
+		if len(tokens) == 2 {
+			return rbac.Subject{Kind: rbac.ServiceAccountKind, Namespace: tokens[0], Name: tokens[1]}
+		}
+	}
+	return rbac.Subject{Kind: rbac.UserKind, APIGroup: rbac.GroupName, Name: username}
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbac "k8s.io/api/rbac/v1"
+)
+
+const auditLog = `{"kind":"Event","apiVersion":"audit.k8s.io/v1","stage":"RequestReceived","requestURI":"/api/v1/namespaces/foo/secrets/db","verb":"get","user":{"username":"system:serviceaccount:foo:operator"},"objectRef":{"resource":"secrets","namespace":"foo","name":"db","apiVersion":"v1"}}
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","stage":"ResponseComplete","requestURI":"/api/v1/namespaces/foo/secrets/db","verb":"get","user":{"username":"system:serviceaccount:foo:operator","groups":["system:serviceaccounts","system:serviceaccounts:foo"]},"objectRef":{"resource":"secrets","namespace":"foo","name":"db","apiVersion":"v1"},"responseStatus":{"code":200}}
+
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","stage":"ResponseComplete","requestURI":"/apis/apps/v1/deployments?limit=500","verb":"list","user":{"username":"alice","groups":["developers"]},"objectRef":{"resource":"deployments","apiGroup":"apps","apiVersion":"v1"},"responseStatus":{"code":403}}
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","stage":"ResponseComplete","requestURI":"/healthz?verbose","verb":"get","user":{"username":"admin"},"impersonatedUser":{"username":"bob","groups":["developers"]},"responseStatus":{"code":200}}
+{"kind":"Policy","apiVersion":"audit.k8s.io/v1"}
+`
+
+func TestReadAuditLog(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "audit.log")
+	writeManifest(t, path, auditLog)
+
+	// when
+	events, err := ReadAuditLog([]string{path})
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, Action{Verb: "get", Resource: "secrets", ResourceName: "db", Namespace: "foo"}, events[0].Action())
+	assert.Equal(t, Action{Verb: "list", Resource: "deployments.apps", AllNamespaces: true}, events[1].Action())
+	assert.Equal(t, Action{Verb: "get", NonResourceURL: "/healthz"}, events[2].Action())
+	assert.Equal(t, 403, events[1].ResponseStatus.Code)
+
+	operator := rbac.Subject{Kind: rbac.ServiceAccountKind, Namespace: "foo", Name: "operator"}
+	developers := rbac.Subject{Kind: rbac.GroupKind, Name: "developers"}
+	assert.True(t, events[0].PerformedBy(operator))
+	assert.False(t, events[0].PerformedBy(rbac.Subject{Kind: rbac.ServiceAccountKind, Namespace: "bar", Name: "operator"}))
+	assert.True(t, events[1].PerformedBy(rbac.Subject{Kind: rbac.UserKind, Name: "alice"}))
+	assert.True(t, events[1].PerformedBy(developers))
+	assert.True(t, events[2].PerformedBy(rbac.Subject{Kind: rbac.UserKind, Name: "bob"}), "impersonated user")
+	assert.False(t, events[2].PerformedBy(rbac.Subject{Kind: rbac.UserKind, Name: "admin"}))
+}
+
+func TestReadAuditLog_Errors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+	writeManifest(t, path, "{\"kind\":\"Event\"}\nnot json\n")
+
+	_, err := ReadAuditLog([]string{path})
+	assert.EqualError(t, err, "reading audit log: "+path+":2: invalid character 'o' in literal null (expecting 'u')")
+
+	_, err = ReadAuditLog([]string{filepath.Join(dir, "missing.log")})
+	assert.Error(t, err)
+}
+
+func TestAuditEvent_Allowed(t *testing.T) {
+	assert.True(t, AuditEvent{}.Allowed())
+	assert.True(t, AuditEvent{ResponseStatus: &AuditResponseCode{Code: 404}}.Allowed())
+	assert.False(t, AuditEvent{ResponseStatus: &AuditResponseCode{Code: 401}}.Allowed())
+	assert.False(t, AuditEvent{ResponseStatus: &AuditResponseCode{Code: 403}}.Allowed())
+}
+
+func TestAuditEvent_SubjectOf(t *testing.T) {
+	assert.Equal(t, rbac.Subject{Kind: rbac.ServiceAccountKind, Namespace: "foo", Name: "operator"},
+		AuditEvent{User: AuditUser{Username: "system:serviceaccount:foo:operator"}}.SubjectOf())
+	assert.Equal(t, rbac.Subject{Kind: rbac.UserKind, APIGroup: rbac.GroupName, Name: "bob"},
+		AuditEvent{User: AuditUser{Username: "admin"}, ImpersonatedUser: &AuditUser{Username: "bob"}}.SubjectOf())
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/spf13/pflag"
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	clientcore "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	awsAuthFlag     = "aws-auth"
+	awsAuthFileFlag = "aws-auth-file"
+
+	awsAuthNamespace = "kube-system"
+	awsAuthName      = "aws-auth"
+	configMapKind    = "ConfigMap"
+)
+
+// awsAuthUsernameVariable matches the placeholders of usernames in the aws-auth ConfigMap, e.g. {{SessionName}},
+// which are replaced by the IAM authenticator when a principal signs in.
+var awsAuthUsernameVariable = regexp.MustCompile(`\\\{\\\{[A-Za-z0-9]+\\\}\\\}`)
+
+// AWSAuthMapping is an entry of the mapRoles or mapUsers lists of the aws-auth ConfigMap, which maps an IAM role or
+// user onto a Kubernetes user and groups.
+type AWSAuthMapping struct {
+	RoleARN  string   `json:"rolearn,omitempty"`
+	UserARN  string   `json:"userarn,omitempty"`
+	Username string   `json:"username,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+}
+
+// ARN returns the ARN of the mapped IAM role or user.
+func (m AWSAuthMapping) ARN() string {
+	if m.RoleARN != "" {
+		return m.RoleARN
+	}
+	return m.UserARN
+}
+
+// AWSAuth holds the IAM identities mapped onto Kubernetes users and groups by the aws-auth ConfigMap of an EKS
+// cluster.
+type AWSAuth struct {
+	mappings  []AWSAuthMapping
+	usernames []*regexp.Regexp
+}
+
+// NewAWSAuth parses the mapRoles and mapUsers entries of the specified aws-auth ConfigMap.
+func NewAWSAuth(cm core.ConfigMap) (*AWSAuth, error) {
+	a := &AWSAuth{}
+	for _, key := range []string{"mapRoles", "mapUsers"} {
+		var mappings []AWSAuthMapping
+		if err := yaml.Unmarshal([]byte(cm.Data[key]), &mappings); err != nil {
+			return nil, fmt.Errorf("parsing %s of ConfigMap %s/%s: %v", key, cm.Namespace, cm.Name, err)
+		}
+		for _, m := range mappings {
+			if m.ARN() == "" {
+				continue
+			}
+			// The IAM authenticator uses the ARN as the username if none is mapped.
+			username := m.Username
+			if username == "" {
+				username = m.ARN()
+			}
+			pattern := awsAuthUsernameVariable.ReplaceAllString(regexp.QuoteMeta(username), ".+")
+			a.mappings = append(a.mappings, m)
+			a.usernames = append(a.usernames, regexp.MustCompile("^"+pattern+"$"))
+		}
+	}
+	return a, nil
+}
+
+// GetAWSAuth gets the aws-auth ConfigMap from the kube-system namespace with the specified client.
+func GetAWSAuth(client clientcore.ConfigMapsGetter) (*AWSAuth, error) {
+	cm, err := client.ConfigMaps(awsAuthNamespace).Get(context.Background(), awsAuthName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("getting ConfigMap %s/%s: %v", awsAuthNamespace, awsAuthName, err)
+	}
+	return NewAWSAuth(*cm)
+}
+
+// ReadAWSAuthFile reads the aws-auth ConfigMap from the manifest files with the specified paths.
+func ReadAWSAuthFile(paths []string) (*AWSAuth, error) {
+	manifests, err := LoadManifests(paths)
+	if err != nil {
+		return nil, err
+	}
+	return manifests.AWSAuth()
+}
+
+// ARNsFor returns the sorted ARNs of the IAM roles and users mapped onto the given User or Group.
+func (a *AWSAuth) ARNsFor(subject rbac.Subject) []string {
+	var arns []string
+	for i, m := range a.mappings {
+		var mapped bool
+		switch subject.Kind {
+		case rbac.UserKind:
+			mapped = a.usernames[i].MatchString(subject.Name)
+		case rbac.GroupKind:
+			mapped = containsString(m.Groups, subject.Name)
+		}
+		if mapped {
+			arns = appendUnique(arns, m.ARN())
+		}
+	}
+	sort.Strings(arns)
+	return arns
+}
+
+// SetAWSAuth makes the Printer show the IAM identities mapped onto Group and User subjects.
+func (p *Printer) SetAWSAuth(awsAuth *AWSAuth) {
+	p.awsAuth = awsAuth
+}
+
+// AWSAuthFrom returns the IAM identity mappings requested by the --aws-auth and --aws-auth-file flags, or nil if
+// neither is set. With --aws-auth, the ConfigMap is read from the given manifests if not nil, or from the cluster.
+func AWSAuthFrom(flags *pflag.FlagSet, manifests *Manifests, restConfig func() (*rest.Config, error)) (*AWSAuth, error) {
+	files, err := flags.GetStringSlice(awsAuthFileFlag)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) > 0 {
+		return ReadAWSAuthFile(files)
+	}
+
+	enabled, err := flags.GetBool(awsAuthFlag)
+	if err != nil || !enabled {
+		return nil, err
+	}
+	if manifests != nil {
+		return manifests.AWSAuth()
+	}
+	config, err := restConfig()
+	if err != nil {
+		return nil, fmt.Errorf("getting rest config: %v", err)
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("creating API client: %v", err)
+	}
+	return GetAWSAuth(client.CoreV1())
+}
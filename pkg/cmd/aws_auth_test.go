@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/yaml"
+)
+
+const awsAuthManifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: aws-auth
+  namespace: kube-system
+data:
+  mapRoles: |
+    - rolearn: arn:aws:iam::111122223333:role/eks-nodes
+      username: system:node:{{EC2PrivateDNSName}}
+      groups:
+      - system:bootstrappers
+      - system:nodes
+    - rolearn: arn:aws:iam::111122223333:role/Admin
+      username: admin:{{SessionName}}
+      groups:
+      - system:masters
+    - rolearn: arn:aws:iam::111122223333:role/Developer
+      groups:
+      - eks-developers
+  mapUsers: |
+    - userarn: arn:aws:iam::111122223333:user/alice
+      username: alice
+      groups:
+      - system:masters
+      - eks-developers
+`
+
+func TestAWSAuth_ARNsFor(t *testing.T) {
+	// given
+	var cm core.ConfigMap
+	require.NoError(t, yaml.Unmarshal([]byte(awsAuthManifest), &cm))
+
+	// when
+	awsAuth, err := NewAWSAuth(cm)
+
+	// then
+	require.NoError(t, err)
+	data := []struct {
+		subject rbac.Subject
+		arns    []string
+	}{
+		{subject: rbac.Subject{Kind: rbac.GroupKind, Name: "system:masters"}, arns: []string{"arn:aws:iam::111122223333:role/Admin", "arn:aws:iam::111122223333:user/alice"}},
+		{subject: rbac.Subject{Kind: rbac.GroupKind, Name: "eks-developers"}, arns: []string{"arn:aws:iam::111122223333:role/Developer", "arn:aws:iam::111122223333:user/alice"}},
+		{subject: rbac.Subject{Kind: rbac.UserKind, Name: "alice"}, arns: []string{"arn:aws:iam::111122223333:user/alice"}},
+		{subject: rbac.Subject{Kind: rbac.UserKind, Name: "admin:jane"}, arns: []string{"arn:aws:iam::111122223333:role/Admin"}},
+		{subject: rbac.Subject{Kind: rbac.UserKind, Name: "system:node:ip-10-0-0-1.ec2.internal"}, arns: []string{"arn:aws:iam::111122223333:role/eks-nodes"}},
+		{subject: rbac.Subject{Kind: rbac.UserKind, Name: "arn:aws:iam::111122223333:role/Developer"}, arns: []string{"arn:aws:iam::111122223333:role/Developer"}},
+		{subject: rbac.Subject{Kind: rbac.UserKind, Name: "admin:"}},
+		{subject: rbac.Subject{Kind: rbac.ServiceAccountKind, Namespace: "kube-system", Name: "alice"}},
+	}
+	for _, tt := range data {
+		t.Run(tt.subject.Name, func(t *testing.T) {
+			assert.Equal(t, tt.arns, awsAuth.ARNsFor(tt.subject))
+		})
+	}
+
+	_, err = NewAWSAuth(core.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "aws-auth", Namespace: "kube-system"},
+		Data:       map[string]string{"mapUsers": "userarn: foo"},
+	})
+	assert.EqualError(t, err, "parsing mapUsers of ConfigMap kube-system/aws-auth: error unmarshaling JSON: while decoding JSON: json: cannot unmarshal object into Go value of type []cmd.AWSAuthMapping")
+}
+
+func TestGetAWSAuth(t *testing.T) {
+	// given
+	client := fake.NewSimpleClientset(&core.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "aws-auth", Namespace: "kube-system"},
+		Data:       map[string]string{"mapRoles": "- rolearn: arn:aws:iam::111122223333:role/Admin\n  groups: [system:masters]\n"},
+	})
+
+	// when
+	awsAuth, err := GetAWSAuth(client.CoreV1())
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"arn:aws:iam::111122223333:role/Admin"}, awsAuth.ARNsFor(rbac.Subject{Kind: rbac.GroupKind, Name: "system:masters"}))
+
+	_, err = GetAWSAuth(fake.NewSimpleClientset().CoreV1())
+	assert.EqualError(t, err, `getting ConfigMap kube-system/aws-auth: configmaps "aws-auth" not found`)
+}
+
+func TestReadAWSAuthFile(t *testing.T) {
+	// given
+	dir := t.TempDir()
+	path := filepath.Join(dir, "aws-auth.yaml")
+	writeManifest(t, path, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: other\n  namespace: kube-system\n---\n"+awsAuthManifest)
+
+	// when
+	awsAuth, err := ReadAWSAuthFile([]string{path})
+
+	// then
+	require.NoError(t, err)
+	assert.Len(t, awsAuth.mappings, 4)
+
+	// when
+	_, err = ReadAWSAuthFile([]string{dir + "/missing.yaml"})
+
+	// then
+	assert.Error(t, err)
+
+	// when
+	empty := filepath.Join(dir, "empty.yaml")
+	writeManifest(t, empty, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: other\n  namespace: kube-system\n")
+	_, err = ReadAWSAuthFile([]string{empty})
+
+	// then
+	assert.EqualError(t, err, "ConfigMap kube-system/aws-auth not found in manifests")
+}
+
+func TestPrinter_AWSAuth(t *testing.T) {
+	// given
+	var cm core.ConfigMap
+	require.NoError(t, yaml.Unmarshal([]byte(awsAuthManifest), &cm))
+	awsAuth, err := NewAWSAuth(cm)
+	require.NoError(t, err)
+	roleBindings := []rbac.RoleBinding{{
+		ObjectMeta: metav1.ObjectMeta{Name: "developers", Namespace: "foo"},
+		Subjects: []rbac.Subject{
+			{Kind: rbac.GroupKind, Name: "eks-developers"},
+			{Kind: rbac.ServiceAccountKind, Namespace: "foo", Name: "ci"},
+		},
+	}}
+	clusterRoleBindings := []rbac.ClusterRoleBinding{{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin"},
+		Subjects:   []rbac.Subject{{Kind: rbac.GroupKind, Name: "system:masters"}},
+	}}
+	action := Action{Verb: "get", Resource: "pods", Namespace: "foo"}
+
+	// when
+	var buf bytes.Buffer
+	printer := NewPrinter(&buf, false)
+	printer.SetAWSAuth(awsAuth)
+	printer.PrintChecks(action, roleBindings, clusterRoleBindings)
+
+	// then
+	assert.Equal(t, `ROLEBINDING  NAMESPACE  SUBJECT         TYPE            SA-NAMESPACE  AWS-IDENTITIES
+developers   foo        ci              ServiceAccount  foo           
+developers   foo        eks-developers  Group                         arn:aws:iam::111122223333:role/Developer,arn:aws:iam::111122223333:user/alice
+
+CLUSTERROLEBINDING  SUBJECT         TYPE   SA-NAMESPACE  AWS-IDENTITIES
+cluster-admin       system:masters  Group                arn:aws:iam::111122223333:role/Admin,arn:aws:iam::111122223333:user/alice
+`, buf.String())
+
+	// when
+	buf.Reset()
+	printer.ExportData(action, roleBindings, clusterRoleBindings)
+
+	// then
+	assert.JSONEq(t, `{
+  "roleBindings": [{
+    "name": "developers",
+    "roleRef": {"apiGroup": "", "kind": "", "name": ""},
+    "subjects": [
+      {"kind": "ServiceAccount", "name": "ci", "namespace": "foo"},
+      {"kind": "Group", "name": "eks-developers", "awsIdentities": ["arn:aws:iam::111122223333:role/Developer", "arn:aws:iam::111122223333:user/alice"]}
+    ]
+  }],
+  "clusterRoleBindings": [{
+    "name": "cluster-admin",
+    "roleRef": {"apiGroup": "", "kind": "", "name": ""},
+    "subjects": [
+      {"kind": "Group", "name": "system:masters", "awsIdentities": ["arn:aws:iam::111122223333:role/Admin", "arn:aws:iam::111122223333:user/alice"]}
+    ]
+  }]
+}`, buf.String())
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+const byNamespaceFlag = "by-namespace"
+
+// NamespaceAccess tells how a subject is granted an action in a namespace.
+type NamespaceAccess string
+
+// Namespace access kinds and the marks printed for them.
+const (
+	AccessRoleBinding        NamespaceAccess = RoleBindingKind
+	AccessClusterRoleBinding NamespaceAccess = ClusterRoleBindingKind
+	AccessNone               NamespaceAccess = ""
+
+	markRoleBinding        = "RB"
+	markClusterRoleBinding = "CRB"
+	markNone               = "-"
+)
+
+// AccessIn returns how the action is granted to the subject in the specified namespace. A ClusterRoleBinding takes
+// precedence over RoleBindings since it grants the action in all namespaces.
+func (g SubjectGrant) AccessIn(namespace string) NamespaceAccess {
+	switch {
+	case g.ClusterWide:
+		return AccessClusterRoleBinding
+	case containsString(g.Namespaces, namespace):
+		return AccessRoleBinding
+	}
+	return AccessNone
+}
+
+// mark returns the mark printed in the cell of the namespace access.
+func (a NamespaceAccess) mark() string {
+	switch a {
+	case AccessRoleBinding:
+		return markRoleBinding
+	case AccessClusterRoleBinding:
+		return markClusterRoleBinding
+	}
+	return markNone
+}
+
+// ByNamespace returns the specified Action checked in each namespace along with the namespaces in which it is checked.
+// An Action checked in all namespaces is turned into one checked in each existing namespace, so that RoleBindings are
+// checked as well. Without access to the API server, the namespaces are the ones in which RoleBindings are defined.
+func (w *WhoCan) ByNamespace(action Action) (Action, []string, error) {
+	if action.AllNamespaces {
+		namespaces, err := w.allNamespaces(action)
+		if err != nil {
+			return Action{}, nil, err
+		}
+		action.Namespace = core.NamespaceAll
+		action.Namespaces = namespaces
+		klog.V(3).Infof("Resolved namespaces `%v` for --%s", namespaces, byNamespaceFlag)
+	}
+
+	namespaces, err := w.namespacesOf(action)
+	if err != nil {
+		return Action{}, nil, err
+	}
+	return action, namespaces, nil
+}
+
+// expandAllNamespaces returns the specified Action checked in each existing namespace if it is checked in all
+// namespaces, so that RoleBindings are checked along with ClusterRoleBindings. Non-resource URLs are left as is since
+// they can only be granted by ClusterRoleBindings.
+func (w *WhoCan) expandAllNamespaces(action Action) (Action, error) {
+	if !action.AllNamespaces || action.NonResourceURL != "" {
+		return action, nil
+	}
+	action, _, err := w.ByNamespace(action)
+	return action, err
+}
+
+// allNamespaces returns the sorted names of the existing namespaces, or of the namespaces in which RoleBindings
+// matching the binding selector of the specified Action are defined if there is no access to the API server.
+func (w *WhoCan) allNamespaces(action Action) ([]string, error) {
+	var namespaces []string
+	if w.clientNamespace != nil {
+		nsList, err := w.clientNamespace.List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("listing namespaces: %v", err)
+		}
+		for _, ns := range nsList.Items {
+			namespaces = append(namespaces, ns.Name)
+		}
+	} else {
+		resolved, err := w.resolve(Action{RoleSelector: action.RoleSelector, BindingSelector: action.BindingSelector})
+		if err != nil {
+			return nil, err
+		}
+		roleBindings, err := w.rbacSource.ListRoleBindings(core.NamespaceAll, resolved.bindings())
+		if err != nil {
+			return nil, fmt.Errorf("listing RoleBindings: %v", err)
+		}
+		for _, rb := range roleBindings {
+			namespaces = appendUnique(namespaces, rb.Namespace)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+// PrintNamespaceAccess prints a grid of the subjects and the namespaces in which they are granted the action, marking
+// access through a RoleBinding with RB, access through a ClusterRoleBinding with CRB and no access with -.
+func (p *Printer) PrintNamespaceAccess(action Action, namespaces []string, grants []SubjectGrant) {
+	if len(grants) == 0 {
+		_, _ = fmt.Fprintf(p.out, "No subjects found with permissions to %s\n", action)
+		return
+	}
+
+	wr := new(tabwriter.Writer)
+	wr.Init(p.out, 0, 8, 2, ' ', 0)
+
+	columns := append([]string{"SUBJECT", "TYPE", "SA-NAMESPACE"}, namespaces...)
+	columns = append(columns, p.subjectColumnHeaders()...)
+	_, _ = fmt.Fprintln(wr, strings.Join(columns, "\t"))
+
+	format := "%s\t%s\t%s" + strings.Repeat("\t%s", len(namespaces))
+	for _, g := range grants {
+		args := []interface{}{g.Subject.Name, g.Subject.Kind, g.Subject.Namespace}
+		for _, namespace := range namespaces {
+			args = append(args, g.AccessIn(namespace).mark())
+		}
+		p.printRow(wr, format, args, g.Subject)
+	}
+	_ = wr.Flush()
+}
+
+// namespaceAccessData is a subject along with the kind of binding granting it the action in each namespace.
+type namespaceAccessData struct {
+	subjectData
+	Access map[string]NamespaceAccess `json:"access"`
+}
+
+// ExportNamespaceAccess exports the namespaces and the subjects along with the kind of binding granting them the
+// action in each namespace as JSON, where a namespace without access is omitted.
+func (p *Printer) ExportNamespaceAccess(namespaces []string, grants []SubjectGrant, hidden HiddenSubjects) {
+	data := make(map[string]interface{})
+	data["namespaces"] = append([]string{}, namespaces...)
+
+	subjects := make([]namespaceAccessData, 0, len(grants))
+	for _, g := range grants {
+		access := make(map[string]NamespaceAccess)
+		for _, namespace := range namespaces {
+			if a := g.AccessIn(namespace); a != AccessNone {
+				access[namespace] = a
+			}
+		}
+		subjects = append(subjects, namespaceAccessData{subjectData: p.subjectData(g.Subject), Access: access})
+	}
+	data["subjects"] = subjects
+
+	if hidden.Total > 0 {
+		data["hidden"] = hidden
+	}
+
+	encoder := json.NewEncoder(p.out)
+	encoder.SetIndent("", "    ")
+	_ = encoder.Encode(data)
+}
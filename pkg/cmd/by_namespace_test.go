@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var byNamespaceRoleBindings = []*rbac.RoleBinding{
+	{
+		ObjectMeta: metav1.ObjectMeta{Name: "read-secrets", Namespace: "foo"},
+		RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "secret-reader"},
+		Subjects:   []rbac.Subject{{Kind: rbac.UserKind, Name: "alice"}},
+	},
+	{
+		ObjectMeta: metav1.ObjectMeta{Name: "read-secrets", Namespace: "bar"},
+		RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "secret-reader"},
+		Subjects:   []rbac.Subject{{Kind: rbac.UserKind, Name: "alice"}, {Kind: rbac.ServiceAccountKind, Namespace: "bar", Name: "backup"}},
+	},
+}
+
+func TestWhoCan_ByNamespace(t *testing.T) {
+	// given
+	source, err := NewStaticRBACSource(
+		&rbac.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "secret-reader"},
+			Rules:      []rbac.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}}},
+		},
+		byNamespaceRoleBindings[0],
+		byNamespaceRoleBindings[1],
+		&rbac.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "read-secrets"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "secret-reader"},
+			Subjects:   []rbac.Subject{{Kind: rbac.GroupKind, Name: "auditors"}},
+		},
+	)
+	require.NoError(t, err)
+	wc := NewOfflineWhoCan(source)
+
+	// when
+	action, namespaces, err := wc.ByNamespace(Action{Verb: "get", Resource: "secrets", AllNamespaces: true})
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bar", "foo"}, namespaces)
+	assert.Equal(t, []string{"bar", "foo"}, action.Namespaces)
+
+	// when
+	roleBindings, clusterRoleBindings, err := wc.Check(action)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []SubjectGrant{
+		{
+			Subject:     rbac.Subject{Kind: rbac.GroupKind, Name: "auditors"},
+			ClusterWide: true,
+			Bindings:    []GrantingBinding{{Kind: ClusterRoleBindingKind, Name: "read-secrets", RoleRef: rbac.RoleRef{Kind: ClusterRoleKind, Name: "secret-reader"}}},
+		},
+		{
+			Subject:    rbac.Subject{Kind: rbac.ServiceAccountKind, Namespace: "bar", Name: "backup"},
+			Namespaces: []string{"bar"},
+			Bindings:   []GrantingBinding{{Kind: RoleBindingKind, Name: "read-secrets", Namespace: "bar", RoleRef: rbac.RoleRef{Kind: ClusterRoleKind, Name: "secret-reader"}}},
+		},
+		{
+			Subject:    rbac.Subject{Kind: rbac.UserKind, Name: "alice"},
+			Namespaces: []string{"bar", "foo"},
+			Bindings: []GrantingBinding{
+				{Kind: RoleBindingKind, Name: "read-secrets", Namespace: "bar", RoleRef: rbac.RoleRef{Kind: ClusterRoleKind, Name: "secret-reader"}},
+				{Kind: RoleBindingKind, Name: "read-secrets", Namespace: "foo", RoleRef: rbac.RoleRef{Kind: ClusterRoleKind, Name: "secret-reader"}},
+			},
+		},
+	}, GroupBySubject(roleBindings, clusterRoleBindings))
+
+	// when
+	_, namespaces, err = wc.ByNamespace(Action{Verb: "get", Resource: "secrets", Namespace: "foo"})
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo"}, namespaces)
+}
+
+func TestWhoCan_ByNamespace_ListsNamespaces(t *testing.T) {
+	// given
+	client := fake.NewSimpleClientset(
+		&core.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "foo"}},
+		&core.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "bar"}},
+		&core.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "baz"}},
+	)
+	wc := WhoCan{clientNamespace: client.CoreV1().Namespaces()}
+
+	// when
+	_, namespaces, err := wc.ByNamespace(Action{Verb: "get", Resource: "secrets", AllNamespaces: true})
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bar", "baz", "foo"}, namespaces)
+}
+
+func TestPrinter_PrintNamespaceAccess(t *testing.T) {
+	grants := []SubjectGrant{
+		{Subject: rbac.Subject{Kind: rbac.GroupKind, Name: "auditors"}, ClusterWide: true},
+		{Subject: rbac.Subject{Kind: rbac.ServiceAccountKind, Namespace: "bar", Name: "backup"}, Namespaces: []string{"bar"}},
+		{Subject: rbac.Subject{Kind: rbac.UserKind, Name: "alice"}, Namespaces: []string{"bar", "foo"}},
+	}
+	namespaces := []string{"bar", "baz", "foo"}
+
+	t.Run("Should print a mark for each namespace", func(t *testing.T) {
+		var buf bytes.Buffer
+		NewPrinter(&buf, false).PrintNamespaceAccess(Action{Verb: "get", Resource: "secrets"}, namespaces, grants)
+
+		assert.Equal(t, `SUBJECT   TYPE            SA-NAMESPACE  bar  baz  foo
+auditors  Group                         CRB  CRB  CRB
+backup    ServiceAccount  bar           RB   -    -
+alice     User                          RB   -    RB
+`, buf.String())
+	})
+
+	t.Run("Should print message when there are no subjects", func(t *testing.T) {
+		var buf bytes.Buffer
+		NewPrinter(&buf, false).PrintNamespaceAccess(Action{Verb: "get", Resource: "secrets"}, namespaces, nil)
+
+		assert.Equal(t, "No subjects found with permissions to get secrets\n", buf.String())
+	})
+
+	t.Run("Should export JSON", func(t *testing.T) {
+		var buf bytes.Buffer
+		NewPrinter(&buf, false).ExportNamespaceAccess(namespaces, grants[1:], HiddenSubjects{})
+
+		assert.JSONEq(t, `{
+  "namespaces": ["bar", "baz", "foo"],
+  "subjects": [
+    {"kind": "ServiceAccount", "namespace": "bar", "name": "backup", "access": {"bar": "RoleBinding"}},
+    {"kind": "User", "name": "alice", "access": {"bar": "RoleBinding", "foo": "RoleBinding"}}
+  ]
+}`, buf.String())
+	})
+}
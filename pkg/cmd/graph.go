@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	rbac "k8s.io/api/rbac/v1"
+)
+
+// graphNode is a subject, binding or role in the graph of subjects, bindings and roles.
+type graphNode struct {
+	id    string
+	kind  string
+	label string
+}
+
+// graphEdge connects a subject to a binding, or a binding to the role it refers to.
+type graphEdge struct {
+	from  string
+	to    string
+	label string
+}
+
+// graph is a directed graph of subjects pointing at bindings, which point at roles. Each subject, binding and role is
+// represented by a single node regardless of the number of edges it has.
+type graph struct {
+	nodes []graphNode
+	edges []graphEdge
+
+	nodeIDs map[string]struct{}
+	edgeIDs map[graphEdge]struct{}
+}
+
+// newGraph builds the graph of the given bindings. Edges pointing at roles are labelled with the role's PolicyRules
+// which match the Action.
+func newGraph(roleBindings []rbac.RoleBinding, clusterRoleBindings []rbac.ClusterRoleBinding, rules RoleRules) *graph {
+	g := &graph{
+		nodeIDs: make(map[string]struct{}),
+		edgeIDs: make(map[graphEdge]struct{}),
+	}
+	for _, bs := range bindingSubjectsOf(roleBindings, clusterRoleBindings) {
+		subjectID := g.addSubject(bs.Subject)
+
+		bindingLabel := bs.Binding
+		if bs.Namespace != "" {
+			bindingLabel = bs.Namespace + "/" + bs.Binding
+		}
+		bindingID := g.addNode(bs.BindingKind, bindingLabel)
+
+		roleLabel := bs.RoleRef.Name
+		if bs.RoleRef.Kind == RoleKind {
+			roleLabel = bs.Namespace + "/" + bs.RoleRef.Name
+		}
+		roleID := g.addNode(bs.RoleRef.Kind, roleLabel)
+
+		var ruleLabels []string
+		for _, rule := range rules.For(bs.Namespace, bs.RoleRef) {
+			ruleLabels = append(ruleLabels, ruleString(rule))
+		}
+
+		g.addEdge(graphEdge{from: subjectID, to: bindingID})
+		g.addEdge(graphEdge{from: bindingID, to: roleID, label: strings.Join(ruleLabels, "\n")})
+	}
+	return g
+}
+
+func (g *graph) addSubject(s rbac.Subject) string {
+	label := s.Name
+	if s.Kind == rbac.ServiceAccountKind {
+		label = s.Namespace + "/" + s.Name
+	}
+	return g.addNode(s.Kind, label)
+}
+
+func (g *graph) addNode(kind, label string) string {
+	id := kind + ":" + label
+	if _, ok := g.nodeIDs[id]; !ok {
+		g.nodeIDs[id] = struct{}{}
+		g.nodes = append(g.nodes, graphNode{id: id, kind: kind, label: label})
+	}
+	return id
+}
+
+func (g *graph) addEdge(e graphEdge) {
+	if _, ok := g.edgeIDs[e]; !ok {
+		g.edgeIDs[e] = struct{}{}
+		g.edges = append(g.edges, e)
+	}
+}
+
+// ruleString returns a compact representation of the given PolicyRule, e.g. `get,list pods,deployments.apps`.
+func ruleString(rule rbac.PolicyRule) string {
+	var targets []string
+	for _, group := range rule.APIGroups {
+		for _, resource := range rule.Resources {
+			if group == "" {
+				targets = append(targets, resource)
+			} else {
+				targets = append(targets, resource+"."+group)
+			}
+		}
+	}
+	targets = append(targets, rule.NonResourceURLs...)
+
+	s := strings.Join(rule.Verbs, ",") + " " + strings.Join(targets, ",")
+	if len(rule.ResourceNames) > 0 {
+		s += " [" + strings.Join(rule.ResourceNames, ",") + "]"
+	}
+	return s
+}
+
+// PrintDot prints the subjects, bindings and roles allowing the Action as a Graphviz DOT graph.
+func (p *Printer) PrintDot(action Action, roleBindings []rbac.RoleBinding, clusterRoleBindings []rbac.ClusterRoleBinding, rules RoleRules) {
+	g := newGraph(roleBindings, clusterRoleBindings, rules)
+	quote := func(s string) string {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+		s = strings.ReplaceAll(s, `"`, `\"`)
+		return `"` + strings.ReplaceAll(s, "\n", `\n`) + `"`
+	}
+
+	_, _ = fmt.Fprintln(p.out, "digraph whocan {")
+	_, _ = fmt.Fprintf(p.out, "  label=%s;\n", quote(action.String()))
+	_, _ = fmt.Fprintln(p.out, "  rankdir=LR;")
+	for _, n := range g.nodes {
+		_, _ = fmt.Fprintf(p.out, "  %s [label=%s, shape=%s];\n", quote(n.id), quote(n.kind+"\n"+n.label), dotShape(n.kind))
+	}
+	for _, e := range g.edges {
+		if e.label == "" {
+			_, _ = fmt.Fprintf(p.out, "  %s -> %s;\n", quote(e.from), quote(e.to))
+		} else {
+			_, _ = fmt.Fprintf(p.out, "  %s -> %s [label=%s];\n", quote(e.from), quote(e.to), quote(e.label))
+		}
+	}
+	_, _ = fmt.Fprintln(p.out, "}")
+}
+
+func dotShape(kind string) string {
+	switch kind {
+	case RoleBindingKind, ClusterRoleBindingKind:
+		return "box"
+	case RoleKind, ClusterRoleKind:
+		return "note"
+	default:
+		return "ellipse"
+	}
+}
+
+// PrintMermaid prints the subjects, bindings and roles allowing the Action as a Mermaid flowchart.
+func (p *Printer) PrintMermaid(action Action, roleBindings []rbac.RoleBinding, clusterRoleBindings []rbac.ClusterRoleBinding, rules RoleRules) {
+	g := newGraph(roleBindings, clusterRoleBindings, rules)
+	quote := func(s string) string {
+		s = strings.ReplaceAll(s, `"`, "#quot;")
+		return `"` + strings.ReplaceAll(s, "\n", "<br>") + `"`
+	}
+
+	ids := make(map[string]string, len(g.nodes))
+	_, _ = fmt.Fprintf(p.out, "%%%% %s\n", action)
+	_, _ = fmt.Fprintln(p.out, "graph LR")
+	for i, n := range g.nodes {
+		ids[n.id] = fmt.Sprintf("n%d", i)
+		label := quote(n.kind + "\n" + n.label)
+		switch n.kind {
+		case RoleBindingKind, ClusterRoleBindingKind:
+			_, _ = fmt.Fprintf(p.out, "  %s[%s]\n", ids[n.id], label)
+		case RoleKind, ClusterRoleKind:
+			_, _ = fmt.Fprintf(p.out, "  %s[[%s]]\n", ids[n.id], label)
+		default:
+			_, _ = fmt.Fprintf(p.out, "  %s([%s])\n", ids[n.id], label)
+		}
+	}
+	for _, e := range g.edges {
+		if e.label == "" {
+			_, _ = fmt.Fprintf(p.out, "  %s --> %s\n", ids[e.from], ids[e.to])
+		} else {
+			_, _ = fmt.Fprintf(p.out, "  %s -->|%s| %s\n", ids[e.from], quote(e.label), ids[e.to])
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	graphAction = Action{Verb: "get", Resource: "secrets"}
+
+	graphRoleBindings = []rbac.RoleBinding{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "admins", Namespace: "foo"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "admin"},
+			Subjects: []rbac.Subject{
+				{Kind: rbac.UserKind, Name: "alice"},
+				{Kind: rbac.ServiceAccountKind, Name: "operator", Namespace: "foo"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "admins", Namespace: "bar"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "admin"},
+			Subjects:   []rbac.Subject{{Kind: rbac.UserKind, Name: "alice"}},
+		},
+	}
+
+	graphClusterRoleBindings = []rbac.ClusterRoleBinding{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "read-secrets"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "read-secrets"},
+			Subjects:   []rbac.Subject{{Kind: rbac.GroupKind, Name: "auditors"}},
+		},
+	}
+
+	graphRules = RoleRules{
+		clusterRoles: map[string][]rbac.PolicyRule{
+			"admin":        {{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}}},
+			"read-secrets": {{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: []string{"db"}}},
+		},
+	}
+)
+
+func TestNewGraph(t *testing.T) {
+	g := newGraph(graphRoleBindings, graphClusterRoleBindings, graphRules)
+
+	assert.Equal(t, []graphNode{
+		{id: "User:alice", kind: "User", label: "alice"},
+		{id: "RoleBinding:foo/admins", kind: "RoleBinding", label: "foo/admins"},
+		{id: "ClusterRole:admin", kind: "ClusterRole", label: "admin"},
+		{id: "ServiceAccount:foo/operator", kind: "ServiceAccount", label: "foo/operator"},
+		{id: "RoleBinding:bar/admins", kind: "RoleBinding", label: "bar/admins"},
+		{id: "Group:auditors", kind: "Group", label: "auditors"},
+		{id: "ClusterRoleBinding:read-secrets", kind: "ClusterRoleBinding", label: "read-secrets"},
+		{id: "ClusterRole:read-secrets", kind: "ClusterRole", label: "read-secrets"},
+	}, g.nodes)
+	assert.Equal(t, []graphEdge{
+		{from: "User:alice", to: "RoleBinding:foo/admins"},
+		{from: "RoleBinding:foo/admins", to: "ClusterRole:admin", label: "* *.*"},
+		{from: "ServiceAccount:foo/operator", to: "RoleBinding:foo/admins"},
+		{from: "User:alice", to: "RoleBinding:bar/admins"},
+		{from: "RoleBinding:bar/admins", to: "ClusterRole:admin", label: "* *.*"},
+		{from: "Group:auditors", to: "ClusterRoleBinding:read-secrets"},
+		{from: "ClusterRoleBinding:read-secrets", to: "ClusterRole:read-secrets", label: "get,list secrets [db]"},
+	}, g.edges)
+}
+
+func TestPrinter_PrintDot(t *testing.T) {
+	var buf bytes.Buffer
+	NewPrinter(&buf, false).PrintDot(graphAction, graphRoleBindings[1:], graphClusterRoleBindings, graphRules)
+
+	assert.Equal(t, `digraph whocan {
+  label="get secrets";
+  rankdir=LR;
+  "User:alice" [label="User\nalice", shape=ellipse];
+  "RoleBinding:bar/admins" [label="RoleBinding\nbar/admins", shape=box];
+  "ClusterRole:admin" [label="ClusterRole\nadmin", shape=note];
+  "Group:auditors" [label="Group\nauditors", shape=ellipse];
+  "ClusterRoleBinding:read-secrets" [label="ClusterRoleBinding\nread-secrets", shape=box];
+  "ClusterRole:read-secrets" [label="ClusterRole\nread-secrets", shape=note];
+  "User:alice" -> "RoleBinding:bar/admins";
+  "RoleBinding:bar/admins" -> "ClusterRole:admin" [label="* *.*"];
+  "Group:auditors" -> "ClusterRoleBinding:read-secrets";
+  "ClusterRoleBinding:read-secrets" -> "ClusterRole:read-secrets" [label="get,list secrets [db]"];
+}
+`, buf.String())
+}
+
+func TestPrinter_PrintMermaid(t *testing.T) {
+	var buf bytes.Buffer
+	NewPrinter(&buf, false).PrintMermaid(graphAction, graphRoleBindings[1:], graphClusterRoleBindings, graphRules)
+
+	assert.Equal(t, `%% get secrets
+graph LR
+  n0(["User<br>alice"])
+  n1["RoleBinding<br>bar/admins"]
+  n2[["ClusterRole<br>admin"]]
+  n3(["Group<br>auditors"])
+  n4["ClusterRoleBinding<br>read-secrets"]
+  n5[["ClusterRole<br>read-secrets"]]
+  n0 --> n1
+  n1 -->|"* *.*"| n2
+  n3 --> n4
+  n4 -->|"get,list secrets [db]"| n5
+`, buf.String())
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	rbac "k8s.io/api/rbac/v1"
+)
+
+const (
+	groupByFlag    = "group-by"
+	groupBySubject = "subject"
+	scopeCluster   = "cluster-wide"
+)
+
+// SubjectGrant is a subject along with the bindings which grant it an action, and the namespaces in which the
+// action is granted.
+type SubjectGrant struct {
+	Subject     rbac.Subject
+	ClusterWide bool
+	Namespaces  []string
+	Bindings    []GrantingBinding
+}
+
+// GrantingBinding is a RoleBinding or ClusterRoleBinding which grants an action to a subject.
+type GrantingBinding struct {
+	Kind      string       `json:"kind"`
+	Name      string       `json:"name"`
+	Namespace string       `json:"namespace,omitempty"`
+	RoleRef   rbac.RoleRef `json:"roleRef"`
+}
+
+// String returns the kind, namespace and name of the binding, e.g. `RoleBinding/foo/admins`.
+func (b GrantingBinding) String() string {
+	if b.Namespace != "" {
+		return b.Kind + "/" + b.Namespace + "/" + b.Name
+	}
+	return b.Kind + "/" + b.Name
+}
+
+// Scope returns `cluster-wide` if the action is granted through a ClusterRoleBinding, or the namespaces in which it is
+// granted otherwise.
+func (g SubjectGrant) Scope() string {
+	if g.ClusterWide {
+		return scopeCluster
+	}
+	return strings.Join(g.Namespaces, ",")
+}
+
+// GroupBySubject returns one SubjectGrant for each distinct subject of the specified bindings, sorted by kind,
+// namespace and name.
+func GroupBySubject(roleBindings []rbac.RoleBinding, clusterRoleBindings []rbac.ClusterRoleBinding) []SubjectGrant {
+	var grants []*SubjectGrant
+	grantOf := make(map[string]*SubjectGrant)
+	grantFor := func(subject rbac.Subject) *SubjectGrant {
+		key := subjectString(subject)
+		if g, ok := grantOf[key]; ok {
+			return g
+		}
+		g := &SubjectGrant{Subject: subject}
+		grantOf[key] = g
+		grants = append(grants, g)
+		return g
+	}
+
+	for _, crb := range clusterRoleBindings {
+		for _, s := range crb.Subjects {
+			g := grantFor(s)
+			g.ClusterWide = true
+			g.Bindings = append(g.Bindings, GrantingBinding{Kind: ClusterRoleBindingKind, Name: crb.Name, RoleRef: crb.RoleRef})
+		}
+	}
+	for _, rb := range roleBindings {
+		for _, s := range rb.Subjects {
+			g := grantFor(s)
+			g.Namespaces = appendUnique(g.Namespaces, rb.Namespace)
+			g.Bindings = append(g.Bindings, GrantingBinding{Kind: RoleBindingKind, Name: rb.Name, Namespace: rb.Namespace, RoleRef: rb.RoleRef})
+		}
+	}
+
+	result := make([]SubjectGrant, 0, len(grants))
+	for _, g := range grants {
+		sort.Strings(g.Namespaces)
+		result = append(result, *g)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return lessSubject(result[i].Subject, result[j].Subject)
+	})
+	return result
+}
+
+// lessSubject returns true if the subject a is sorted before the subject b by kind, namespace and name.
+func lessSubject(a, b rbac.Subject) bool {
+	if a.Kind != b.Kind {
+		return a.Kind < b.Kind
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
+
+// PrintSubjectGrants prints one row for each subject along with the scope in which it is granted the action and the
+// bindings which grant it. Wide output lists the roles referred to by the bindings as well.
+func (p *Printer) PrintSubjectGrants(action Action, grants []SubjectGrant) {
+	if len(grants) == 0 {
+		_, _ = fmt.Fprintf(p.out, "No subjects found with permissions to %s\n", action)
+		return
+	}
+
+	wr := new(tabwriter.Writer)
+	wr.Init(p.out, 0, 8, 2, ' ', 0)
+
+	columns := []string{"SUBJECT", "TYPE", "SA-NAMESPACE", "SCOPE", "BINDINGS"}
+	if p.wide {
+		columns = append(columns, "ROLES")
+	}
+	columns = append(columns, p.subjectColumnHeaders()...)
+	_, _ = fmt.Fprintln(wr, strings.Join(columns, "\t"))
+
+	for _, g := range grants {
+		var bindings, roles []string
+		for _, b := range g.Bindings {
+			bindings = append(bindings, b.String())
+			roles = appendUnique(roles, b.RoleRef.Kind+"/"+b.RoleRef.Name)
+		}
+		format := "%s\t%s\t%s\t%s\t%s"
+		args := []interface{}{g.Subject.Name, g.Subject.Kind, g.Subject.Namespace, g.Scope(), strings.Join(bindings, ",")}
+		if p.wide {
+			format += "\t%s"
+			args = append(args, strings.Join(roles, ","))
+		}
+		p.printRow(wr, format, args, g.Subject)
+	}
+	_ = wr.Flush()
+}
+
+// subjectGrantData is a SubjectGrant along with the owner and the IAM identities of the subject.
+type subjectGrantData struct {
+	subjectData
+	ClusterWide bool              `json:"clusterWide"`
+	Namespaces  []string          `json:"namespaces,omitempty"`
+	Bindings    []GrantingBinding `json:"bindings"`
+}
+
+// ExportSubjectGrants exports the subject grants as JSON along with the number of binding subjects hidden from them
+// by reason.
+func (p *Printer) ExportSubjectGrants(grants []SubjectGrant, hidden HiddenSubjects) {
+	data := make(map[string]interface{})
+
+	subjects := make([]subjectGrantData, 0, len(grants))
+	for _, g := range grants {
+		subjects = append(subjects, subjectGrantData{
+			subjectData: p.subjectData(g.Subject),
+			ClusterWide: g.ClusterWide,
+			Namespaces:  g.Namespaces,
+			Bindings:    g.Bindings,
+		})
+	}
+	data["subjects"] = subjects
+
+	if hidden.Total > 0 {
+		data["hidden"] = hidden
+	}
+
+	encoder := json.NewEncoder(p.out)
+	encoder.SetIndent("", "    ")
+	_ = encoder.Encode(data)
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	groupByRoleBindings = []rbac.RoleBinding{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "edit", Namespace: "foo"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "edit"},
+			Subjects: []rbac.Subject{
+				{Kind: rbac.ServiceAccountKind, Namespace: "ci", Name: "deployer"},
+				{Kind: rbac.UserKind, APIGroup: rbac.GroupName, Name: "alice"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "deployer", Namespace: "bar"},
+			RoleRef:    rbac.RoleRef{Kind: RoleKind, Name: "deployer"},
+			Subjects:   []rbac.Subject{{Kind: rbac.ServiceAccountKind, Namespace: "ci", Name: "deployer"}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "admin", Namespace: "bar"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "admin"},
+			Subjects:   []rbac.Subject{{Kind: rbac.UserKind, Name: "bob"}},
+		},
+	}
+	groupByClusterRoleBindings = []rbac.ClusterRoleBinding{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "cluster-admin"},
+			Subjects:   []rbac.Subject{{Kind: rbac.UserKind, Name: "alice"}},
+		},
+	}
+)
+
+func TestGroupBySubject(t *testing.T) {
+	// when
+	grants := GroupBySubject(groupByRoleBindings, groupByClusterRoleBindings)
+
+	// then
+	assert.Equal(t, []SubjectGrant{
+		{
+			Subject:    rbac.Subject{Kind: rbac.ServiceAccountKind, Namespace: "ci", Name: "deployer"},
+			Namespaces: []string{"bar", "foo"},
+			Bindings: []GrantingBinding{
+				{Kind: RoleBindingKind, Name: "edit", Namespace: "foo", RoleRef: rbac.RoleRef{Kind: ClusterRoleKind, Name: "edit"}},
+				{Kind: RoleBindingKind, Name: "deployer", Namespace: "bar", RoleRef: rbac.RoleRef{Kind: RoleKind, Name: "deployer"}},
+			},
+		},
+		{
+			Subject:     rbac.Subject{Kind: rbac.UserKind, Name: "alice"},
+			ClusterWide: true,
+			Namespaces:  []string{"foo"},
+			Bindings: []GrantingBinding{
+				{Kind: ClusterRoleBindingKind, Name: "cluster-admin", RoleRef: rbac.RoleRef{Kind: ClusterRoleKind, Name: "cluster-admin"}},
+				{Kind: RoleBindingKind, Name: "edit", Namespace: "foo", RoleRef: rbac.RoleRef{Kind: ClusterRoleKind, Name: "edit"}},
+			},
+		},
+		{
+			Subject:    rbac.Subject{Kind: rbac.UserKind, Name: "bob"},
+			Namespaces: []string{"bar"},
+			Bindings: []GrantingBinding{
+				{Kind: RoleBindingKind, Name: "admin", Namespace: "bar", RoleRef: rbac.RoleRef{Kind: ClusterRoleKind, Name: "admin"}},
+			},
+		},
+	}, grants)
+	assert.Equal(t, []SubjectGrant{}, GroupBySubject(nil, nil))
+}
+
+func TestPrinter_PrintSubjectGrants(t *testing.T) {
+	grants := GroupBySubject(groupByRoleBindings, groupByClusterRoleBindings)
+	action := Action{Verb: "get", Resource: "pods", AllNamespaces: true}
+
+	data := []struct {
+		scenario string
+		wide     bool
+		grants   []SubjectGrant
+		output   string
+	}{
+		{
+			scenario: "Table",
+			grants:   grants,
+			output: `SUBJECT   TYPE            SA-NAMESPACE  SCOPE         BINDINGS
+deployer  ServiceAccount  ci            bar,foo       RoleBinding/foo/edit,RoleBinding/bar/deployer
+alice     User                          cluster-wide  ClusterRoleBinding/cluster-admin,RoleBinding/foo/edit
+bob       User                          bar           RoleBinding/bar/admin
+`,
+		},
+		{
+			scenario: "Wide",
+			wide:     true,
+			grants:   grants,
+			output: `SUBJECT   TYPE            SA-NAMESPACE  SCOPE         BINDINGS                                               ROLES
+deployer  ServiceAccount  ci            bar,foo       RoleBinding/foo/edit,RoleBinding/bar/deployer          ClusterRole/edit,Role/deployer
+alice     User                          cluster-wide  ClusterRoleBinding/cluster-admin,RoleBinding/foo/edit  ClusterRole/cluster-admin,ClusterRole/edit
+bob       User                          bar           RoleBinding/bar/admin                                  ClusterRole/admin
+`,
+		},
+		{
+			scenario: "Empty",
+			output:   "No subjects found with permissions to get pods\n",
+		},
+	}
+
+	for _, tt := range data {
+		t.Run(tt.scenario, func(t *testing.T) {
+			var buf bytes.Buffer
+			NewPrinter(&buf, tt.wide).PrintSubjectGrants(action, tt.grants)
+			assert.Equal(t, tt.output, buf.String())
+		})
+	}
+}
+
+func TestPrinter_ExportSubjectGrants(t *testing.T) {
+	// given
+	grants := GroupBySubject(groupByRoleBindings[2:], groupByClusterRoleBindings)
+	hidden := HiddenSubjects{Total: 1, Reasons: map[string]int{"Kubelet node identity": 1}}
+
+	// when
+	var buf bytes.Buffer
+	NewPrinter(&buf, false).ExportSubjectGrants(grants, hidden)
+
+	// then
+	assert.JSONEq(t, `{
+  "subjects": [
+    {
+      "kind": "User",
+      "name": "alice",
+      "clusterWide": true,
+      "bindings": [{"kind": "ClusterRoleBinding", "name": "cluster-admin", "roleRef": {"apiGroup": "", "kind": "ClusterRole", "name": "cluster-admin"}}]
+    },
+    {
+      "kind": "User",
+      "name": "bob",
+      "clusterWide": false,
+      "namespaces": ["bar"],
+      "bindings": [{"kind": "RoleBinding", "name": "admin", "namespace": "bar", "roleRef": {"apiGroup": "", "kind": "ClusterRole", "name": "admin"}}]
+    }
+  ],
+  "hidden": {"total": 1, "reasons": {"Kubelet node identity": 1}}
+}`, buf.String())
+}
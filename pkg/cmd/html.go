@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"html/template"
+	"strings"
+	"time"
+
+	rbac "k8s.io/api/rbac/v1"
+)
+
+// ReportInfo holds the context of a report printed by PrintHTML.
+type ReportInfo struct {
+	Cluster  string
+	Time     time.Time
+	Warnings []string
+	// Notes holds the notes about the API groups of the resource, which don't mean that the list might not be complete.
+	Notes []string
+}
+
+// htmlRow is a single binding subject in the HTML report.
+type htmlRow struct {
+	bindingSubject
+	Rules    []string
+	Wildcard bool
+	System   bool
+}
+
+type htmlReport struct {
+	ReportInfo
+	Action              string
+	NonResourceURL      bool
+	RoleBindings        []htmlRow
+	ClusterRoleBindings []htmlRow
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>who-can {{.Action}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+header dl { display: grid; grid-template-columns: max-content auto; gap: .25em 1em; }
+header dt { font-weight: bold; }
+.warnings { background: #fff4ce; border: 1px solid #e0c050; padding: .5em 1em; }
+.notes { background: #eef4fb; border: 1px solid #9ab8d8; padding: .5em 1em; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: .3em .6em; text-align: left; vertical-align: top; }
+th { background: #f0f0f0; cursor: pointer; user-select: none; }
+th[data-order="asc"]::after { content: " \25B2"; }
+th[data-order="desc"]::after { content: " \25BC"; }
+tr.wildcard td { background: #fde2e2; }
+tr.system td { background: #e2ecfd; }
+tr.wildcard.system td { background: #f3e2fd; }
+input.filter { margin-bottom: .5em; padding: .3em; width: 20em; }
+.legend span { display: inline-block; padding: .1em .5em; margin-right: 1em; }
+pre { margin: .3em 0; }
+</style>
+</head>
+<body>
+<header>
+<h1>who-can {{.Action}}</h1>
+<dl>
+<dt>Action</dt><dd>{{.Action}}</dd>
+<dt>Cluster</dt><dd>{{.Cluster}}</dd>
+<dt>Generated</dt><dd>{{.Time.Format "2006-01-02T15:04:05Z07:00"}}</dd>
+</dl>
+{{- if .Warnings}}
+<div class="warnings">
+<p>The list might not be complete due to missing permission(s):</p>
+<ul>
+{{- range .Warnings}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+</div>
+{{- end}}
+{{- if .Notes}}
+<div class="notes">
+<p>Resolving the API groups of the resource:</p>
+<ul>
+{{- range .Notes}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+</div>
+{{- end}}
+<p class="legend"><span style="background: #fde2e2">Granted through a wildcard rule</span><span style="background: #e2ecfd">Granted to a system group</span></p>
+</header>
+{{- if not .NonResourceURL}}
+<h2>RoleBindings</h2>
+{{- template "table" .RoleBindings}}
+{{- end}}
+<h2>ClusterRoleBindings</h2>
+{{- template "table" .ClusterRoleBindings}}
+<script>
+document.querySelectorAll("table.sortable").forEach(function (table) {
+  var tbody = table.tBodies[0];
+  table.querySelectorAll("th").forEach(function (th, column) {
+    th.addEventListener("click", function () {
+      var order = th.dataset.order === "asc" ? "desc" : "asc";
+      table.querySelectorAll("th").forEach(function (other) { delete other.dataset.order; });
+      th.dataset.order = order;
+      Array.from(tbody.rows)
+        .sort(function (a, b) {
+          var result = a.cells[column].textContent.trim().localeCompare(b.cells[column].textContent.trim());
+          return order === "asc" ? result : -result;
+        })
+        .forEach(function (row) { tbody.appendChild(row); });
+    });
+  });
+});
+document.querySelectorAll("input.filter").forEach(function (input) {
+  var table = input.nextElementSibling;
+  input.addEventListener("input", function () {
+    var query = input.value.toLowerCase();
+    Array.from(table.tBodies[0].rows).forEach(function (row) {
+      row.style.display = row.textContent.toLowerCase().indexOf(query) === -1 ? "none" : "";
+    });
+  });
+});
+</script>
+</body>
+</html>
+{{define "table"}}
+{{- if .}}
+<input class="filter" type="search" placeholder="Filter">
+<table class="sortable">
+<thead>
+<tr><th>Binding</th><th>Namespace</th><th>Role</th><th>Subject</th><th>Type</th><th>SA-Namespace</th><th>Rules</th></tr>
+</thead>
+<tbody>
+{{- range .}}
+<tr class="{{if .Wildcard}}wildcard{{end}}{{if .System}} system{{end}}">
+<td>{{.Binding}}</td><td>{{.Namespace}}</td><td>{{.RoleRef.Kind}}/{{.RoleRef.Name}}</td><td>{{.Subject.Name}}</td><td>{{.Subject.Kind}}</td><td>{{.Subject.Namespace}}</td>
+<td><details><summary>{{len .Rules}} matching rule(s)</summary>{{range .Rules}}<pre>{{.}}</pre>{{end}}</details></td>
+</tr>
+{{- end}}
+</tbody>
+</table>
+{{- else}}
+<p>No subjects found.</p>
+{{- end}}
+{{- end}}
+`))
+
+// PrintHTML prints a self-contained HTML report of the subjects allowed to perform the Action.
+func (p *Printer) PrintHTML(action Action, info ReportInfo, roleBindings []rbac.RoleBinding, clusterRoleBindings []rbac.ClusterRoleBinding, rules RoleRules) error {
+	report := htmlReport{
+		ReportInfo:     info,
+		Action:         action.String(),
+		NonResourceURL: action.NonResourceURL != "",
+	}
+	for _, bs := range bindingSubjectsOf(roleBindings, clusterRoleBindings) {
+		row := htmlRow{
+			bindingSubject: bs,
+			System:         bs.Subject.Kind == rbac.GroupKind && strings.HasPrefix(bs.Subject.Name, "system:"),
+		}
+		for _, rule := range rules.For(bs.Namespace, bs.RoleRef) {
+			row.Rules = append(row.Rules, ruleString(rule))
+			row.Wildcard = row.Wildcard || isWildcardRule(rule)
+		}
+		if bs.BindingKind == RoleBindingKind {
+			report.RoleBindings = append(report.RoleBindings, row)
+		} else {
+			report.ClusterRoleBindings = append(report.ClusterRoleBindings, row)
+		}
+	}
+	return htmlTemplate.Execute(p.out, report)
+}
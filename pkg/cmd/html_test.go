@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPrinter_PrintHTML(t *testing.T) {
+	// given
+	var buf bytes.Buffer
+	info := ReportInfo{
+		Cluster:  "prod (https://10.0.0.1:6443)",
+		Time:     time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC),
+		Warnings: []string{"The user is not allowed to list roles in the <bar> namespace"},
+		Notes:    []string{"The resource secrets is also served by the API group(s) example.com"},
+	}
+	clusterRoleBindings := append(graphClusterRoleBindings, rbac.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin"},
+		RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "admin"},
+		Subjects:   []rbac.Subject{{Kind: rbac.GroupKind, Name: "system:masters"}},
+	})
+
+	// when
+	err := NewPrinter(&buf, false).PrintHTML(graphAction, info, graphRoleBindings, clusterRoleBindings, graphRules)
+
+	// then
+	require.NoError(t, err)
+	html := buf.String()
+	assert.Contains(t, html, "<title>who-can get secrets</title>")
+	assert.Contains(t, html, "<dt>Cluster</dt><dd>prod (https://10.0.0.1:6443)</dd>")
+	assert.Contains(t, html, "<dt>Generated</dt><dd>2021-07-01T12:00:00Z</dd>")
+	assert.Contains(t, html, "<li>The user is not allowed to list roles in the &lt;bar&gt; namespace</li>")
+	assert.Contains(t, html, `<div class="notes">
+<p>Resolving the API groups of the resource:</p>
+<ul>
+<li>The resource secrets is also served by the API group(s) example.com</li>`)
+	assert.Contains(t, html, `<tr class="wildcard">
+<td>admins</td><td>foo</td><td>ClusterRole/admin</td><td>alice</td><td>User</td><td></td>
+<td><details><summary>1 matching rule(s)</summary><pre>* *.*</pre></details></td>`)
+	assert.Contains(t, html, `<tr class="">
+<td>read-secrets</td><td></td><td>ClusterRole/read-secrets</td><td>auditors</td><td>Group</td><td></td>`)
+	assert.Contains(t, html, `<tr class="wildcard system">
+<td>cluster-admin</td><td></td><td>ClusterRole/admin</td><td>system:masters</td><td>Group</td><td></td>`)
+	assert.Equal(t, 2, bytes.Count(buf.Bytes(), []byte(`<table class="sortable">`)))
+}
+
+func TestPrinter_PrintHTML_NoSubjects(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := NewPrinter(&buf, false).PrintHTML(Action{Verb: "get", NonResourceURL: "/logs"}, ReportInfo{}, nil, nil, RoleRules{})
+
+	require.NoError(t, err)
+	assert.NotContains(t, buf.String(), "<h2>RoleBindings</h2>")
+	assert.Contains(t, buf.String(), "<h2>ClusterRoleBindings</h2>\n<p>No subjects found.</p>")
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	rbac "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const identityMapFlag = "identity-map"
+
+// IdentityMapFile is a YAML or JSON document translating the names of Group and User subjects into the owners
+// responsible for them, such as people, email addresses or teams, for example:
+//
+//	stripPrefixes:
+//	- "oidc:"
+//	identities:
+//	- subjectKind: Group
+//	  subject: platform-*
+//	  owner: Platform team <platform@example.com>
+//	- subject: /^(.+)@example\.com$/
+//	  owner: $1
+//
+// The first of the prefixes which a subject name starts with is stripped before the name is matched against the
+// glob or /regex/ pattern of each rule. The owner of the first matching rule is reported, where $1 or ${name} refer to
+// the groups captured by a regex pattern. If no rule matches a name which had a prefix stripped, the stripped name is
+// reported as the owner.
+type IdentityMapFile struct {
+	StripPrefixes []string       `json:"stripPrefixes,omitempty"`
+	Identities    []IdentityRule `json:"identities,omitempty"`
+}
+
+// IdentityRule maps the Group or User subjects matching a pattern onto an owner.
+type IdentityRule struct {
+	SubjectKind string `json:"subjectKind,omitempty"`
+	Subject     string `json:"subject"`
+	Owner       string `json:"owner"`
+}
+
+// IdentityMap translates Group and User subjects into their owners.
+type IdentityMap struct {
+	stripPrefixes []string
+	rules         []IdentityRule
+	patterns      []*regexp.Regexp
+}
+
+// ReadIdentityMap reads and validates the identity mapping file with the specified path.
+func ReadIdentityMap(path string) (*IdentityMap, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading identity map: %v", err)
+	}
+	return parseIdentityMap(data)
+}
+
+func parseIdentityMap(data []byte) (*IdentityMap, error) {
+	var file IdentityMapFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing identity map: %v", err)
+	}
+	if len(file.StripPrefixes) == 0 && len(file.Identities) == 0 {
+		return nil, errors.New("parsing identity map: no prefixes or identities specified")
+	}
+
+	m := &IdentityMap{stripPrefixes: file.StripPrefixes, rules: file.Identities}
+	for i, rule := range file.Identities {
+		if rule.SubjectKind != "" && rule.SubjectKind != rbac.GroupKind && rule.SubjectKind != rbac.UserKind {
+			return nil, fmt.Errorf("parsing identity map: identity #%d: subjectKind must be Group or User", i+1)
+		}
+		if rule.Subject == "" || rule.Owner == "" {
+			return nil, fmt.Errorf("parsing identity map: identity #%d: subject and owner are required", i+1)
+		}
+		pattern, err := compileOptionalPattern(rule.Subject)
+		if err != nil {
+			return nil, fmt.Errorf("parsing identity map: identity #%d: %v", i+1, err)
+		}
+		m.patterns = append(m.patterns, pattern)
+	}
+	return m, nil
+}
+
+// OwnerOf returns the owner of the given Group or User subject, or an empty string if it is not mapped.
+func (m *IdentityMap) OwnerOf(subject rbac.Subject) string {
+	if subject.Kind != rbac.GroupKind && subject.Kind != rbac.UserKind {
+		return ""
+	}
+
+	name, stripped := subject.Name, false
+	for _, prefix := range m.stripPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			name, stripped = strings.TrimPrefix(name, prefix), true
+			break
+		}
+	}
+
+	for i, rule := range m.rules {
+		if rule.SubjectKind != "" && rule.SubjectKind != subject.Kind {
+			continue
+		}
+		match := m.patterns[i].FindStringSubmatchIndex(name)
+		if match == nil {
+			continue
+		}
+		return string(m.patterns[i].ExpandString(nil, rule.Owner, name, match))
+	}
+
+	if stripped {
+		return name
+	}
+	return ""
+}
+
+// SetIdentityMap makes the Printer show the owners of Group and User subjects in wide and JSON output.
+func (p *Printer) SetIdentityMap(identityMap *IdentityMap) {
+	p.identityMap = identityMap
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const identityMapFile = `stripPrefixes:
+- "oidc:"
+- "ldap:"
+identities:
+- subjectKind: Group
+  subject: platform-*
+  owner: Platform team <platform@example.com>
+- subjectKind: User
+  subject: /^(?P<user>[a-z]+)@example\.com$/
+  owner: ${user} (employee)
+- subject: ci-bot
+  owner: CI <ci@example.com>
+`
+
+func TestIdentityMap_OwnerOf(t *testing.T) {
+	// given
+	identityMap, err := parseIdentityMap([]byte(identityMapFile))
+	require.NoError(t, err)
+
+	data := []struct {
+		subject rbac.Subject
+		owner   string
+	}{
+		{subject: rbac.Subject{Kind: rbac.GroupKind, Name: "oidc:platform-admins"}, owner: "Platform team <platform@example.com>"},
+		{subject: rbac.Subject{Kind: rbac.GroupKind, Name: "platform-viewers"}, owner: "Platform team <platform@example.com>"},
+		{subject: rbac.Subject{Kind: rbac.UserKind, Name: "platform-admins"}},
+		{subject: rbac.Subject{Kind: rbac.UserKind, Name: "oidc:alice@example.com"}, owner: "alice (employee)"},
+		{subject: rbac.Subject{Kind: rbac.UserKind, Name: "ldap:ci-bot"}, owner: "CI <ci@example.com>"},
+		{subject: rbac.Subject{Kind: rbac.GroupKind, Name: "ldap:payments"}, owner: "payments"},
+		{subject: rbac.Subject{Kind: rbac.GroupKind, Name: "payments"}},
+		{subject: rbac.Subject{Kind: rbac.ServiceAccountKind, Namespace: "ci", Name: "ci-bot"}},
+	}
+	for _, tt := range data {
+		t.Run(tt.subject.Kind+"/"+tt.subject.Name, func(t *testing.T) {
+			assert.Equal(t, tt.owner, identityMap.OwnerOf(tt.subject))
+		})
+	}
+}
+
+func TestParseIdentityMap_Errors(t *testing.T) {
+	data := []struct {
+		scenario      string
+		content       string
+		expectedError string
+	}{
+		{scenario: "Empty", content: "{}", expectedError: "parsing identity map: no prefixes or identities specified"},
+		{scenario: "Unknown field", content: "identities:\n- subject: a\n  owner: b\n  team: c\n", expectedError: `parsing identity map: error unmarshaling JSON: while decoding JSON: json: unknown field "team"`},
+		{scenario: "Missing owner", content: "identities:\n- subject: a\n", expectedError: "parsing identity map: identity #1: subject and owner are required"},
+		{scenario: "Invalid kind", content: "identities:\n- subjectKind: ServiceAccount\n  subject: a\n  owner: b\n", expectedError: "parsing identity map: identity #1: subjectKind must be Group or User"},
+		{scenario: "Invalid pattern", content: "identities:\n- subject: /(/\n  owner: b\n", expectedError: "parsing identity map: identity #1: invalid subject pattern: /(/: error parsing regexp: missing closing ): `(`"},
+	}
+	for _, tt := range data {
+		t.Run(tt.scenario, func(t *testing.T) {
+			_, err := parseIdentityMap([]byte(tt.content))
+			assert.EqualError(t, err, tt.expectedError)
+		})
+	}
+}
+
+func TestReadIdentityMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "map.yaml")
+	writeManifest(t, path, identityMapFile)
+
+	identityMap, err := ReadIdentityMap(path)
+	require.NoError(t, err)
+	assert.Len(t, identityMap.rules, 3)
+
+	_, err = ReadIdentityMap(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestPrinter_IdentityMap(t *testing.T) {
+	// given
+	identityMap, err := parseIdentityMap([]byte(identityMapFile))
+	require.NoError(t, err)
+	roleBindings := []rbac.RoleBinding{{
+		ObjectMeta: metav1.ObjectMeta{Name: "admins", Namespace: "foo"},
+		RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "admin"},
+		Subjects: []rbac.Subject{
+			{Kind: rbac.GroupKind, Name: "oidc:platform-admins"},
+			{Kind: rbac.ServiceAccountKind, Namespace: "foo", Name: "operator"},
+		},
+	}}
+	clusterRoleBindings := []rbac.ClusterRoleBinding{{
+		ObjectMeta: metav1.ObjectMeta{Name: "view"},
+		RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "view"},
+		Subjects:   []rbac.Subject{{Kind: rbac.UserKind, Name: "oidc:alice@example.com"}},
+	}}
+	action := Action{Verb: "get", Resource: "pods", Namespace: "foo"}
+
+	// when
+	var buf bytes.Buffer
+	printer := NewPrinter(&buf, true)
+	printer.SetIdentityMap(identityMap)
+	printer.PrintChecks(action, roleBindings, clusterRoleBindings)
+
+	// then
+	assert.Equal(t, `ROLEBINDING  ROLE               NAMESPACE  SUBJECT               TYPE            SA-NAMESPACE  OWNER
+admins       ClusterRole/admin  foo        oidc:platform-admins  Group                         Platform team <platform@example.com>
+admins       ClusterRole/admin  foo        operator              ServiceAccount  foo           
+
+CLUSTERROLEBINDING  ROLE              SUBJECT                 TYPE  SA-NAMESPACE  OWNER
+view                ClusterRole/view  oidc:alice@example.com  User                alice (employee)
+`, buf.String())
+
+	// when
+	buf.Reset()
+	printer = NewPrinter(&buf, false)
+	printer.SetIdentityMap(identityMap)
+	printer.PrintChecks(action, nil, clusterRoleBindings)
+
+	// then
+	assert.Equal(t, `No subjects found with permissions to get pods assigned through RoleBindings
+
+CLUSTERROLEBINDING  SUBJECT                 TYPE  SA-NAMESPACE
+view                oidc:alice@example.com  User  
+`, buf.String())
+
+	// when
+	buf.Reset()
+	printer.ExportData(action, nil, clusterRoleBindings)
+
+	// then
+	assert.JSONEq(t, `{
+  "clusterRoleBindings": [{
+    "name": "view",
+    "roleRef": {"apiGroup": "", "kind": "ClusterRole", "name": "view"},
+    "subjects": [{"kind": "User", "name": "oidc:alice@example.com", "owner": "alice (employee)"}]
+  }]
+}`, buf.String())
+}
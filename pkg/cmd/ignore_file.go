@@ -0,0 +1,281 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+
+	rbac "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// IgnoreFile is a YAML or JSON document listing binding subjects which are expected to be allowed to perform actions
+// and therefore hidden from the results, for example:
+//
+//	ignore:
+//	- subjectKind: ServiceAccount
+//	  subjectNamespace: ci
+//	  subject: deployer-*
+//	  justification: CI pipelines deploy all workloads
+//	- bindingKind: ClusterRoleBinding
+//	  binding: vault-auth
+//	  justification: Vault verifies service account tokens
+//
+// Each rule must specify a justification and at least one of the other fields. A binding subject is hidden if it
+// matches all fields specified by any rule. Names are matched as glob or /regex/ patterns.
+type IgnoreFile struct {
+	Ignore []IgnoreRule `json:"ignore"`
+}
+
+// IgnoreRule selects binding subjects to be hidden from the results.
+type IgnoreRule struct {
+	BindingKind      string `json:"bindingKind,omitempty"`
+	Binding          string `json:"binding,omitempty"`
+	Namespace        string `json:"namespace,omitempty"`
+	RoleKind         string `json:"roleKind,omitempty"`
+	Role             string `json:"role,omitempty"`
+	SubjectKind      string `json:"subjectKind,omitempty"`
+	Subject          string `json:"subject,omitempty"`
+	SubjectNamespace string `json:"subjectNamespace,omitempty"`
+	Justification    string `json:"justification"`
+}
+
+// systemIgnoreRules hides the built-in bindings of Kubernetes components, which are expected to be allowed to perform
+// most actions. Built-in bindings are matched by their exact name, role and subjects, so that a binding merely named
+// like a built-in one, a subject added to a built-in binding, or a system subject such as the system:masters group
+// bound by any other binding, is still reported.
+var systemIgnoreRules = append(
+	builtInBindingRules(kubernetesBindings, "Built-in binding of Kubernetes components"),
+	builtInBindingRules(kubeadmBindings, "Built-in binding created by kubeadm")...,
+)
+
+// builtInBinding is a binding created by the API server or by kubeadm along with the subjects it is created with.
+// Bindings without namespace are ClusterRoleBindings.
+type builtInBinding struct {
+	namespace string
+	name      string
+	roleRef   rbac.RoleRef
+	subjects  []rbac.Subject
+}
+
+func builtInClusterRoleBinding(name, clusterRole string, subjects ...rbac.Subject) builtInBinding {
+	return builtInBinding{name: name, roleRef: rbac.RoleRef{Kind: ClusterRoleKind, Name: clusterRole}, subjects: subjects}
+}
+
+func builtInRoleBinding(namespace, name, role string, subjects ...rbac.Subject) builtInBinding {
+	return builtInBinding{namespace: namespace, name: name, roleRef: rbac.RoleRef{Kind: RoleKind, Name: role}, subjects: subjects}
+}
+
+func systemUser(name string) rbac.Subject {
+	return rbac.Subject{Kind: rbac.UserKind, Name: name}
+}
+
+func systemGroup(name string) rbac.Subject {
+	return rbac.Subject{Kind: rbac.GroupKind, Name: name}
+}
+
+func systemServiceAccount(name string) rbac.Subject {
+	return rbac.Subject{Kind: rbac.ServiceAccountKind, Name: name, Namespace: kubeSystemNamespace}
+}
+
+const (
+	kubeSystemNamespace = "kube-system"
+	kubePublicNamespace = "kube-public"
+
+	controllerPrefix        = "system:controller:"
+	bootstrapTokenGroup     = "system:bootstrappers:kubeadm:default-node-token"
+	kubeControllerManager   = "system:kube-controller-manager"
+	kubeScheduler           = "system:kube-scheduler"
+	leaderLockingController = "system::leader-locking-kube-controller-manager"
+	leaderLockingScheduler  = "system::leader-locking-kube-scheduler"
+	authenticationReader    = "system::extension-apiserver-authentication-reader"
+)
+
+// controllers are the controllers of kube-controller-manager, each of which is bound to the ClusterRole
+// system:controller:<name> through the ClusterRoleBinding of the same name.
+var controllers = []string{
+	"attachdetach-controller",
+	"certificate-controller",
+	"clusterrole-aggregation-controller",
+	"cronjob-controller",
+	"daemon-set-controller",
+	"deployment-controller",
+	"disruption-controller",
+	"endpoint-controller",
+	"endpointslice-controller",
+	"endpointslicemirroring-controller",
+	"ephemeral-volume-controller",
+	"expand-controller",
+	"generic-garbage-collector",
+	"horizontal-pod-autoscaler",
+	"job-controller",
+	"namespace-controller",
+	"node-controller",
+	"persistent-volume-binder",
+	"pod-garbage-collector",
+	"pv-protection-controller",
+	"pvc-protection-controller",
+	"replicaset-controller",
+	"replication-controller",
+	"resourcequota-controller",
+	"root-ca-cert-publisher",
+	"route-controller",
+	"service-account-controller",
+	"service-controller",
+	"statefulset-controller",
+	"ttl-after-finished-controller",
+	"ttl-controller",
+}
+
+// kubernetesBindings are the bindings created by the API server, i.e. the ones labelled
+// kubernetes.io/bootstrapping=rbac-defaults.
+var kubernetesBindings = append([]builtInBinding{
+	builtInClusterRoleBinding("cluster-admin", "cluster-admin", systemGroup("system:masters")),
+	builtInClusterRoleBinding("system:basic-user", "system:basic-user", systemGroup("system:authenticated")),
+	builtInClusterRoleBinding("system:discovery", "system:discovery", systemGroup("system:authenticated")),
+	builtInClusterRoleBinding("system:public-info-viewer", "system:public-info-viewer", systemGroup("system:authenticated"), systemGroup("system:unauthenticated")),
+	builtInClusterRoleBinding("system:monitoring", "system:monitoring", systemGroup("system:monitoring")),
+	builtInClusterRoleBinding("system:service-account-issuer-discovery", "system:service-account-issuer-discovery", systemGroup("system:serviceaccounts")),
+	builtInClusterRoleBinding("system:node-proxier", "system:node-proxier", systemUser("system:kube-proxy")),
+	builtInClusterRoleBinding("system:kube-controller-manager", "system:kube-controller-manager", systemUser(kubeControllerManager)),
+	builtInClusterRoleBinding("system:kube-scheduler", "system:kube-scheduler", systemUser(kubeScheduler)),
+	builtInClusterRoleBinding("system:volume-scheduler", "system:volume-scheduler", systemUser(kubeScheduler)),
+	builtInClusterRoleBinding("system:kube-dns", "system:kube-dns", systemServiceAccount("kube-dns")),
+	builtInRoleBinding(kubeSystemNamespace, authenticationReader, "extension-apiserver-authentication-reader", systemUser(kubeControllerManager), systemUser(kubeScheduler)),
+	builtInRoleBinding(kubeSystemNamespace, leaderLockingController, leaderLockingController, systemUser(kubeControllerManager), systemServiceAccount("kube-controller-manager")),
+	builtInRoleBinding(kubeSystemNamespace, leaderLockingScheduler, leaderLockingScheduler, systemUser(kubeScheduler), systemServiceAccount("kube-scheduler")),
+	builtInRoleBinding(kubeSystemNamespace, controllerPrefix+"bootstrap-signer", controllerPrefix+"bootstrap-signer", systemServiceAccount("bootstrap-signer")),
+	builtInRoleBinding(kubeSystemNamespace, controllerPrefix+"cloud-provider", controllerPrefix+"cloud-provider", systemServiceAccount("cloud-provider")),
+	builtInRoleBinding(kubeSystemNamespace, controllerPrefix+"token-cleaner", controllerPrefix+"token-cleaner", systemServiceAccount("token-cleaner")),
+	builtInRoleBinding(kubePublicNamespace, controllerPrefix+"bootstrap-signer", controllerPrefix+"bootstrap-signer", systemServiceAccount("bootstrap-signer")),
+}, controllerBindings()...)
+
+// kubeadmBindings are the bindings created by kubeadm when initializing a cluster.
+var kubeadmBindings = []builtInBinding{
+	builtInClusterRoleBinding("kubeadm:get-nodes", "kubeadm:get-nodes", systemGroup(bootstrapTokenGroup)),
+	builtInClusterRoleBinding("kubeadm:kubelet-bootstrap", "system:node-bootstrapper", systemGroup(bootstrapTokenGroup)),
+	builtInClusterRoleBinding("kubeadm:node-autoapprove-bootstrap", "system:certificates.k8s.io:certificatesigningrequests:nodeclient", systemGroup(bootstrapTokenGroup)),
+	builtInClusterRoleBinding("kubeadm:node-autoapprove-certificate-rotation", "system:certificates.k8s.io:certificatesigningrequests:selfnodeclient", systemGroup("system:nodes")),
+	builtInClusterRoleBinding("kubeadm:node-proxier", "system:node-proxier", systemServiceAccount("kube-proxy")),
+	builtInRoleBinding(kubeSystemNamespace, "kubeadm:kubelet-config", "kubeadm:kubelet-config", systemGroup("system:nodes"), systemGroup(bootstrapTokenGroup)),
+	builtInRoleBinding(kubeSystemNamespace, "kubeadm:nodes-kubeadm-config", "kubeadm:nodes-kubeadm-config", systemGroup("system:nodes"), systemGroup(bootstrapTokenGroup)),
+	builtInRoleBinding(kubeSystemNamespace, "kube-proxy", "kube-proxy", systemGroup(bootstrapTokenGroup)),
+	builtInRoleBinding(kubePublicNamespace, "kubeadm:bootstrap-signer-clusterinfo", "kubeadm:bootstrap-signer-clusterinfo", systemUser("system:anonymous")),
+}
+
+func controllerBindings() []builtInBinding {
+	bindings := make([]builtInBinding, len(controllers))
+	for i, controller := range controllers {
+		bindings[i] = builtInClusterRoleBinding(controllerPrefix+controller, controllerPrefix+controller, systemServiceAccount(controller))
+	}
+	return bindings
+}
+
+// builtInBindingRules returns an IgnoreRule for each subject of the given bindings, which matches the subject only
+// if it is bound through the binding with the exact name, namespace and role.
+func builtInBindingRules(bindings []builtInBinding, justification string) []IgnoreRule {
+	var rules []IgnoreRule
+	for _, b := range bindings {
+		kind := ClusterRoleBindingKind
+		if b.namespace != "" {
+			kind = RoleBindingKind
+		}
+		for _, subject := range b.subjects {
+			rules = append(rules, IgnoreRule{
+				BindingKind:      kind,
+				Binding:          b.name,
+				Namespace:        b.namespace,
+				RoleKind:         b.roleRef.Kind,
+				Role:             b.roleRef.Name,
+				SubjectKind:      subject.Kind,
+				Subject:          subject.Name,
+				SubjectNamespace: subject.Namespace,
+				Justification:    justification,
+			})
+		}
+	}
+	return rules
+}
+
+// ReadIgnoreFile reads and validates the ignore rules listed in the file with the specified path.
+func ReadIgnoreFile(path string) ([]IgnoreRule, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading ignore file: %v", err)
+	}
+	return parseIgnoreRules(data)
+}
+
+func parseIgnoreRules(data []byte) ([]IgnoreRule, error) {
+	var file IgnoreFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing ignore file: %v", err)
+	}
+	if len(file.Ignore) == 0 {
+		return nil, errors.New("parsing ignore file: no rules specified")
+	}
+
+	for i, rule := range file.Ignore {
+		if rule.Justification == "" {
+			return nil, fmt.Errorf("parsing ignore file: rule #%d: justification is required", i+1)
+		}
+		if rule == (IgnoreRule{Justification: rule.Justification}) {
+			return nil, fmt.Errorf("parsing ignore file: rule #%d: at least one binding, role or subject field is required", i+1)
+		}
+	}
+
+	return file.Ignore, nil
+}
+
+// ignoreMatcher is a compiled IgnoreRule.
+type ignoreMatcher struct {
+	rule    IgnoreRule
+	binding *regexp.Regexp
+	role    *regexp.Regexp
+	subject *regexp.Regexp
+}
+
+func newIgnoreMatcher(rule IgnoreRule) (*ignoreMatcher, error) {
+	m := &ignoreMatcher{rule: rule}
+	var err error
+	if m.binding, err = compileOptionalPattern(rule.Binding); err != nil {
+		return nil, err
+	}
+	if m.role, err = compileOptionalPattern(rule.Role); err != nil {
+		return nil, err
+	}
+	if m.subject, err = compileOptionalPattern(rule.Subject); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// compileOptionalPattern compiles the given glob or /regex/ pattern, or returns nil if the pattern is empty.
+func compileOptionalPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	compiled, err := compilePatterns([]string{pattern})
+	if err != nil {
+		return nil, err
+	}
+	return compiled[0], nil
+}
+
+// matches returns true if the given binding subject matches all fields specified by the rule.
+func (m *ignoreMatcher) matches(bs bindingSubject) bool {
+	rule := m.rule
+	switch {
+	case rule.BindingKind != "" && rule.BindingKind != bs.BindingKind,
+		m.binding != nil && !m.binding.MatchString(bs.Binding),
+		rule.Namespace != "" && rule.Namespace != bs.Namespace,
+		rule.RoleKind != "" && rule.RoleKind != bs.RoleRef.Kind,
+		m.role != nil && !m.role.MatchString(bs.RoleRef.Name),
+		rule.SubjectKind != "" && rule.SubjectKind != bs.Subject.Kind,
+		m.subject != nil && !m.subject.MatchString(bs.Subject.Name),
+		rule.SubjectNamespace != "" && rule.SubjectNamespace != bs.Subject.Namespace:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	rbac "k8s.io/api/rbac/v1"
+)
+
+func TestParseIgnoreRules(t *testing.T) {
+	testCases := []struct {
+		scenario string
+		data     string
+
+		expectedRules []IgnoreRule
+		expectedError error
+	}{
+		{
+			scenario: "Should parse rules",
+			data: `ignore:
+- subjectKind: ServiceAccount
+  subjectNamespace: ci
+  subject: deployer-*
+  justification: CI pipelines deploy all workloads
+- bindingKind: ClusterRoleBinding
+  binding: vault-auth
+  justification: Vault verifies service account tokens
+`,
+			expectedRules: []IgnoreRule{
+				{SubjectKind: rbac.ServiceAccountKind, SubjectNamespace: "ci", Subject: "deployer-*", Justification: "CI pipelines deploy all workloads"},
+				{BindingKind: ClusterRoleBindingKind, Binding: "vault-auth", Justification: "Vault verifies service account tokens"},
+			},
+		},
+		{
+			scenario:      "Should return error when no rules are specified",
+			data:          "ignore: []",
+			expectedError: errors.New("parsing ignore file: no rules specified"),
+		},
+		{
+			scenario:      "Should return error when justification is missing",
+			data:          "ignore:\n- binding: vault-auth",
+			expectedError: errors.New("parsing ignore file: rule #1: justification is required"),
+		},
+		{
+			scenario:      "Should return error when rule matches everything",
+			data:          "ignore:\n- justification: Trust me",
+			expectedError: errors.New("parsing ignore file: rule #1: at least one binding, role or subject field is required"),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.scenario, func(t *testing.T) {
+			rules, err := parseIgnoreRules([]byte(tt.data))
+			assert.Equal(t, tt.expectedError, err)
+			assert.Equal(t, tt.expectedRules, rules)
+		})
+	}
+}
+
+func TestIgnoreMatcher_Matches(t *testing.T) {
+	bs := bindingSubject{
+		BindingKind: RoleBindingKind,
+		Binding:     "deployers",
+		Namespace:   "ci",
+		RoleRef:     rbac.RoleRef{Kind: ClusterRoleKind, Name: "edit"},
+		Subject:     rbac.Subject{Kind: rbac.ServiceAccountKind, Name: "deployer-prod", Namespace: "ci"},
+	}
+
+	testCases := []struct {
+		rule     IgnoreRule
+		expected bool
+	}{
+		{rule: IgnoreRule{SubjectKind: rbac.ServiceAccountKind, Subject: "deployer-*", SubjectNamespace: "ci"}, expected: true},
+		{rule: IgnoreRule{BindingKind: RoleBindingKind, Binding: "/^deploy/", Namespace: "ci"}, expected: true},
+		{rule: IgnoreRule{RoleKind: ClusterRoleKind, Role: "edit"}, expected: true},
+		{rule: IgnoreRule{BindingKind: ClusterRoleBindingKind, Binding: "deployers"}, expected: false},
+		{rule: IgnoreRule{Subject: "deployer-*", SubjectNamespace: "prod"}, expected: false},
+		{rule: IgnoreRule{Role: "admin"}, expected: false},
+	}
+
+	for _, tt := range testCases {
+		m, err := newIgnoreMatcher(tt.rule)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expected, m.matches(bs), "%+v", tt.rule)
+	}
+}
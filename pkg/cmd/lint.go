@@ -0,0 +1,538 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	clioptions "k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	clientcore "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+const (
+	lintUsage = "lint"
+	lintLong  = `Report orphaned and dangling references between RBAC objects and risky rules.
+
+Bindings referencing a Role or ClusterRole that doesn't exist, and ServiceAccount subjects whose ServiceAccount or
+namespace is gone, are latent privilege grants: anyone who creates an object with that name gains the bound access.
+The lint subcommand reports such references along with bindings without subjects and Roles or ClusterRoles that no
+binding references. ServiceAccount subjects without namespace in ClusterRoleBindings are reported as well, since
+they cannot refer to any ServiceAccount.
+
+Roles and ClusterRoles are also checked for risky rules, such as wildcard verbs, resources or API groups, verbs
+allowing privilege escalation, and access to nodes/proxy, pods/exec or the status of resources, as well as
+ClusterRoleBindings granting read access to secrets in all namespaces. The default RBAC objects of Kubernetes are
+exempt from these checks, but the risky rules of a default Role or ClusterRole are reported on each custom binding
+referencing it, e.g. a ClusterRoleBinding to cluster-admin.
+
+Each finding has a severity of low, medium or high. Findings can be suppressed by annotating the reported object
+with ` + lintSuppressionAnnotation + ` set to a comma separated list of rule IDs, or * to suppress any rule.
+
+The command exits with a non-zero status if any finding which is not suppressed is reported.
+
+When RBAC objects are read from manifest files, missing namespaces and ServiceAccounts are only reported if the
+manifests define at least one Namespace or ServiceAccount respectively.`
+	lintExample = `  # Report dangling references between RBAC objects in the cluster
+  kubectl who-can lint
+
+  # Report dangling references between RBAC objects defined in manifest files as JSON
+  kubectl who-can lint -f manifests/ -o json
+
+  # Report high severity findings for RBAC manifests as a SARIF log
+  kubectl who-can lint -f manifests/ --min-severity high -o sarif > lint.sarif`
+)
+
+// Lint rules reported by the Linter.
+const (
+	LintDanglingRoleRef       = "dangling-role-ref"
+	LintMissingServiceAccount = "missing-service-account"
+	LintMissingNamespace      = "missing-namespace"
+	LintMissingSANamespace    = "missing-sa-namespace"
+	LintEmptySubjects         = "empty-subjects"
+	LintUnreferencedRole      = "unreferenced-role"
+)
+
+const (
+	minSeverityFlag = "min-severity"
+)
+
+// bootstrappingLabel marks the default RBAC objects of Kubernetes, whose ClusterRoles are not expected to be referenced.
+const (
+	bootstrappingLabel         = "kubernetes.io/bootstrapping"
+	bootstrappingLabelDefaults = "rbac-defaults"
+)
+
+// LintFinding is a problem reported for an RBAC object. Suppressed findings are reported but don't fail the lint.
+type LintFinding struct {
+	Rule       string `json:"rule"`
+	Severity   string `json:"severity"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	Message    string `json:"message"`
+	Suppressed bool   `json:"suppressed,omitempty"`
+}
+
+// SubjectSource wraps the methods used to list the Namespaces and ServiceAccounts referenced by binding subjects.
+type SubjectSource interface {
+	ListNamespaces() ([]core.Namespace, error)
+	ListServiceAccounts() ([]core.ServiceAccount, error)
+}
+
+type clientSubjectSource struct {
+	client clientcore.CoreV1Interface
+}
+
+// NewClientSubjectSource constructs a new SubjectSource which lists objects with the specified client.
+func NewClientSubjectSource(client clientcore.CoreV1Interface) SubjectSource {
+	return &clientSubjectSource{client: client}
+}
+
+func (s *clientSubjectSource) ListNamespaces() ([]core.Namespace, error) {
+	list, err := s.client.Namespaces().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (s *clientSubjectSource) ListServiceAccounts() ([]core.ServiceAccount, error) {
+	list, err := s.client.ServiceAccounts(core.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+type staticSubjectSource struct {
+	namespaces      []core.Namespace
+	serviceAccounts []core.ServiceAccount
+}
+
+func (s *staticSubjectSource) ListNamespaces() ([]core.Namespace, error) {
+	return s.namespaces, nil
+}
+
+func (s *staticSubjectSource) ListServiceAccounts() ([]core.ServiceAccount, error) {
+	return s.serviceAccounts, nil
+}
+
+// Linter reports orphaned and dangling references between RBAC objects.
+type Linter struct {
+	rbacSource    RBACSource
+	subjectSource SubjectSource
+	namespaces    bool
+	accounts      bool
+}
+
+// NewLinter constructs a new Linter checking the objects served by the specified sources. Missing namespaces and
+// ServiceAccounts are reported only if the corresponding flag is true.
+func NewLinter(rbacSource RBACSource, subjectSource SubjectSource, namespaces, accounts bool) *Linter {
+	return &Linter{
+		rbacSource:    rbacSource,
+		subjectSource: subjectSource,
+		namespaces:    namespaces,
+		accounts:      accounts,
+	}
+}
+
+// rbacInventory holds all RBAC objects checked by the Linter.
+type rbacInventory struct {
+	roles               []rbac.Role
+	clusterRoles        []rbac.ClusterRole
+	roleBindings        []rbac.RoleBinding
+	clusterRoleBindings []rbac.ClusterRoleBinding
+}
+
+func listInventory(source RBACSource) (inv rbacInventory, err error) {
+	if inv.roles, err = source.ListRoles(core.NamespaceAll, labels.Everything()); err != nil {
+		return inv, fmt.Errorf("listing Roles: %v", err)
+	}
+	if inv.clusterRoles, err = source.ListClusterRoles(labels.Everything()); err != nil {
+		return inv, fmt.Errorf("listing ClusterRoles: %v", err)
+	}
+	if inv.roleBindings, err = source.ListRoleBindings(core.NamespaceAll, labels.Everything()); err != nil {
+		return inv, fmt.Errorf("listing RoleBindings: %v", err)
+	}
+	if inv.clusterRoleBindings, err = source.ListClusterRoleBindings(labels.Everything()); err != nil {
+		return inv, fmt.Errorf("listing ClusterRoleBindings: %v", err)
+	}
+	return inv, nil
+}
+
+// Lint returns the problems found, sorted by object kind, namespace and name.
+func (l *Linter) Lint() ([]LintFinding, error) {
+	inv, err := listInventory(l.rbacSource)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := make(map[string]bool)
+	if l.namespaces {
+		list, err := l.subjectSource.ListNamespaces()
+		if err != nil {
+			return nil, fmt.Errorf("listing Namespaces: %v", err)
+		}
+		for _, ns := range list {
+			namespaces[ns.Name] = true
+		}
+	}
+	accounts := make(map[string]bool)
+	if l.accounts {
+		list, err := l.subjectSource.ListServiceAccounts()
+		if err != nil {
+			return nil, fmt.Errorf("listing ServiceAccounts: %v", err)
+		}
+		for _, sa := range list {
+			accounts[sa.Namespace+"/"+sa.Name] = true
+		}
+	}
+
+	roles := make(map[string]bool)
+	for _, role := range inv.roles {
+		roles[role.Namespace+"/"+role.Name] = true
+	}
+	clusterRoles := make(map[string]bool)
+	for _, clusterRole := range inv.clusterRoles {
+		clusterRoles[clusterRole.Name] = true
+	}
+
+	var findings []LintFinding
+	referenced := make(map[string]bool)
+	lintBinding := func(kind, namespace, name string, roleRef rbac.RoleRef, subjects []rbac.Subject) {
+		report := func(rule, format string, args ...interface{}) {
+			findings = append(findings, LintFinding{Rule: rule, Kind: kind, Namespace: namespace, Name: name,
+				Message: fmt.Sprintf(format, args...)})
+		}
+
+		switch roleRef.Kind {
+		case RoleKind:
+			referenced[objectKey(RoleKind, namespace, roleRef.Name)] = true
+			if !roles[namespace+"/"+roleRef.Name] {
+				report(LintDanglingRoleRef, "Role %s/%s does not exist", namespace, roleRef.Name)
+			}
+		case ClusterRoleKind:
+			referenced[objectKey(ClusterRoleKind, "", roleRef.Name)] = true
+			if !clusterRoles[roleRef.Name] {
+				report(LintDanglingRoleRef, "ClusterRole %s does not exist", roleRef.Name)
+			}
+		}
+
+		if len(subjects) == 0 {
+			report(LintEmptySubjects, "%s has no subjects", kind)
+		}
+
+		for _, s := range subjects {
+			if s.Kind != rbac.ServiceAccountKind {
+				continue
+			}
+			saNamespace := s.Namespace
+			if saNamespace == "" {
+				saNamespace = namespace
+			}
+			switch {
+			case saNamespace == "":
+				report(LintMissingSANamespace, "ServiceAccount %s has no namespace", s.Name)
+			case l.namespaces && !namespaces[saNamespace]:
+				report(LintMissingNamespace, "Namespace %s of ServiceAccount %s/%s does not exist", saNamespace, saNamespace, s.Name)
+			case l.accounts && !accounts[saNamespace+"/"+s.Name]:
+				report(LintMissingServiceAccount, "ServiceAccount %s/%s does not exist", saNamespace, s.Name)
+			}
+		}
+	}
+	for _, rb := range inv.roleBindings {
+		lintBinding(RoleBindingKind, rb.Namespace, rb.Name, rb.RoleRef, rb.Subjects)
+	}
+	for _, crb := range inv.clusterRoleBindings {
+		lintBinding(ClusterRoleBindingKind, "", crb.Name, crb.RoleRef, crb.Subjects)
+	}
+
+	for _, role := range inv.roles {
+		if !referenced[objectKey(RoleKind, role.Namespace, role.Name)] {
+			findings = append(findings, LintFinding{Rule: LintUnreferencedRole, Kind: RoleKind, Namespace: role.Namespace,
+				Name: role.Name, Message: "Role is not referenced by any binding"})
+		}
+	}
+	aggregated, err := aggregatedClusterRoles(inv.clusterRoles)
+	if err != nil {
+		return nil, err
+	}
+	for _, clusterRole := range inv.clusterRoles {
+		if referenced[objectKey(ClusterRoleKind, "", clusterRole.Name)] || aggregated[clusterRole.Name] ||
+			isDefaultObject(clusterRole.Labels) {
+			continue
+		}
+		findings = append(findings, LintFinding{Rule: LintUnreferencedRole, Kind: ClusterRoleKind,
+			Name: clusterRole.Name, Message: "ClusterRole is not referenced by any binding"})
+	}
+
+	findings = append(findings, lintPolicyRules(inv)...)
+
+	suppressed := inv.suppressedRules()
+	for i := range findings {
+		f := &findings[i]
+		f.Severity = lintRuleByID(f.Rule).Severity
+		rules := suppressed[objectKey(f.Kind, f.Namespace, f.Name)]
+		f.Suppressed = rules[f.Rule] || rules[lintSuppressAll]
+	}
+
+	sortLintFindings(findings)
+	return findings, nil
+}
+
+// suppressedRules returns the rules suppressed by annotations of the RBAC objects by object key.
+func (inv rbacInventory) suppressedRules() map[string]map[string]bool {
+	suppressed := make(map[string]map[string]bool)
+	add := func(kind, namespace, name string, annotations map[string]string) {
+		if rules := suppressedRules(annotations); rules != nil {
+			suppressed[objectKey(kind, namespace, name)] = rules
+		}
+	}
+	for _, role := range inv.roles {
+		add(RoleKind, role.Namespace, role.Name, role.Annotations)
+	}
+	for _, clusterRole := range inv.clusterRoles {
+		add(ClusterRoleKind, "", clusterRole.Name, clusterRole.Annotations)
+	}
+	for _, rb := range inv.roleBindings {
+		add(RoleBindingKind, rb.Namespace, rb.Name, rb.Annotations)
+	}
+	for _, crb := range inv.clusterRoleBindings {
+		add(ClusterRoleBindingKind, "", crb.Name, crb.Annotations)
+	}
+	return suppressed
+}
+
+// filterLintFindings returns the findings with at least the specified severity.
+func filterLintFindings(findings []LintFinding, minSeverity string) []LintFinding {
+	var filtered []LintFinding
+	for _, f := range findings {
+		if severityRank[f.Severity] >= severityRank[minSeverity] {
+			filtered = append(filtered, f)
+		}
+	}
+	return filtered
+}
+
+// countUnsuppressed returns the number of findings which are not suppressed.
+func countUnsuppressed(findings []LintFinding) int {
+	count := 0
+	for _, f := range findings {
+		if !f.Suppressed {
+			count++
+		}
+	}
+	return count
+}
+
+// aggregatedClusterRoles returns the names of ClusterRoles whose rules are aggregated into another ClusterRole.
+func aggregatedClusterRoles(clusterRoles []rbac.ClusterRole) (map[string]bool, error) {
+	aggregated := make(map[string]bool)
+	for _, aggregating := range clusterRoles {
+		if aggregating.AggregationRule == nil {
+			continue
+		}
+		for _, labelSelector := range aggregating.AggregationRule.ClusterRoleSelectors {
+			selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
+			if err != nil {
+				return nil, fmt.Errorf("parsing aggregation rule of ClusterRole %s: %v", aggregating.Name, err)
+			}
+			for _, clusterRole := range clusterRoles {
+				if clusterRole.Name != aggregating.Name && selector.Matches(labels.Set(clusterRole.Labels)) {
+					aggregated[clusterRole.Name] = true
+				}
+			}
+		}
+	}
+	return aggregated, nil
+}
+
+func sortLintFindings(findings []LintFinding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Severity != b.Severity {
+			return severityRank[a.Severity] > severityRank[b.Severity]
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Rule < b.Rule
+	})
+}
+
+// PrintLint prints the specified lint findings as a table, followed by the number of suppressed findings.
+func (p *Printer) PrintLint(findings []LintFinding) {
+	suppressed := len(findings) - countUnsuppressed(findings)
+	if suppressed == len(findings) {
+		_, _ = fmt.Fprintln(p.out, "No problems found")
+	} else {
+		wr := new(tabwriter.Writer)
+		wr.Init(p.out, 0, 8, 2, ' ', 0)
+
+		_, _ = fmt.Fprintln(wr, "SEVERITY\tRULE\tKIND\tNAMESPACE\tNAME\tMESSAGE")
+		for _, f := range findings {
+			if !f.Suppressed {
+				_, _ = fmt.Fprintf(wr, "%s\t%s\t%s\t%s\t%s\t%s\n", strings.ToUpper(f.Severity), f.Rule, f.Kind, f.Namespace, f.Name, f.Message)
+			}
+		}
+		_ = wr.Flush()
+	}
+
+	if suppressed > 0 {
+		_, _ = fmt.Fprintf(p.out, "\n%d finding(s) suppressed by the %s annotation\n", suppressed, lintSuppressionAnnotation)
+	}
+}
+
+// ExportLint prints the specified lint findings as JSON.
+func (p *Printer) ExportLint(findings []LintFinding) error {
+	if findings == nil {
+		findings = []LintFinding{}
+	}
+	encoder := json.NewEncoder(p.out)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(findings)
+}
+
+// sarifLevels maps the severity levels of lint findings to SARIF levels.
+var sarifLevels = map[string]string{
+	SeverityLow:    "note",
+	SeverityMedium: "warning",
+	SeverityHigh:   "error",
+}
+
+// PrintLintSARIF prints the specified lint findings as results of a SARIF log. If the given ObjectLocator is not nil,
+// the results point at the manifest files defining the reported objects.
+func (p *Printer) PrintLintSARIF(findings []LintFinding, locator ObjectLocator) {
+	run := sarifRun{
+		Tool:    sarifTool{Driver: sarifDriver{Name: toolName, InformationURI: toolURI}},
+		Results: []sarifResult{},
+	}
+	for _, rule := range lintRules {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:                   rule.ID,
+			ShortDescription:     sarifMessage{Text: rule.Description},
+			DefaultConfiguration: &sarifConfiguration{Level: sarifLevels[rule.Severity]},
+		})
+	}
+
+	for _, f := range findings {
+		result := sarifResult{
+			RuleID:    f.Rule,
+			Level:     sarifLevels[f.Severity],
+			Message:   sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{sarifLocationOf(locator, f.Kind, f.Namespace, f.Name)},
+		}
+		if f.Suppressed {
+			result.Suppressions = []sarifSuppression{
+				{Kind: "inSource", Justification: "Suppressed by the " + lintSuppressionAnnotation + " annotation"},
+			}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	p.printSARIF(run)
+}
+
+// NewLintCommand constructs the lint command with the specified IOStreams and ConfigFlags.
+func NewLintCommand(streams clioptions.IOStreams, configFlags *clioptions.ConfigFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          lintUsage,
+		Short:        "Report orphaned and dangling references between RBAC objects",
+		Long:         lintLong,
+		Example:      lintExample,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			files, err := cmd.Flags().GetStringSlice(fromFileFlag)
+			if err != nil {
+				return err
+			}
+			output, err := cmd.Flags().GetString(outputFlag)
+			if err != nil {
+				return err
+			}
+			output = strings.ToLower(output)
+			if output != "" && output != outputJson && output != outputSARIF {
+				return fmt.Errorf("invalid output format: %v", output)
+			}
+			minSeverity, err := cmd.Flags().GetString(minSeverityFlag)
+			if err != nil {
+				return err
+			}
+			minSeverity = strings.ToLower(minSeverity)
+			if _, ok := severityRank[minSeverity]; !ok {
+				return fmt.Errorf("invalid severity: %v: must be one of low, medium or high", minSeverity)
+			}
+
+			var linter *Linter
+			var locator ObjectLocator
+			if len(files) > 0 {
+				manifests, err := LoadManifests(files)
+				if err != nil {
+					return err
+				}
+				source, err := manifests.Source()
+				if err != nil {
+					return err
+				}
+				linter = NewLinter(source, manifests.SubjectSource(),
+					len(manifests.namespaces) > 0, len(manifests.serviceAccounts) > 0)
+				locator = manifests
+			} else {
+				restConfig, err := configFlags.ToRESTConfig()
+				if err != nil {
+					return fmt.Errorf("getting rest config: %v", err)
+				}
+				client, err := kubernetes.NewForConfig(restConfig)
+				if err != nil {
+					return fmt.Errorf("creating API client: %v", err)
+				}
+				linter = NewLinter(NewClientRBACSource(client.RbacV1()), NewClientSubjectSource(client.CoreV1()), true, true)
+			}
+
+			findings, err := linter.Lint()
+			if err != nil {
+				return err
+			}
+			findings = filterLintFindings(findings, minSeverity)
+
+			printer := NewPrinter(streams.Out, false)
+			switch output {
+			case outputJson:
+				if err := printer.ExportLint(findings); err != nil {
+					return fmt.Errorf("exporting findings: %v", err)
+				}
+			case outputSARIF:
+				printer.PrintLintSARIF(findings, locator)
+			default:
+				printer.PrintLint(findings)
+			}
+
+			if count := countUnsuppressed(findings); count > 0 {
+				return fmt.Errorf("%d problem(s) found", count)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceP(fromFileFlag, "f", nil, "Check RBAC objects defined in manifest files or directories instead of the cluster")
+	cmd.Flags().StringP(outputFlag, "o", "", "Output format. One of: json, sarif.")
+	cmd.Flags().String(minSeverityFlag, SeverityLow, "Only report findings with at least the given severity. One of: low, medium, high")
+
+	return cmd
+}
@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	rbac "k8s.io/api/rbac/v1"
+)
+
+// Severity levels of lint findings.
+const (
+	SeverityLow    = "low"
+	SeverityMedium = "medium"
+	SeverityHigh   = "high"
+)
+
+// severityRank orders the severity levels from the least to the most severe.
+var severityRank = map[string]int{
+	SeverityLow:    1,
+	SeverityMedium: 2,
+	SeverityHigh:   3,
+}
+
+// Hygiene lint rules reported for risky PolicyRules.
+const (
+	LintWildcardVerb       = "wildcard-verb"
+	LintWildcardResource   = "wildcard-resource"
+	LintWildcardAPIGroup   = "wildcard-api-group"
+	LintSecretsClusterWide = "secrets-cluster-wide"
+	LintNodesProxy         = "nodes-proxy"
+	LintPodsExec           = "pods-exec"
+	LintEscalationVerb     = "escalation-verb"
+	LintStatusWrite        = "status-write"
+)
+
+// lintSuppressionAnnotation lists the IDs of the rules not to be reported for the annotated object, separated by
+// commas, or lintSuppressAll to suppress any rule.
+const (
+	lintSuppressionAnnotation = "kubectl-who-can.aquasecurity.github.io/lint-ignore"
+	lintSuppressAll           = "*"
+)
+
+// LintRule describes a rule reported by the Linter.
+type LintRule struct {
+	ID          string
+	Severity    string
+	Description string
+}
+
+// lintRules lists all rules reported by the Linter.
+var lintRules = []LintRule{
+	{LintDanglingRoleRef, SeverityHigh, "Binding references a Role or ClusterRole that doesn't exist"},
+	{LintMissingServiceAccount, SeverityMedium, "Binding references a ServiceAccount that doesn't exist"},
+	{LintMissingNamespace, SeverityMedium, "Binding references a ServiceAccount in a namespace that doesn't exist"},
+	{LintMissingSANamespace, SeverityMedium, "ClusterRoleBinding references a ServiceAccount without namespace"},
+	{LintEmptySubjects, SeverityLow, "Binding has no subjects"},
+	{LintUnreferencedRole, SeverityLow, "Role or ClusterRole is not referenced by any binding"},
+	{LintWildcardVerb, SeverityHigh, "Rule grants any verb"},
+	{LintWildcardResource, SeverityHigh, "Rule grants access to any resource or non-resource URL"},
+	{LintWildcardAPIGroup, SeverityMedium, "Rule grants access to resources in any API group"},
+	{LintSecretsClusterWide, SeverityHigh, "ClusterRoleBinding grants read access to secrets in all namespaces"},
+	{LintNodesProxy, SeverityHigh, "Rule grants access to nodes/proxy, which bypasses admission control and audit logging"},
+	{LintPodsExec, SeverityMedium, "Rule allows executing commands in containers through pods/exec"},
+	{LintEscalationVerb, SeverityHigh, "Rule grants the bind, escalate or impersonate verb, which allows privilege escalation"},
+	{LintStatusWrite, SeverityMedium, "Rule grants write access on the status of resources"},
+}
+
+// lintRuleByID returns the LintRule with the given ID.
+func lintRuleByID(id string) LintRule {
+	for _, rule := range lintRules {
+		if rule.ID == id {
+			return rule
+		}
+	}
+	return LintRule{ID: id, Severity: SeverityLow}
+}
+
+var (
+	readVerbs  = []string{"get", "list", "watch"}
+	writeVerbs = []string{"create", "update", "patch", "delete", "deletecollection"}
+)
+
+// policyRuleCheck reports the PolicyRules of Roles and ClusterRoles for which matches returns true.
+type policyRuleCheck struct {
+	rule    string
+	matches func(rule rbac.PolicyRule) bool
+}
+
+var policyRuleChecks = []policyRuleCheck{
+	{LintWildcardVerb, func(rule rbac.PolicyRule) bool {
+		return containsString(rule.Verbs, rbac.VerbAll)
+	}},
+	{LintWildcardResource, func(rule rbac.PolicyRule) bool {
+		return containsString(rule.Resources, rbac.ResourceAll) || containsString(rule.NonResourceURLs, rbac.NonResourceAll)
+	}},
+	{LintWildcardAPIGroup, func(rule rbac.PolicyRule) bool {
+		return containsString(rule.APIGroups, rbac.APIGroupAll)
+	}},
+	{LintNodesProxy, func(rule rbac.PolicyRule) bool {
+		return grantsCoreResource(rule, nil, "nodes/proxy")
+	}},
+	{LintPodsExec, func(rule rbac.PolicyRule) bool {
+		// Clients upgrading the connection to a WebSocket request pods/exec with the get verb.
+		return grantsCoreResource(rule, []string{"create", "get"}, "pods/exec")
+	}},
+	{LintEscalationVerb, func(rule rbac.PolicyRule) bool {
+		return containsString(rule.Verbs, "bind") || containsString(rule.Verbs, "escalate") ||
+			containsString(rule.Verbs, "impersonate")
+	}},
+	{LintStatusWrite, func(rule rbac.PolicyRule) bool {
+		if !grantsAnyVerb(rule, writeVerbs) {
+			return false
+		}
+		for _, resource := range rule.Resources {
+			if strings.HasSuffix(resource, "/status") {
+				return true
+			}
+		}
+		return false
+	}},
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// grantsAnyVerb returns true if the rule grants any of the given verbs, or any verb at all if verbs is empty.
+func grantsAnyVerb(rule rbac.PolicyRule, verbs []string) bool {
+	if len(verbs) == 0 || containsString(rule.Verbs, rbac.VerbAll) {
+		return len(rule.Verbs) > 0
+	}
+	for _, verb := range verbs {
+		if containsString(rule.Verbs, verb) {
+			return true
+		}
+	}
+	return false
+}
+
+// grantsCoreResource returns true if the rule grants any of the given verbs on the resource of the core API group,
+// either named explicitly or through the `*` resource.
+func grantsCoreResource(rule rbac.PolicyRule, verbs []string, resource string) bool {
+	return grantsAnyVerb(rule, verbs) &&
+		(containsString(rule.Resources, resource) || containsString(rule.Resources, rbac.ResourceAll)) &&
+		(containsString(rule.APIGroups, "") || containsString(rule.APIGroups, rbac.APIGroupAll))
+}
+
+// isDefaultObject returns true if the object is one of the default Roles, ClusterRoles or bindings of Kubernetes,
+// which are exempt from the hygiene rules.
+func isDefaultObject(labels map[string]string) bool {
+	return labels[bootstrappingLabel] == bootstrappingLabelDefaults
+}
+
+// lintPolicyRules returns the findings of the hygiene rules for the given RBAC objects. Each rule is reported at most
+// once for every Role or ClusterRole, along with the first PolicyRule matching it. The rules of the default Roles and
+// ClusterRoles are reported on the bindings which are not default objects themselves, e.g. a custom
+// ClusterRoleBinding to cluster-admin.
+func lintPolicyRules(inv rbacInventory) []LintFinding {
+	var findings []LintFinding
+	checkRules := func(kind, namespace, name, prefix string, rules []rbac.PolicyRule) {
+		for _, check := range policyRuleChecks {
+			for _, rule := range rules {
+				if check.matches(rule) {
+					findings = append(findings, LintFinding{Rule: check.rule, Kind: kind, Namespace: namespace, Name: name,
+						Message: fmt.Sprintf("%s%s: %s", prefix, lintRuleByID(check.rule).Description, ruleString(rule))})
+					break
+				}
+			}
+		}
+	}
+
+	defaultRoles := make(map[string][]rbac.PolicyRule)
+	for _, role := range inv.roles {
+		if isDefaultObject(role.Labels) {
+			defaultRoles[objectKey(RoleKind, role.Namespace, role.Name)] = role.Rules
+		} else {
+			checkRules(RoleKind, role.Namespace, role.Name, "", role.Rules)
+		}
+	}
+
+	clusterRoles := make(map[string]rbac.ClusterRole)
+	for _, clusterRole := range inv.clusterRoles {
+		clusterRoles[clusterRole.Name] = clusterRole
+		if isDefaultObject(clusterRole.Labels) {
+			defaultRoles[objectKey(ClusterRoleKind, "", clusterRole.Name)] = clusterRole.Rules
+		} else {
+			checkRules(ClusterRoleKind, "", clusterRole.Name, "", clusterRole.Rules)
+		}
+	}
+
+	checkDefaultRole := func(kind, namespace, name string, labels map[string]string, roleRef rbac.RoleRef) {
+		roleNamespace := namespace
+		if roleRef.Kind == ClusterRoleKind {
+			roleNamespace = ""
+		}
+		rules, ok := defaultRoles[objectKey(roleRef.Kind, roleNamespace, roleRef.Name)]
+		if ok && !isDefaultObject(labels) {
+			checkRules(kind, namespace, name, fmt.Sprintf("%s %s: ", roleRef.Kind, roleRef.Name), rules)
+		}
+	}
+	for _, rb := range inv.roleBindings {
+		checkDefaultRole(RoleBindingKind, rb.Namespace, rb.Name, rb.Labels, rb.RoleRef)
+	}
+	for _, crb := range inv.clusterRoleBindings {
+		checkDefaultRole(ClusterRoleBindingKind, "", crb.Name, crb.Labels, crb.RoleRef)
+	}
+
+	for _, crb := range inv.clusterRoleBindings {
+		clusterRole, ok := clusterRoles[crb.RoleRef.Name]
+		if crb.RoleRef.Kind != ClusterRoleKind || !ok || isDefaultObject(crb.Labels) {
+			continue
+		}
+		for _, rule := range clusterRole.Rules {
+			if grantsCoreResource(rule, readVerbs, "secrets") && len(rule.ResourceNames) == 0 {
+				findings = append(findings, LintFinding{Rule: LintSecretsClusterWide, Kind: ClusterRoleBindingKind, Name: crb.Name,
+					Message: fmt.Sprintf("ClusterRole %s grants read access to secrets in all namespaces: %s", clusterRole.Name, ruleString(rule))})
+				break
+			}
+		}
+	}
+
+	return findings
+}
+
+// suppressedRules returns the IDs of the rules suppressed by the lint-ignore annotation of an object.
+func suppressedRules(annotations map[string]string) map[string]bool {
+	value, ok := annotations[lintSuppressionAnnotation]
+	if !ok {
+		return nil
+	}
+	suppressed := make(map[string]bool)
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			suppressed[id] = true
+		}
+	}
+	return suppressed
+}
@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var lintFindings = []LintFinding{
+	{Rule: LintDanglingRoleRef, Severity: SeverityHigh, Kind: ClusterRoleBindingKind, Name: "deployers", Message: "ClusterRole deployer does not exist"},
+	{Rule: LintMissingServiceAccount, Severity: SeverityMedium, Kind: RoleBindingKind, Namespace: "foo", Name: "operator", Message: "ServiceAccount foo/operator does not exist"},
+	{Rule: LintPodsExec, Severity: SeverityMedium, Kind: RoleKind, Namespace: "foo", Name: "debug", Message: "Rule allows executing commands in containers through pods/exec: create pods/exec", Suppressed: true},
+}
+
+func TestLinter_Lint(t *testing.T) {
+	// given
+	source, err := NewStaticRBACSource(
+		&rbac.Role{ObjectMeta: metav1.ObjectMeta{Name: "view-pods", Namespace: "foo"}},
+		&rbac.Role{ObjectMeta: metav1.ObjectMeta{Name: "unused", Namespace: "foo"}},
+		&rbac.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "admin"}},
+		&rbac.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "unused"}},
+		&rbac.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "view", Labels: map[string]string{bootstrappingLabel: bootstrappingLabelDefaults}}},
+		&rbac.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "monitoring"},
+			AggregationRule: &rbac.AggregationRule{ClusterRoleSelectors: []metav1.LabelSelector{
+				{MatchLabels: map[string]string{"aggregate-to-monitoring": "true"}},
+			}},
+		},
+		&rbac.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "monitoring-pods", Labels: map[string]string{"aggregate-to-monitoring": "true"}}},
+		&rbac.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "view-pods", Namespace: "foo"},
+			RoleRef:    rbac.RoleRef{Kind: RoleKind, Name: "view-pods"},
+			Subjects: []rbac.Subject{
+				{Kind: rbac.UserKind, Name: "alice"},
+				{Kind: rbac.ServiceAccountKind, Name: "operator"},
+				{Kind: rbac.ServiceAccountKind, Name: "ghost"},
+				{Kind: rbac.ServiceAccountKind, Name: "builder", Namespace: "ci"},
+			},
+		},
+		&rbac.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "edit", Namespace: "foo"},
+			RoleRef:    rbac.RoleRef{Kind: RoleKind, Name: "edit"},
+			Subjects:   []rbac.Subject{{Kind: rbac.GroupKind, Name: "developers"}},
+		},
+		&rbac.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "admins"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "admin"},
+		},
+		&rbac.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "monitoring"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "monitoring"},
+			Subjects:   []rbac.Subject{{Kind: rbac.GroupKind, Name: "sre"}, {Kind: rbac.ServiceAccountKind, Name: "prometheus"}},
+		},
+		&rbac.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "deployers"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "deployer"},
+			Subjects:   []rbac.Subject{{Kind: rbac.GroupKind, Name: "ci"}},
+		},
+	)
+	require.NoError(t, err)
+	subjects := &staticSubjectSource{
+		namespaces:      []core.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}},
+		serviceAccounts: []core.ServiceAccount{{ObjectMeta: metav1.ObjectMeta{Name: "operator", Namespace: "foo"}}},
+	}
+
+	// when
+	findings, err := NewLinter(source, subjects, true, true).Lint()
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []LintFinding{
+		{Rule: LintDanglingRoleRef, Severity: SeverityHigh, Kind: ClusterRoleBindingKind, Name: "deployers", Message: "ClusterRole deployer does not exist"},
+		{Rule: LintDanglingRoleRef, Severity: SeverityHigh, Kind: RoleBindingKind, Namespace: "foo", Name: "edit", Message: "Role foo/edit does not exist"},
+		{Rule: LintMissingSANamespace, Severity: SeverityMedium, Kind: ClusterRoleBindingKind, Name: "monitoring", Message: "ServiceAccount prometheus has no namespace"},
+		{Rule: LintMissingNamespace, Severity: SeverityMedium, Kind: RoleBindingKind, Namespace: "foo", Name: "view-pods", Message: "Namespace ci of ServiceAccount ci/builder does not exist"},
+		{Rule: LintMissingServiceAccount, Severity: SeverityMedium, Kind: RoleBindingKind, Namespace: "foo", Name: "view-pods", Message: "ServiceAccount foo/ghost does not exist"},
+		{Rule: LintUnreferencedRole, Severity: SeverityLow, Kind: ClusterRoleKind, Name: "unused", Message: "ClusterRole is not referenced by any binding"},
+		{Rule: LintEmptySubjects, Severity: SeverityLow, Kind: ClusterRoleBindingKind, Name: "admins", Message: "ClusterRoleBinding has no subjects"},
+		{Rule: LintUnreferencedRole, Severity: SeverityLow, Kind: RoleKind, Namespace: "foo", Name: "unused", Message: "Role is not referenced by any binding"},
+	}, findings)
+
+	// when
+	findings, err = NewLinter(source, subjects, false, false).Lint()
+
+	// then
+	require.NoError(t, err)
+	assert.Len(t, findings, 6, "missing namespaces and ServiceAccounts must not be reported")
+}
+
+func TestLinter_Lint_PolicyRules(t *testing.T) {
+	// given
+	defaults := map[string]string{bootstrappingLabel: bootstrappingLabelDefaults}
+	source, err := NewStaticRBACSource(
+		&rbac.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin", Labels: defaults},
+			Rules:      []rbac.PolicyRule{{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}}},
+		},
+		&rbac.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "operator"},
+			Rules: []rbac.PolicyRule{
+				{Verbs: []string{"*"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+				{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"secrets", "nodes/proxy"}},
+				{Verbs: []string{"impersonate"}, APIGroups: []string{""}, Resources: []string{"users"}},
+				{Verbs: []string{"patch"}, APIGroups: []string{"apps"}, Resources: []string{"deployments/status"}},
+			},
+		},
+		&rbac.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "read-config", Annotations: map[string]string{lintSuppressionAnnotation: "*"}},
+			Rules: []rbac.PolicyRule{
+				{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: []string{"config"}},
+				{Verbs: []string{"get"}, APIGroups: []string{"*"}, Resources: []string{"configmaps"}},
+			},
+		},
+		&rbac.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: "debug", Namespace: "foo", Annotations: map[string]string{lintSuppressionAnnotation: "pods-exec"}},
+			Rules:      []rbac.PolicyRule{{Verbs: []string{"create"}, APIGroups: []string{""}, Resources: []string{"pods/exec"}}},
+		},
+		&rbac.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin", Labels: defaults},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "cluster-admin"},
+			Subjects:   []rbac.Subject{{Kind: rbac.GroupKind, Name: "system:masters"}},
+		},
+		&rbac.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "mallory-admin"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "cluster-admin"},
+			Subjects:   []rbac.Subject{{Kind: rbac.UserKind, Name: "mallory"}},
+		},
+		&rbac.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "operator"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "operator"},
+			Subjects:   []rbac.Subject{{Kind: rbac.UserKind, Name: "operator"}},
+		},
+		&rbac.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "read-config"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "read-config"},
+			Subjects:   []rbac.Subject{{Kind: rbac.UserKind, Name: "app"}},
+		},
+		&rbac.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "debug", Namespace: "foo"},
+			RoleRef:    rbac.RoleRef{Kind: RoleKind, Name: "debug"},
+			Subjects:   []rbac.Subject{{Kind: rbac.UserKind, Name: "alice"}},
+		},
+	)
+	require.NoError(t, err)
+
+	// when
+	findings, err := NewLinter(source, nil, false, false).Lint()
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []LintFinding{
+		{Rule: LintEscalationVerb, Severity: SeverityHigh, Kind: ClusterRoleKind, Name: "operator",
+			Message: "Rule grants the bind, escalate or impersonate verb, which allows privilege escalation: impersonate users"},
+		{Rule: LintNodesProxy, Severity: SeverityHigh, Kind: ClusterRoleKind, Name: "operator",
+			Message: "Rule grants access to nodes/proxy, which bypasses admission control and audit logging: get,list secrets,nodes/proxy"},
+		{Rule: LintWildcardVerb, Severity: SeverityHigh, Kind: ClusterRoleKind, Name: "operator",
+			Message: "Rule grants any verb: * deployments.apps"},
+		{Rule: LintNodesProxy, Severity: SeverityHigh, Kind: ClusterRoleBindingKind, Name: "mallory-admin",
+			Message: "ClusterRole cluster-admin: Rule grants access to nodes/proxy, which bypasses admission control and audit logging: * *.*"},
+		{Rule: LintSecretsClusterWide, Severity: SeverityHigh, Kind: ClusterRoleBindingKind, Name: "mallory-admin",
+			Message: "ClusterRole cluster-admin grants read access to secrets in all namespaces: * *.*"},
+		{Rule: LintWildcardResource, Severity: SeverityHigh, Kind: ClusterRoleBindingKind, Name: "mallory-admin",
+			Message: "ClusterRole cluster-admin: Rule grants access to any resource or non-resource URL: * *.*"},
+		{Rule: LintWildcardVerb, Severity: SeverityHigh, Kind: ClusterRoleBindingKind, Name: "mallory-admin",
+			Message: "ClusterRole cluster-admin: Rule grants any verb: * *.*"},
+		{Rule: LintSecretsClusterWide, Severity: SeverityHigh, Kind: ClusterRoleBindingKind, Name: "operator",
+			Message: "ClusterRole operator grants read access to secrets in all namespaces: get,list secrets,nodes/proxy"},
+		{Rule: LintStatusWrite, Severity: SeverityMedium, Kind: ClusterRoleKind, Name: "operator",
+			Message: "Rule grants write access on the status of resources: patch deployments/status.apps"},
+		{Rule: LintWildcardAPIGroup, Severity: SeverityMedium, Kind: ClusterRoleKind, Name: "read-config",
+			Message: "Rule grants access to resources in any API group: get configmaps.*", Suppressed: true},
+		{Rule: LintPodsExec, Severity: SeverityMedium, Kind: ClusterRoleBindingKind, Name: "mallory-admin",
+			Message: "ClusterRole cluster-admin: Rule allows executing commands in containers through pods/exec: * *.*"},
+		{Rule: LintWildcardAPIGroup, Severity: SeverityMedium, Kind: ClusterRoleBindingKind, Name: "mallory-admin",
+			Message: "ClusterRole cluster-admin: Rule grants access to resources in any API group: * *.*"},
+		{Rule: LintPodsExec, Severity: SeverityMedium, Kind: RoleKind, Namespace: "foo", Name: "debug",
+			Message: "Rule allows executing commands in containers through pods/exec: create pods/exec", Suppressed: true},
+	}, findings)
+	assert.Equal(t, 11, countUnsuppressed(findings))
+	assert.Equal(t, findings[:8], filterLintFindings(findings, SeverityHigh))
+}
+
+func TestClientSubjectSource(t *testing.T) {
+	// given
+	client := fake.NewSimpleClientset(
+		&core.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "foo"}},
+		&core.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "operator", Namespace: "foo"}},
+	)
+	source := NewClientSubjectSource(client.CoreV1())
+
+	// when
+	namespaces, err := source.ListNamespaces()
+	require.NoError(t, err)
+	serviceAccounts, err := source.ListServiceAccounts()
+	require.NoError(t, err)
+
+	// then
+	require.Len(t, namespaces, 1)
+	assert.Equal(t, "foo", namespaces[0].Name)
+	require.Len(t, serviceAccounts, 1)
+	assert.Equal(t, "operator", serviceAccounts[0].Name)
+}
+
+func TestPrinter_PrintLint(t *testing.T) {
+	var buf bytes.Buffer
+	NewPrinter(&buf, false).PrintLint(lintFindings)
+
+	assert.Equal(t, `SEVERITY  RULE                     KIND                NAMESPACE  NAME       MESSAGE
+HIGH      dangling-role-ref        ClusterRoleBinding             deployers  ClusterRole deployer does not exist
+MEDIUM    missing-service-account  RoleBinding         foo        operator   ServiceAccount foo/operator does not exist
+
+1 finding(s) suppressed by the kubectl-who-can.aquasecurity.github.io/lint-ignore annotation
+`, buf.String())
+
+	buf.Reset()
+	NewPrinter(&buf, false).PrintLint(nil)
+	assert.Equal(t, "No problems found\n", buf.String())
+}
+
+func TestPrinter_ExportLint(t *testing.T) {
+	var buf bytes.Buffer
+	NewPrinter(&buf, false).ExportLint(lintFindings)
+
+	assert.JSONEq(t, `[
+  {"rule": "dangling-role-ref", "severity": "high", "kind": "ClusterRoleBinding", "name": "deployers", "message": "ClusterRole deployer does not exist"},
+  {"rule": "missing-service-account", "severity": "medium", "kind": "RoleBinding", "namespace": "foo", "name": "operator", "message": "ServiceAccount foo/operator does not exist"},
+  {"rule": "pods-exec", "severity": "medium", "kind": "Role", "namespace": "foo", "name": "debug", "message": "Rule allows executing commands in containers through pods/exec: create pods/exec", "suppressed": true}
+]`, buf.String())
+
+	buf.Reset()
+	NewPrinter(&buf, false).ExportLint(nil)
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+func TestPrinter_PrintLintSARIF(t *testing.T) {
+	// given
+	locator := locatorStub{
+		objectKey(RoleKind, "foo", "debug"): {File: "manifests/foo.yaml", Line: 12},
+	}
+
+	// when
+	var buf bytes.Buffer
+	NewPrinter(&buf, false).PrintLintSARIF(lintFindings[1:], locator)
+
+	// then
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	assert.Len(t, run.Tool.Driver.Rules, len(lintRules))
+	assert.Equal(t, &sarifConfiguration{Level: "error"}, run.Tool.Driver.Rules[0].DefaultConfiguration)
+	require.Len(t, run.Results, 2)
+
+	assert.Equal(t, LintMissingServiceAccount, run.Results[0].RuleID)
+	assert.Equal(t, "warning", run.Results[0].Level)
+	assert.Nil(t, run.Results[0].Locations[0].PhysicalLocation)
+	assert.Empty(t, run.Results[0].Suppressions)
+
+	assert.Equal(t, LintPodsExec, run.Results[1].RuleID)
+	assert.Equal(t, "manifests/foo.yaml", run.Results[1].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, 12, run.Results[1].Locations[0].PhysicalLocation.Region.StartLine)
+	assert.Equal(t, []sarifSuppression{
+		{Kind: "inSource", Justification: "Suppressed by the kubectl-who-can.aquasecurity.github.io/lint-ignore annotation"},
+	}, run.Results[1].Suppressions)
+}
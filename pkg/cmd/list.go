@@ -5,19 +5,23 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	core "k8s.io/api/core/v1"
 	rbac "k8s.io/api/rbac/v1"
-	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	clioptions "k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	clientcore "k8s.io/client-go/kubernetes/typed/core/v1"
-	clientrbac "k8s.io/client-go/kubernetes/typed/rbac/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog/v2"
@@ -56,7 +60,34 @@ NONRESOURCEURL is a partial URL that starts with "/".`
   kubectl who-can get pods --subresource=log
 
   # List who can access the URL /logs/
-  kubectl who-can get /logs`
+  kubectl who-can get /logs
+
+  # List who can get secrets in namespaces "a" and "b", and in any namespace labelled env=prod
+  kubectl who-can get secrets -n a,b --namespace-selector env=prod
+
+  # List who can delete deployments in namespace "foo" through bindings labelled team=payments
+  kubectl who-can delete deployments -n foo --binding-selector team=payments
+
+  # List which ServiceAccounts outside the kube-system namespace can get pods in any of the available namespaces
+  kubectl who-can get pods -A --subject-kind ServiceAccount --subject-namespace '!kube-system'
+
+  # List which users other than the ones with the system: prefix can delete namespaces
+  kubectl who-can delete namespaces --subject-kind User --exclude-subject 'system:*'
+
+  # List who can delete pods in any of the available namespaces, hiding built-in and expected subjects
+  kubectl who-can delete pods -A --hide-system --ignore-file ignore.yaml -o json
+
+  # Draw a graph of subjects, bindings and roles allowing to delete nodes
+  kubectl who-can delete nodes -o dot | dot -Tsvg > delete-nodes.svg
+
+  # Generate an HTML report of who can create pods/exec in any of the available namespaces
+  kubectl who-can create pods --subresource=exec -A -o html > report.html
+
+  # Report which bindings defined in the manifests directory allow to read secrets, for code scanning
+  kubectl who-can get secrets -A -f manifests/ -o sarif > who-can.sarif
+
+  # Watch who gains or loses permissions to get secrets in any of the available namespaces
+  kubectl who-can get secrets -A --watch`
 )
 
 const (
@@ -64,34 +95,112 @@ const (
 	RoleKind = "Role"
 	// ClusterRoleKind is the RoleRef's Kind referencing a ClusterRole.
 	ClusterRoleKind = "ClusterRole"
+	// RoleBindingKind is the Kind of a RoleBinding.
+	RoleBindingKind = "RoleBinding"
+	// ClusterRoleBindingKind is the Kind of a ClusterRoleBinding.
+	ClusterRoleBindingKind = "ClusterRoleBinding"
+	// NamespaceKind is the Kind of a Namespace.
+	NamespaceKind = "Namespace"
 )
 
 const (
-	subResourceFlag   = "subresource"
-	allNamespacesFlag = "all-namespaces"
-	namespaceFlag     = "namespace"
-	outputFlag        = "output"
-	outputWide        = "wide"
-	outputJson        = "json"
+	subResourceFlag       = "subresource"
+	allNamespacesFlag     = "all-namespaces"
+	namespaceFlag         = "namespace"
+	outputFlag            = "output"
+	watchFlag             = "watch"
+	fromFileFlag          = "from-file"
+	roleSelectorFlag      = "role-selector"
+	bindingSelectorFlag   = "binding-selector"
+	namespaceSelectorFlag = "namespace-selector"
+	apiGroupFlag          = "api-group"
+	allAPIGroupsFlag      = "all-api-groups"
+	outputWide            = "wide"
+	outputJson            = "json"
+	outputDot             = "dot"
+	outputMermaid         = "mermaid"
+	outputHTML            = "html"
+	outputSARIF           = "sarif"
+
+	// coreGroup is the value of the --api-group flag standing for the core API group, whose name is empty.
+	coreGroup = "core"
 )
 
 // Action represents an action a subject can be given permission to.
 type Action struct {
-	Verb         string
-	Resource     string
-	ResourceName string
-	SubResource  string
-
-	NonResourceURL string
+	Verb         string `json:"verb"`
+	Resource     string `json:"resource,omitempty"`
+	ResourceName string `json:"resourceName,omitempty"`
+	SubResource  string `json:"subResource,omitempty"`
+	// APIGroup restricts the Resource to the API group, where `core` stands for the core API group and `*` for
+	// PolicyRules granting all API groups.
+	APIGroup string `json:"apiGroup,omitempty"`
+	// AllAPIGroups checks a Resource which is not qualified by an API group in each API group serving it, instead of
+	// in the preferred one only.
+	AllAPIGroups bool `json:"allAPIGroups,omitempty"`
+
+	NonResourceURL string `json:"nonResourceURL,omitempty"`
+
+	Namespace     string `json:"namespace,omitempty"`
+	AllNamespaces bool   `json:"allNamespaces,omitempty"`
+
+	// RoleSelector and BindingSelector restrict the checked Roles and ClusterRoles, and RoleBindings and
+	// ClusterRoleBindings respectively, to the ones whose labels match the label selector.
+	RoleSelector    string `json:"roleSelector,omitempty"`
+	BindingSelector string `json:"bindingSelector,omitempty"`
+
+	// Namespaces and NamespaceSelector check the action in each of the listed namespaces and in each namespace whose
+	// labels match the label selector, instead of the single Namespace.
+	Namespaces        []string `json:"namespaces,omitempty"`
+	NamespaceSelector string   `json:"namespaceSelector,omitempty"`
+
+	// groupResources holds the GroupResources of the Resource once resolved, so that the API server is not queried
+	// again each time the Action is checked.
+	groupResources []schema.GroupResource
+}
 
-	Namespace     string
-	AllNamespaces bool
+// multiNamespace returns true if the Action is checked in a selected set of namespaces rather than in a single
+// namespace or in all namespaces.
+func (w Action) multiNamespace() bool {
+	return len(w.Namespaces) > 0 || w.NamespaceSelector != ""
 }
 
 type resolvedAction struct {
 	Action
 
-	gr schema.GroupResource
+	gr              schema.GroupResource
+	grs             []schema.GroupResource
+	roleSelector    labels.Selector
+	bindingSelector labels.Selector
+
+	// anyResourceName makes PolicyRules restricted to resource names match an Action without resource name, which
+	// finds the subjects granted the Action on specific objects only.
+	anyResourceName bool
+}
+
+// groupResources returns the GroupResources matched by the action, i.e. the ones of each API group serving the
+// resource.
+func (a resolvedAction) groupResources() []schema.GroupResource {
+	if len(a.grs) == 0 {
+		return []schema.GroupResource{a.gr}
+	}
+	return a.grs
+}
+
+// roles returns the label selector of the Roles and ClusterRoles to be checked.
+func (a resolvedAction) roles() labels.Selector {
+	if a.roleSelector == nil {
+		return labels.Everything()
+	}
+	return a.roleSelector
+}
+
+// bindings returns the label selector of the RoleBindings and ClusterRoleBindings to be checked.
+func (a resolvedAction) bindings() labels.Selector {
+	if a.bindingSelector == nil {
+		return labels.Everything()
+	}
+	return a.bindingSelector
 }
 
 // roles is a set of Role names matching the specified Action.
@@ -102,7 +211,7 @@ type clusterRoles map[string]struct{}
 
 type WhoCan struct {
 	clientNamespace clientcore.NamespaceInterface
-	clientRBAC      clientrbac.RbacV1Interface
+	rbacSource      RBACSource
 
 	namespaceValidator NamespaceValidator
 	resourceResolver   ResourceResolver
@@ -110,8 +219,25 @@ type WhoCan struct {
 	policyRuleMatcher  PolicyRuleMatcher
 }
 
-// NewWhoCan constructs a new WhoCan checker with the specified rest.Config and RESTMapper.
-func NewWhoCan(restConfig *rest.Config, mapper apimeta.RESTMapper) (*WhoCan, error) {
+// NewWhoCan constructs a new WhoCan checker with the rest.Config, discovery client and RESTMapper of the specified
+// RESTClientGetter, and the specified RBACSource. If the given RBACSource is nil, RBAC objects are listed directly from
+// the API server. The discovery client of ConfigFlags caches the API resources on disk as kubectl does.
+func NewWhoCan(clientGetter clioptions.RESTClientGetter, source RBACSource) (*WhoCan, error) {
+	restConfig, err := clientGetter.ToRESTConfig()
+	if err != nil {
+		return nil, fmt.Errorf("getting rest config: %v", err)
+	}
+
+	discoveryClient, err := clientGetter.ToDiscoveryClient()
+	if err != nil {
+		return nil, fmt.Errorf("getting discovery client: %v", err)
+	}
+
+	mapper, err := clientGetter.ToRESTMapper()
+	if err != nil {
+		return nil, fmt.Errorf("getting mapper: %v", err)
+	}
+
 	client, err := kubernetes.NewForConfig(restConfig)
 	if err != nil {
 		return nil, err
@@ -119,16 +245,39 @@ func NewWhoCan(restConfig *rest.Config, mapper apimeta.RESTMapper) (*WhoCan, err
 
 	clientNamespace := client.CoreV1().Namespaces()
 
+	if source == nil {
+		source = NewClientRBACSource(client.RbacV1())
+	}
+
 	return &WhoCan{
 		clientNamespace:    clientNamespace,
-		clientRBAC:         client.RbacV1(),
+		rbacSource:         source,
 		namespaceValidator: NewNamespaceValidator(clientNamespace),
-		resourceResolver:   NewResourceResolver(client.Discovery(), mapper),
+		resourceResolver:   NewResourceResolver(discoveryClient, mapper),
 		accessChecker:      NewAccessChecker(client.AuthorizationV1().SelfSubjectAccessReviews()),
 		policyRuleMatcher:  NewPolicyRuleMatcher(),
 	}, nil
 }
 
+// NewOfflineWhoCan constructs a new WhoCan checker which reads RBAC objects from the specified RBACSource and works
+// without access to the API server. Namespaces are not validated and resources are resolved by name only.
+func NewOfflineWhoCan(source RBACSource) *WhoCan {
+	return &WhoCan{
+		rbacSource:         source,
+		namespaceValidator: NewOfflineNamespaceValidator(),
+		resourceResolver:   NewOfflineResourceResolver(),
+		policyRuleMatcher:  NewPolicyRuleMatcher(),
+	}
+}
+
+func newManifestsWhoCan(manifests *Manifests) (*WhoCan, error) {
+	source, err := manifests.Source()
+	if err != nil {
+		return nil, err
+	}
+	return NewOfflineWhoCan(source), nil
+}
+
 // NewWhoCanCommand constructs the WhoCan command with the specified IOStreams.
 func NewWhoCanCommand(streams clioptions.IOStreams) (*cobra.Command, error) {
 	var configFlags *clioptions.ConfigFlags
@@ -137,33 +286,89 @@ func NewWhoCanCommand(streams clioptions.IOStreams) (*cobra.Command, error) {
 		Use:          whoCanUsage,
 		Long:         whoCanLong,
 		Example:      whoCanExample,
+		Args:         cobra.ArbitraryArgs,
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			clientConfig := configFlags.ToRawKubeConfigLoader()
-			restConfig, err := clientConfig.ClientConfig()
+
+			action, err := ActionFrom(clientConfig, cmd.Flags(), args)
 			if err != nil {
-				return fmt.Errorf("getting rest config: %v", err)
+				return err
 			}
 
-			mapper, err := configFlags.ToRESTMapper()
+			watch, err := cmd.Flags().GetBool(watchFlag)
 			if err != nil {
-				return fmt.Errorf("getting mapper: %v", err)
+				return err
 			}
 
-			action, err := ActionFrom(clientConfig, cmd.Flags(), args)
+			files, err := cmd.Flags().GetStringSlice(fromFileFlag)
 			if err != nil {
 				return err
 			}
 
-			o, err := NewWhoCan(restConfig, mapper)
+			filter, err := SubjectFilterFrom(cmd.Flags())
 			if err != nil {
 				return err
 			}
 
-			warnings, err := o.CheckAPIAccess(action)
+			var o *WhoCan
+			var warnings []string
+			var manifests *Manifests
+			var locator ObjectLocator
+			var factory informers.SharedInformerFactory
+			var cluster string
+
+			if len(files) > 0 {
+				if watch {
+					return fmt.Errorf("--%s cannot be used with --%s", watchFlag, fromFileFlag)
+				}
+
+				manifests, err = LoadManifests(files)
+				if err != nil {
+					return err
+				}
+				o, err = newManifestsWhoCan(manifests)
+				if err != nil {
+					return err
+				}
+				locator = manifests
+				cluster = "offline: " + strings.Join(files, ", ")
+			} else {
+				restConfig, err := clientConfig.ClientConfig()
+				if err != nil {
+					return fmt.Errorf("getting rest config: %v", err)
+				}
+
+				var source RBACSource
+				if watch {
+					client, err := kubernetes.NewForConfig(restConfig)
+					if err != nil {
+						return err
+					}
+					factory = informers.NewSharedInformerFactory(client, 0)
+					source = NewInformerRBACSource(factory)
+				}
+
+				o, err = NewWhoCan(configFlags, source)
+				if err != nil {
+					return err
+				}
+
+				warnings, err = o.CheckAPIAccess(action)
+				if err != nil {
+					return err
+				}
+				cluster = clusterName(configFlags, restConfig)
+			}
+
+			var notes []string
+			action, notes, err = o.withResolvedResource(action)
 			if err != nil {
 				return err
 			}
+			if grs := action.groupResources; len(grs) > 1 {
+				notes = append(notes, fmt.Sprintf("The resource %s is served by more than one API group, checking each of %v", action.Resource, grs))
+			}
 
 			output, err := cmd.Flags().GetString(outputFlag)
 			if err != nil {
@@ -172,21 +377,178 @@ func NewWhoCanCommand(streams clioptions.IOStreams) (*cobra.Command, error) {
 
 			printer := NewPrinter(streams.Out, output == outputWide)
 
-			// Output warnings
-			printer.PrintWarnings(warnings)
+			awsAuth, err := AWSAuthFrom(cmd.Flags(), manifests, clientConfig.ClientConfig)
+			if err != nil {
+				return err
+			}
+			if awsAuth != nil {
+				printer.SetAWSAuth(awsAuth)
+			}
+
+			identityMapFile, err := cmd.Flags().GetString(identityMapFlag)
+			if err != nil {
+				return err
+			}
+			if identityMapFile != "" {
+				identityMap, err := ReadIdentityMap(identityMapFile)
+				if err != nil {
+					return err
+				}
+				printer.SetIdentityMap(identityMap)
+			}
+
+			// Output warnings and notes. Graph formats are meant to be piped to other tools, hence they go to stderr.
+			switch strings.ToLower(output) {
+			case outputDot, outputMermaid, outputHTML, outputSARIF:
+				NewPrinter(streams.ErrOut, false).PrintWarnings(warnings)
+				NewPrinter(streams.ErrOut, false).PrintNotes(notes)
+			default:
+				printer.PrintWarnings(warnings)
+				printer.PrintNotes(notes)
+			}
+
+			sortBy, err := cmd.Flags().GetString(sortByFlag)
+			if err != nil {
+				return err
+			}
+			sortBy = strings.ToLower(sortBy)
+			if err := ValidateSortBy(sortBy); err != nil {
+				return err
+			}
+			printer.SetSortBy(sortBy)
+
+			groupBy, err := cmd.Flags().GetString(groupByFlag)
+			if err != nil {
+				return err
+			}
+			groupBy = strings.ToLower(groupBy)
+			if groupBy != "" && groupBy != groupBySubject {
+				return fmt.Errorf("invalid group by: %v: must be %s", groupBy, groupBySubject)
+			}
+
+			byNamespace, err := cmd.Flags().GetBool(byNamespaceFlag)
+			if err != nil {
+				return err
+			}
+			if byNamespace && groupBy != "" {
+				return fmt.Errorf("--%s cannot be used with --%s", byNamespaceFlag, groupByFlag)
+			}
+
+			showPartial, err := cmd.Flags().GetBool(showPartialFlag)
+			if err != nil {
+				return err
+			}
+			if showPartial && groupBy != "" {
+				return fmt.Errorf("--%s cannot be used with --%s", showPartialFlag, groupByFlag)
+			}
+			if showPartial && byNamespace {
+				return fmt.Errorf("--%s cannot be used with --%s", showPartialFlag, byNamespaceFlag)
+			}
+
+			if watch {
+				if groupBy != "" {
+					return fmt.Errorf("--%s cannot be used with --%s", groupByFlag, watchFlag)
+				}
+				if byNamespace {
+					return fmt.Errorf("--%s cannot be used with --%s", byNamespaceFlag, watchFlag)
+				}
+				if showPartial {
+					return fmt.Errorf("--%s cannot be used with --%s", showPartialFlag, watchFlag)
+				}
+				output = strings.ToLower(output)
+				if output != outputJson && output != outputWide && output != "" {
+					return fmt.Errorf("invalid output format: %v", output)
+				}
+
+				ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+				defer stop()
+				return NewWatcher(o, factory, filter, printer, output == outputJson).Watch(ctx, action)
+			}
+
+			output = strings.ToLower(output)
+			if groupBy != "" && output != outputJson && output != outputWide && output != "" {
+				return fmt.Errorf("--%s cannot be used with the %s output format", groupByFlag, output)
+			}
+			if byNamespace && output != outputJson && output != outputWide && output != "" {
+				return fmt.Errorf("--%s cannot be used with the %s output format", byNamespaceFlag, output)
+			}
+			if showPartial && output != outputJson && output != outputWide && output != "" {
+				return fmt.Errorf("--%s cannot be used with the %s output format", showPartialFlag, output)
+			}
+
+			var namespaces []string
+			if byNamespace {
+				action, namespaces, err = o.ByNamespace(action)
+				if err != nil {
+					return err
+				}
+			}
 
 			roleBindings, clusterRoleBindings, err := o.Check(action)
 			if err != nil {
 				return err
 			}
+			roleBindings, clusterRoleBindings, hidden := filter.Filter(roleBindings, clusterRoleBindings)
+
+			if showPartial {
+				partialRoleBindings, partialClusterRoleBindings, err := o.CheckPartial(action)
+				if err != nil {
+					return err
+				}
+				partialRoleBindings, partialClusterRoleBindings, _ = filter.Filter(partialRoleBindings, partialClusterRoleBindings)
+				printer.SetPartial(partialRoleBindings, partialClusterRoleBindings)
+			}
 
 			// Output check results
-			output = strings.ToLower(output)
-			if output == outputJson {
-				printer.ExportData(action, roleBindings, clusterRoleBindings)
-			} else if output == outputWide || output == "" {
-				printer.PrintChecks(action, roleBindings, clusterRoleBindings)
-			} else {
+			switch output {
+			case outputJson:
+				if byNamespace {
+					printer.ExportNamespaceAccess(namespaces, GroupBySubject(roleBindings, clusterRoleBindings), hidden)
+				} else if groupBy == groupBySubject {
+					printer.ExportSubjectGrants(GroupBySubject(roleBindings, clusterRoleBindings), hidden)
+				} else {
+					printer.ExportDataWithHidden(action, roleBindings, clusterRoleBindings, hidden)
+				}
+			case outputWide, "":
+				if byNamespace {
+					printer.PrintNamespaceAccess(action, namespaces, GroupBySubject(roleBindings, clusterRoleBindings))
+				} else if groupBy == groupBySubject {
+					printer.PrintSubjectGrants(action, GroupBySubject(roleBindings, clusterRoleBindings))
+				} else {
+					printer.PrintChecks(action, roleBindings, clusterRoleBindings)
+				}
+				printer.PrintHidden(hidden)
+			case outputDot, outputMermaid:
+				rules, err := o.MatchingRules(action)
+				if err != nil {
+					return err
+				}
+				if output == outputDot {
+					printer.PrintDot(action, roleBindings, clusterRoleBindings, rules)
+				} else {
+					printer.PrintMermaid(action, roleBindings, clusterRoleBindings, rules)
+				}
+			case outputSARIF:
+				rules, err := o.MatchingRules(action)
+				if err != nil {
+					return err
+				}
+				printer.PrintSARIF(action, roleBindings, clusterRoleBindings, rules, locator)
+			case outputHTML:
+				rules, err := o.MatchingRules(action)
+				if err != nil {
+					return err
+				}
+				info := ReportInfo{
+					Cluster:  cluster,
+					Time:     time.Now(),
+					Warnings: warnings,
+					Notes:    notes,
+				}
+				if err := printer.PrintHTML(action, info, roleBindings, clusterRoleBindings, rules); err != nil {
+					return err
+				}
+			default:
 				return fmt.Errorf("invalid output format: %v", output)
 			}
 
@@ -195,18 +557,64 @@ func NewWhoCanCommand(streams clioptions.IOStreams) (*cobra.Command, error) {
 	}
 
 	cmd.Flags().String(subResourceFlag, "", "SubResource such as pod/log or deployment/scale")
+	cmd.Flags().String(apiGroupFlag, "", "If present, the API group of the resource, e.g. apps. Use core for the core API group and * for rules granting all API groups")
+	cmd.Flags().Bool(allAPIGroupsFlag, false, "If true, check a resource served by several API groups in each of them instead of in the preferred one")
 	cmd.Flags().BoolP(allNamespacesFlag, "A", false, "If true, check for users that can do the specified action in any of the available namespaces")
-	cmd.Flags().StringP(outputFlag, "o", "", "Output format. Currently the only supported output format is wide or JSON.")
+	cmd.Flags().StringP(outputFlag, "o", "", "Output format. One of: wide, json, dot, mermaid, html, sarif.")
+	cmd.Flags().BoolP(watchFlag, "w", false, "If true, keep watching RBAC objects and print subjects which gain or lose the permission")
+	cmd.Flags().StringSliceP(fromFileFlag, "f", nil, "Check RBAC objects defined in manifest files or directories instead of the cluster")
+	cmd.Flags().String(namespaceSelectorFlag, "", "If present, check the action in each namespace matching the label selector, e.g. env=prod")
+	cmd.Flags().String(roleSelectorFlag, "", "If present, only check Roles and ClusterRoles matching the label selector, e.g. team=payments")
+	cmd.Flags().String(bindingSelectorFlag, "", "If present, only check RoleBindings and ClusterRoleBindings matching the label selector, e.g. team=payments")
+	cmd.Flags().Bool(awsAuthFlag, false, "If true, show the IAM identities mapped onto Group and User subjects by the kube-system/aws-auth ConfigMap")
+	cmd.Flags().StringSlice(awsAuthFileFlag, nil, "Path to the manifest of the aws-auth ConfigMap mapping IAM identities onto Group and User subjects")
+	cmd.Flags().String(sortByFlag, "", "If present, sort binding subjects by the given key instead of by namespace and binding. One of: subject, kind, binding, namespace, role")
+	cmd.Flags().String(groupByFlag, "", "If present, print one row for each subject instead of each binding subject. One of: subject")
+	cmd.Flags().Bool(byNamespaceFlag, false, "If true, print which namespaces each subject can perform the action in, through a RoleBinding (RB) or a ClusterRoleBinding (CRB)")
+	cmd.Flags().Bool(showPartialFlag, false, "If true, also show the subjects which can perform the action on specific resource names only")
+	cmd.Flags().String(identityMapFlag, "", "Path to the file mapping Group and User subjects onto their owners, shown in wide and JSON output")
+	AddSubjectFilterFlags(cmd.Flags())
 
 	flag.CommandLine.VisitAll(func(gf *flag.Flag) {
 		cmd.Flags().AddGoFlag(gf)
 	})
 	configFlags = clioptions.NewConfigFlags(true)
-	configFlags.AddFlags(cmd.Flags())
+	configFlags.AddFlags(cmd.PersistentFlags())
+
+	cmd.AddCommand(NewServeCommand(configFlags))
+	cmd.AddCommand(NewMetricsCommand(configFlags))
+	cmd.AddCommand(NewAssertCommand(streams, configFlags))
+	cmd.AddCommand(NewLintCommand(streams, configFlags))
+	cmd.AddCommand(NewSuggestRoleCommand(streams, configFlags))
+	cmd.AddCommand(NewUsageCommand(streams, configFlags))
+	cmd.AddCommand(NewMatrixCommand(streams, configFlags))
 
 	return cmd, nil
 }
 
+// clusterName returns the name of the cluster of the current kubeconfig context followed by the API server URL.
+func clusterName(configFlags *clioptions.ConfigFlags, restConfig *rest.Config) string {
+	raw, err := configFlags.ToRawKubeConfigLoader().RawConfig()
+	if err != nil {
+		return restConfig.Host
+	}
+
+	name := raw.CurrentContext
+	if configFlags.Context != nil && *configFlags.Context != "" {
+		name = *configFlags.Context
+	}
+	if kubeContext, ok := raw.Contexts[name]; ok {
+		name = kubeContext.Cluster
+	}
+	if configFlags.ClusterName != nil && *configFlags.ClusterName != "" {
+		name = *configFlags.ClusterName
+	}
+	if name == "" {
+		return restConfig.Host
+	}
+	return fmt.Sprintf("%s (%s)", name, restConfig.Host)
+}
+
 // ActionFrom sets all information required to check who can perform the specified action.
 func ActionFrom(clientConfig clientcmd.ClientConfig, flags *pflag.FlagSet, args []string) (action Action, err error) {
 	if len(args) < 2 {
@@ -232,12 +640,41 @@ func ActionFrom(clientConfig clientcmd.ClientConfig, flags *pflag.FlagSet, args
 		return
 	}
 
+	action.APIGroup, err = flags.GetString(apiGroupFlag)
+	if err != nil {
+		return
+	}
+
+	action.AllAPIGroups, err = flags.GetBool(allAPIGroupsFlag)
+	if err != nil {
+		return
+	}
+
+	action.RoleSelector, err = flags.GetString(roleSelectorFlag)
+	if err != nil {
+		return
+	}
+
+	action.BindingSelector, err = flags.GetString(bindingSelectorFlag)
+	if err != nil {
+		return
+	}
+
 	action.AllNamespaces, err = flags.GetBool(allNamespacesFlag)
 	if err != nil {
 		return
 	}
 
+	action.NamespaceSelector, err = flags.GetString(namespaceSelectorFlag)
+	if err != nil {
+		return
+	}
+
 	if action.AllNamespaces {
+		if action.NamespaceSelector != "" {
+			err = fmt.Errorf("--%s cannot be used with --%s", namespaceSelectorFlag, allNamespacesFlag)
+			return
+		}
 		action.Namespace = core.NamespaceAll
 		klog.V(3).Infof("Resolved namespace `%s` from --all-namespaces flag", action.Namespace)
 		return
@@ -248,6 +685,17 @@ func ActionFrom(clientConfig clientcmd.ClientConfig, flags *pflag.FlagSet, args
 		return
 	}
 
+	if strings.Contains(action.Namespace, ",") || action.NamespaceSelector != "" {
+		for _, ns := range strings.Split(action.Namespace, ",") {
+			if ns = strings.TrimSpace(ns); ns != "" {
+				action.Namespaces = append(action.Namespaces, ns)
+			}
+		}
+		action.Namespace = core.NamespaceAll
+		klog.V(3).Infof("Resolved namespaces `%v` and namespace selector `%s`", action.Namespaces, action.NamespaceSelector)
+		return
+	}
+
 	if action.Namespace != "" {
 		klog.V(3).Infof("Resolved namespace `%s` from --namespace flag", action.Namespace)
 		return
@@ -265,58 +713,142 @@ func ActionFrom(clientConfig clientcmd.ClientConfig, flags *pflag.FlagSet, args
 // Validate makes sure that the specified action is valid.
 func (w *WhoCan) validate(action Action) error {
 	if action.NonResourceURL != "" && action.SubResource != "" {
-		return fmt.Errorf("--subresource cannot be used with NONRESOURCEURL")
+		return invalidActionError{fmt.Errorf("--subresource cannot be used with NONRESOURCEURL")}
+	}
+	if action.NonResourceURL != "" && action.APIGroup != "" {
+		return invalidActionError{fmt.Errorf("--%s cannot be used with NONRESOURCEURL", apiGroupFlag)}
+	}
+	if action.NonResourceURL != "" && action.AllAPIGroups {
+		return invalidActionError{fmt.Errorf("--%s cannot be used with NONRESOURCEURL", allAPIGroupsFlag)}
 	}
 
 	err := w.namespaceValidator.Validate(action.Namespace)
 	if err != nil {
-		return fmt.Errorf("validating namespace: %v", err)
+		return wrapActionError("validating namespace", err)
+	}
+
+	for _, ns := range action.Namespaces {
+		if err := w.namespaceValidator.Validate(ns); err != nil {
+			return wrapActionError("validating namespace", err)
+		}
 	}
 
 	return nil
 }
 
+// invalidActionError is an error caused by an Action which cannot be checked as specified, e.g. because its resource
+// is unknown or its namespace does not exist, as opposed to a failure to check it.
+type invalidActionError struct {
+	err error
+}
+
+func (e invalidActionError) Error() string {
+	return e.err.Error()
+}
+
+// isInvalidAction returns true if the given error is caused by an Action which cannot be checked as specified.
+func isInvalidAction(err error) bool {
+	_, ok := err.(invalidActionError)
+	return ok
+}
+
+// wrapActionError prefixes the given error with the given message, keeping it an invalidActionError if it is one.
+func wrapActionError(message string, err error) error {
+	wrapped := fmt.Errorf("%s: %v", message, err)
+	if isInvalidAction(err) {
+		return invalidActionError{wrapped}
+	}
+	return wrapped
+}
+
+// namespacesOf returns the namespaces in which the specified Action is checked, i.e. either the single Namespace of
+// the Action, or the listed namespaces followed by the namespaces matching the namespace selector.
+func (w *WhoCan) namespacesOf(action Action) ([]string, error) {
+	if !action.multiNamespace() {
+		return []string{action.Namespace}, nil
+	}
+
+	namespaces := append([]string{}, action.Namespaces...)
+	if action.NamespaceSelector == "" {
+		return namespaces, nil
+	}
+
+	selector, err := labels.Parse(action.NamespaceSelector)
+	if err != nil {
+		return nil, invalidActionError{fmt.Errorf("parsing namespace selector: %v", err)}
+	}
+	if w.clientNamespace == nil {
+		return nil, errors.New("namespace selector requires access to the API server")
+	}
+	nsList, err := w.clientNamespace.List(context.Background(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("listing namespaces: %v", err)
+	}
+
+	listed := make(map[string]bool, len(namespaces))
+	for _, ns := range namespaces {
+		listed[ns] = true
+	}
+	for _, ns := range nsList.Items {
+		if !listed[ns.Name] {
+			namespaces = append(namespaces, ns.Name)
+		}
+	}
+	klog.V(3).Infof("Resolved namespaces `%v` from namespace selector `%s`", namespaces, action.NamespaceSelector)
+	return namespaces, nil
+}
+
 // Check checks who can perform the action specified by WhoCanOptions and returns the role bindings that allows the
 // action to be performed.
 func (w *WhoCan) Check(action Action) (roleBindings []rbac.RoleBinding, clusterRoleBindings []rbac.ClusterRoleBinding, err error) {
 	err = w.validate(action)
 	if err != nil {
-		err = fmt.Errorf("validation: %v", err)
+		err = wrapActionError("validation", err)
 		return
 	}
 
-	resolvedAction := resolvedAction{Action: action}
-
-	if action.Resource != "" {
-		resolvedAction.gr, err = w.resourceResolver.Resolve(action.Verb, action.Resource, action.SubResource)
-		if err != nil {
-			err = fmt.Errorf("resolving resource: %v", err)
-			return
-		}
-		klog.V(3).Infof("Resolved resource `%s`", resolvedAction.gr.String())
+	resolvedAction, err := w.resolve(action)
+	if err != nil {
+		return
 	}
 
-	// Get the Roles that relate to the Verbs and Resources we are interested in
-	roleNames, err := w.getRolesFor(resolvedAction)
+	namespaces, err := w.namespacesOf(action)
 	if err != nil {
-		return []rbac.RoleBinding{}, []rbac.ClusterRoleBinding{}, fmt.Errorf("getting Roles: %v", err)
+		return
 	}
 
+	return w.checkResolved(resolvedAction, namespaces)
+}
+
+// checkResolved returns the bindings which allow the specified resolved action to be performed in the given
+// namespaces.
+func (w *WhoCan) checkResolved(resolvedAction resolvedAction, namespaces []string) (roleBindings []rbac.RoleBinding, clusterRoleBindings []rbac.ClusterRoleBinding, err error) {
 	// Get the ClusterRoles that relate to the verbs and resources we are interested in
 	clusterRoleNames, err := w.getClusterRolesFor(resolvedAction)
 	if err != nil {
 		return []rbac.RoleBinding{}, []rbac.ClusterRoleBinding{}, fmt.Errorf("getting ClusterRoles: %v", err)
 	}
 
-	// Get the RoleBindings that relate to this set of Roles or ClusterRoles
-	roleBindings, err = w.getRoleBindings(resolvedAction, roleNames, clusterRoleNames)
-	if err != nil {
-		err = fmt.Errorf("getting RoleBindings: %v", err)
-		return
+	for _, namespace := range namespaces {
+		namespaceAction := resolvedAction
+		namespaceAction.Namespace = namespace
+
+		// Get the Roles that relate to the Verbs and Resources we are interested in
+		roleNames, err := w.getRolesFor(namespaceAction)
+		if err != nil {
+			return []rbac.RoleBinding{}, []rbac.ClusterRoleBinding{}, fmt.Errorf("getting Roles: %v", err)
+		}
+
+		// Get the RoleBindings that relate to this set of Roles or ClusterRoles
+		namespaceRoleBindings, err := w.getRoleBindings(namespaceAction, roleNames, clusterRoleNames)
+		if err != nil {
+			return nil, nil, fmt.Errorf("getting RoleBindings: %v", err)
+		}
+		roleBindings = append(roleBindings, namespaceRoleBindings...)
 	}
 
 	// Get the ClusterRoleBindings that relate to this set of ClusterRoles
-	clusterRoleBindings, err = w.getClusterRoleBindings(clusterRoleNames)
+	clusterRoleBindings, err = w.getClusterRoleBindings(resolvedAction, clusterRoleNames)
 	if err != nil {
 		err = fmt.Errorf("getting ClusterRoleBindings: %v", err)
 		return
@@ -325,6 +857,98 @@ func (w *WhoCan) Check(action Action) (roleBindings []rbac.RoleBinding, clusterR
 	return
 }
 
+// resolve resolves the resource and parses the label selectors of the specified Action.
+func (w *WhoCan) resolve(action Action) (resolvedAction, error) {
+	resolved := resolvedAction{Action: action}
+
+	var err error
+	resolved.roleSelector, err = labels.Parse(action.RoleSelector)
+	if err != nil {
+		return resolvedAction{}, invalidActionError{fmt.Errorf("parsing role selector: %v", err)}
+	}
+	resolved.bindingSelector, err = labels.Parse(action.BindingSelector)
+	if err != nil {
+		return resolvedAction{}, invalidActionError{fmt.Errorf("parsing binding selector: %v", err)}
+	}
+
+	if action.Resource != "" {
+		grs, warnings, err := w.ResolveResource(action)
+		if err != nil {
+			return resolvedAction{}, err
+		}
+		for _, warning := range warnings {
+			klog.Warning(warning)
+		}
+		resolved.gr, resolved.grs = grs[0], grs
+		klog.V(3).Infof("Resolved resource `%v`", grs)
+	}
+
+	return resolved, nil
+}
+
+// ResolveResource returns the GroupResources of the resource of the specified Action, i.e. the preferred one followed
+// by the ones of the other API groups serving the resource if AllAPIGroups is set, restricted to the API group of the
+// Action if specified. The GroupResources resolved beforehand are returned as is. The warnings report the API groups
+// which are skipped since their resources cannot be discovered, and the other API groups serving the resource which
+// are not checked unless AllAPIGroups is set.
+func (w *WhoCan) ResolveResource(action Action) ([]schema.GroupResource, []string, error) {
+	if action.groupResources != nil {
+		return action.groupResources, nil, nil
+	}
+
+	resource := action.Resource
+	switch action.APIGroup {
+	case "", coreGroup, rbac.APIGroupAll:
+	default:
+		resource += "." + action.APIGroup
+	}
+	if action.APIGroup != "" && schema.ParseGroupResource(action.Resource).Group != "" {
+		return nil, nil, invalidActionError{fmt.Errorf("resolving resource: --%s cannot be used with the resource %s qualified by an API group", apiGroupFlag, action.Resource)}
+	}
+	if action.APIGroup != "" && action.AllAPIGroups {
+		return nil, nil, invalidActionError{fmt.Errorf("resolving resource: --%s cannot be used with --%s", allAPIGroupsFlag, apiGroupFlag)}
+	}
+
+	allGroups := action.AllAPIGroups || action.APIGroup == coreGroup
+	grs, warnings, err := w.resourceResolver.Resolve(action.Verb, resource, action.SubResource, allGroups)
+	if err != nil {
+		return nil, nil, wrapActionError("resolving resource", err)
+	}
+
+	switch action.APIGroup {
+	case coreGroup:
+		var core []schema.GroupResource
+		for _, gr := range grs {
+			if gr.Group == "" {
+				core = append(core, gr)
+			}
+		}
+		if len(core) == 0 {
+			return nil, nil, invalidActionError{fmt.Errorf("resolving resource: the resource %s is not served by the core API group", action.Resource)}
+		}
+		// The skipped API groups don't matter since only the core API group is checked.
+		grs, warnings = core, nil
+	case rbac.APIGroupAll:
+		// Rules granting all API groups match the resource of any API group serving it.
+		grs, warnings = []schema.GroupResource{{Group: rbac.APIGroupAll, Resource: grs[0].Resource}}, nil
+	}
+	return grs, warnings, nil
+}
+
+// withResolvedResource returns the specified Action along with its resolved resource, if any, so that checking it
+// doesn't resolve the resource again, and the notes about the API groups of the resource returned by ResolveResource.
+func (w *WhoCan) withResolvedResource(action Action) (Action, []string, error) {
+	if action.Resource == "" {
+		return action, nil, nil
+	}
+	grs, warnings, err := w.ResolveResource(action)
+	if err != nil {
+		return Action{}, nil, err
+	}
+	action.groupResources = grs
+	return action, warnings, nil
+}
+
 // CheckAPIAccess checks whether the subject in the current context has enough privileges to query Kubernetes API
 // server to perform Check.
 func (w *WhoCan) CheckAPIAccess(action Action) ([]string, error) {
@@ -339,7 +963,20 @@ func (w *WhoCan) CheckAPIAccess(action Action) ([]string, error) {
 	ctx := context.Background()
 
 	// Determine which checks need to be executed.
-	if action.Namespace == "" {
+	if action.multiNamespace() {
+		if action.NamespaceSelector != "" {
+			checks = append(checks, check{"list", "namespaces", ""})
+		}
+
+		namespaces, err := w.namespacesOf(action)
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range namespaces {
+			checks = append(checks, check{"list", "roles", ns})
+			checks = append(checks, check{"list", "rolebindings", ns})
+		}
+	} else if action.Namespace == "" {
 		checks = append(checks, check{"list", "namespaces", ""})
 
 		nsList, err := w.clientNamespace.List(ctx, metav1.ListOptions{})
@@ -379,15 +1016,14 @@ func (w *WhoCan) CheckAPIAccess(action Action) ([]string, error) {
 
 // GetRolesFor returns a set of names of Roles matching the specified Action.
 func (w *WhoCan) getRolesFor(action resolvedAction) (roles, error) {
-	ctx := context.Background()
-	rl, err := w.clientRBAC.Roles(action.Namespace).List(ctx, metav1.ListOptions{})
+	rl, err := w.rbacSource.ListRoles(action.Namespace, action.roles())
 	if err != nil {
 		return nil, err
 	}
 
 	roleNames := make(map[string]struct{}, 10)
 
-	for _, item := range rl.Items {
+	for _, item := range rl {
 		if w.policyRuleMatcher.MatchesRole(item, action) {
 			if _, ok := roleNames[item.Name]; !ok {
 				roleNames[item.Name] = struct{}{}
@@ -400,15 +1036,14 @@ func (w *WhoCan) getRolesFor(action resolvedAction) (roles, error) {
 
 // GetClusterRolesFor returns a set of names of ClusterRoles matching the specified Action.
 func (w *WhoCan) getClusterRolesFor(action resolvedAction) (clusterRoles, error) {
-	ctx := context.Background()
-	crl, err := w.clientRBAC.ClusterRoles().List(ctx, metav1.ListOptions{})
+	crl, err := w.rbacSource.ListClusterRoles(action.roles())
 	if err != nil {
 		return nil, err
 	}
 
 	cr := make(map[string]struct{}, 10)
 
-	for _, item := range crl.Items {
+	for _, item := range crl {
 		if w.policyRuleMatcher.MatchesClusterRole(item, action) {
 			if _, ok := cr[item.Name]; !ok {
 				cr[item.Name] = struct{}{}
@@ -424,13 +1059,12 @@ func (w *WhoCan) getRoleBindings(action resolvedAction, roleNames roles, cluster
 	if action.Namespace == core.NamespaceAll {
 		return
 	}
-	ctx := context.Background()
-	list, err := w.clientRBAC.RoleBindings(action.Namespace).List(ctx, metav1.ListOptions{})
+	list, err := w.rbacSource.ListRoleBindings(action.Namespace, action.bindings())
 	if err != nil {
 		return
 	}
 
-	for _, roleBinding := range list.Items {
+	for _, roleBinding := range list {
 		if roleBinding.RoleRef.Kind == RoleKind {
 			if _, ok := roleNames[roleBinding.RoleRef.Name]; ok {
 				roleBindings = append(roleBindings, roleBinding)
@@ -446,14 +1080,13 @@ func (w *WhoCan) getRoleBindings(action resolvedAction, roleNames roles, cluster
 }
 
 // GetClusterRoleBindings returns the ClusterRoleBindings that refer to the given sef of ClusterRole names.
-func (w *WhoCan) getClusterRoleBindings(clusterRoleNames clusterRoles) (clusterRoleBindings []rbac.ClusterRoleBinding, err error) {
-	ctx := context.Background()
-	list, err := w.clientRBAC.ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+func (w *WhoCan) getClusterRoleBindings(action resolvedAction, clusterRoleNames clusterRoles) (clusterRoleBindings []rbac.ClusterRoleBinding, err error) {
+	list, err := w.rbacSource.ListClusterRoleBindings(action.bindings())
 	if err != nil {
 		return
 	}
 
-	for _, roleBinding := range list.Items {
+	for _, roleBinding := range list {
 		if _, ok := clusterRoleNames[roleBinding.RoleRef.Name]; ok {
 			clusterRoleBindings = append(clusterRoleBindings, roleBinding)
 		}
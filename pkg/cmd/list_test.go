@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -41,9 +42,11 @@ type resourceResolverMock struct {
 	mock.Mock
 }
 
-func (r *resourceResolverMock) Resolve(verb, resource, subResource string) (schema.GroupResource, error) {
-	args := r.Called(verb, resource, subResource)
-	return args.Get(0).(schema.GroupResource), args.Error(1)
+func (r *resourceResolverMock) Resolve(verb, resource, subResource string, allGroups bool) ([]schema.GroupResource, []string, error) {
+	args := r.Called(verb, resource, subResource, allGroups)
+	grs, _ := args.Get(0).([]schema.GroupResource)
+	warnings, _ := args.Get(1).([]string)
+	return grs, warnings, args.Error(2)
 }
 
 type clientConfigMock struct {
@@ -70,6 +73,11 @@ func (prm *policyRuleMatcherMock) MatchesClusterRole(role rbac.ClusterRole, acti
 	return args.Bool(0)
 }
 
+func (prm *policyRuleMatcherMock) MatchingRules(rules []rbac.PolicyRule, action resolvedAction) []rbac.PolicyRule {
+	args := prm.Called(rules, action)
+	return args.Get(0).([]rbac.PolicyRule)
+}
+
 func TestActionFrom(t *testing.T) {
 
 	type currentContext struct {
@@ -78,9 +86,14 @@ func TestActionFrom(t *testing.T) {
 	}
 
 	type flags struct {
-		subResource   string
-		namespace     string
-		allNamespaces bool
+		subResource       string
+		apiGroup          string
+		allAPIGroups      bool
+		namespace         string
+		allNamespaces     bool
+		roleSelector      string
+		bindingSelector   string
+		namespaceSelector string
 	}
 
 	testCases := []struct {
@@ -150,11 +163,79 @@ func TestActionFrom(t *testing.T) {
 				NonResourceURL: "/logs",
 			},
 		},
+		{
+			name:  "H",
+			flags: flags{namespace: "foo", roleSelector: "team=payments", bindingSelector: "env in (prod)"},
+			args:  []string{"get", "secrets"},
+			expectedAction: Action{
+				Namespace:       "foo",
+				Verb:            "get",
+				Resource:        "secrets",
+				RoleSelector:    "team=payments",
+				BindingSelector: "env in (prod)",
+			},
+		},
+		{
+			name:  "I",
+			flags: flags{namespace: "a, b,c"},
+			args:  []string{"get", "secrets"},
+			expectedAction: Action{
+				Namespace:  core.NamespaceAll,
+				Namespaces: []string{"a", "b", "c"},
+				Verb:       "get",
+				Resource:   "secrets",
+			},
+		},
+		{
+			name:  "J",
+			flags: flags{namespaceSelector: "env=prod"},
+			args:  []string{"get", "secrets"},
+			expectedAction: Action{
+				Namespace:         core.NamespaceAll,
+				NamespaceSelector: "env=prod",
+				Verb:              "get",
+				Resource:          "secrets",
+			},
+		},
+		{
+			name:  "K",
+			flags: flags{allNamespaces: true, namespaceSelector: "env=prod"},
+			args:  []string{"get", "secrets"},
+			expectedAction: Action{
+				AllNamespaces:     true,
+				NamespaceSelector: "env=prod",
+				Verb:              "get",
+				Resource:          "secrets",
+			},
+			expectedError: errors.New("--namespace-selector cannot be used with --all-namespaces"),
+		},
+		{
+			name:  "L",
+			flags: flags{apiGroup: "apps", namespace: "foo"},
+			args:  []string{"get", "deployments"},
+			expectedAction: Action{
+				Namespace: "foo",
+				Verb:      "get",
+				Resource:  "deployments",
+				APIGroup:  "apps",
+			},
+		},
 		{
 			name:          "G",
 			args:          []string{},
 			expectedError: errors.New("you must specify two or three arguments: verb, resource, and optional resourceName"),
 		},
+		{
+			name:  "M",
+			flags: flags{allAPIGroups: true, namespace: "foo"},
+			args:  []string{"list", "events"},
+			expectedAction: Action{
+				Namespace:    "foo",
+				Verb:         "list",
+				Resource:     "events",
+				AllAPIGroups: true,
+			},
+		},
 	}
 
 	for _, tt := range testCases {
@@ -171,6 +252,11 @@ func TestActionFrom(t *testing.T) {
 			flags.String(namespaceFlag, tt.flags.namespace, "")
 			flags.Bool(allNamespacesFlag, tt.flags.allNamespaces, "")
 			flags.String(subResourceFlag, "", "")
+			flags.String(apiGroupFlag, tt.flags.apiGroup, "")
+			flags.Bool(allAPIGroupsFlag, tt.flags.allAPIGroups, "")
+			flags.String(roleSelectorFlag, tt.flags.roleSelector, "")
+			flags.String(bindingSelectorFlag, tt.flags.bindingSelector, "")
+			flags.String(namespaceSelectorFlag, tt.flags.namespaceSelector, "")
 
 			// when
 			o, err := ActionFrom(clientConfig, flags, tt.args)
@@ -196,6 +282,8 @@ func TestValidate(t *testing.T) {
 
 		nonResourceURL string
 		subResource    string
+		apiGroup       string
+		allAPIGroups   bool
 		namespace      string
 
 		*namespaceValidation
@@ -219,6 +307,18 @@ func TestValidate(t *testing.T) {
 			subResource:    "logs",
 			expectedErr:    errors.New("--subresource cannot be used with NONRESOURCEURL"),
 		},
+		{
+			scenario:       "Should return error when --api-group flag is used with non-resource URL",
+			nonResourceURL: "/api",
+			apiGroup:       "apps",
+			expectedErr:    errors.New("--api-group cannot be used with NONRESOURCEURL"),
+		},
+		{
+			scenario:       "Should return error when --all-api-groups flag is used with non-resource URL",
+			nonResourceURL: "/api",
+			allAPIGroups:   true,
+			expectedErr:    errors.New("--all-api-groups cannot be used with NONRESOURCEURL"),
+		},
 	}
 
 	for _, tt := range data {
@@ -237,6 +337,8 @@ func TestValidate(t *testing.T) {
 			action := Action{
 				NonResourceURL: tt.nonResourceURL,
 				SubResource:    tt.subResource,
+				APIGroup:       tt.apiGroup,
+				AllAPIGroups:   tt.allAPIGroups,
 				Namespace:      tt.namespace,
 			}
 
@@ -244,12 +346,148 @@ func TestValidate(t *testing.T) {
 			err := o.validate(action)
 
 			// then
-			assert.Equal(t, tt.expectedErr, err)
+			if tt.expectedErr != nil {
+				assert.EqualError(t, err, tt.expectedErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
 			namespaceValidator.AssertExpectations(t)
 		})
 	}
 }
 
+func TestValidate_InvalidAction(t *testing.T) {
+	// given
+	o := &WhoCan{namespaceValidator: new(namespaceValidatorMock)}
+
+	// when
+	err := o.validate(Action{NonResourceURL: "/api", SubResource: "logs"})
+
+	// then
+	var invalid invalidActionError
+	assert.True(t, errors.As(err, &invalid), "flags which cannot be used together must be reported as an invalid action")
+}
+
+func TestWhoCan_ResolveResource(t *testing.T) {
+	events := []schema.GroupResource{{Resource: "events"}, {Group: "events.k8s.io", Resource: "events"}}
+
+	data := []struct {
+		scenario string
+
+		action           Action
+		resolvedResource string
+		allGroups        bool
+		resolved         []schema.GroupResource
+		warnings         []string
+
+		expected         []schema.GroupResource
+		expectedWarnings []string
+		expectedErr      error
+	}{
+		{
+			scenario:         "Should return the preferred group serving the resource",
+			action:           Action{Verb: "list", Resource: "events"},
+			resolvedResource: "events",
+			resolved:         events[:1],
+			expected:         events[:1],
+		},
+		{
+			scenario:         "Should return the warning about the other groups serving the resource",
+			action:           Action{Verb: "list", Resource: "events"},
+			resolvedResource: "events",
+			resolved:         events[:1],
+			warnings:         []string{"The resource events is also served by the API group(s) events.k8s.io, which are only checked with --all-api-groups"},
+			expected:         events[:1],
+			expectedWarnings: []string{"The resource events is also served by the API group(s) events.k8s.io, which are only checked with --all-api-groups"},
+		},
+		{
+			scenario:         "Should return each group serving the resource",
+			action:           Action{Verb: "list", Resource: "events", AllAPIGroups: true},
+			resolvedResource: "events",
+			allGroups:        true,
+			resolved:         events,
+			expected:         events,
+		},
+		{
+			scenario:         "Should return the warnings about skipped API groups",
+			action:           Action{Verb: "list", Resource: "pods", AllAPIGroups: true},
+			resolvedResource: "pods",
+			allGroups:        true,
+			resolved:         []schema.GroupResource{{Resource: "pods"}},
+			warnings:         []string{"Skipping the API group metrics.k8s.io/v1beta1 of the resource pods: getting API groups: not found"},
+			expected:         []schema.GroupResource{{Resource: "pods"}},
+			expectedWarnings: []string{"Skipping the API group metrics.k8s.io/v1beta1 of the resource pods: getting API groups: not found"},
+		},
+		{
+			scenario: "Should return the resource resolved beforehand",
+			action:   Action{Verb: "list", Resource: "events", groupResources: events},
+			expected: events,
+		},
+		{
+			scenario:         "Should qualify the resource with the API group",
+			action:           Action{Verb: "list", Resource: "events", APIGroup: "events.k8s.io"},
+			resolvedResource: "events.events.k8s.io",
+			resolved:         events[1:],
+			expected:         events[1:],
+		},
+		{
+			scenario:         "Should keep the core API group",
+			action:           Action{Verb: "list", Resource: "events", APIGroup: "core"},
+			resolvedResource: "events",
+			allGroups:        true,
+			resolved:         events[:1],
+			warnings:         []string{"Skipping the API group events.k8s.io/v1 of the resource events: getting API groups: not found"},
+			expected:         events[:1],
+		},
+		{
+			scenario:         "Should return error when the resource is not served by the core API group",
+			action:           Action{Verb: "list", Resource: "deployments", APIGroup: "core"},
+			resolvedResource: "deployments",
+			allGroups:        true,
+			resolved:         []schema.GroupResource{{Group: "apps", Resource: "deployments"}},
+			expectedErr:      invalidActionError{errors.New("resolving resource: the resource deployments is not served by the core API group")},
+		},
+		{
+			scenario:         "Should return all API groups",
+			action:           Action{Verb: "list", Resource: "events", APIGroup: "*"},
+			resolvedResource: "events",
+			resolved:         events[:1],
+			warnings:         []string{"The resource events is also served by the API group(s) events.k8s.io, which are only checked with --all-api-groups"},
+			expected:         []schema.GroupResource{{Group: "*", Resource: "events"}},
+		},
+		{
+			scenario:    "Should return error when the resource is qualified by an API group",
+			action:      Action{Verb: "list", Resource: "deployments.apps", APIGroup: "apps"},
+			expectedErr: invalidActionError{errors.New("resolving resource: --api-group cannot be used with the resource deployments.apps qualified by an API group")},
+		},
+		{
+			scenario:    "Should return error when all API groups are requested along with an API group",
+			action:      Action{Verb: "list", Resource: "events", APIGroup: "events.k8s.io", AllAPIGroups: true},
+			expectedErr: invalidActionError{errors.New("resolving resource: --all-api-groups cannot be used with --api-group")},
+		},
+	}
+
+	for _, tt := range data {
+		t.Run(tt.scenario, func(t *testing.T) {
+			// given
+			resourceResolver := new(resourceResolverMock)
+			if tt.resolvedResource != "" {
+				resourceResolver.On("Resolve", tt.action.Verb, tt.resolvedResource, "", tt.allGroups).Return(tt.resolved, tt.warnings, nil)
+			}
+			wc := WhoCan{resourceResolver: resourceResolver}
+
+			// when
+			grs, warnings, err := wc.ResolveResource(tt.action)
+
+			// then
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expected, grs)
+			assert.Equal(t, tt.expectedWarnings, warnings)
+			resourceResolver.AssertExpectations(t)
+		})
+	}
+}
+
 func TestWhoCan_CheckAPIAccess(t *testing.T) {
 	const (
 		FooNs = "foo"
@@ -268,10 +506,10 @@ func TestWhoCan_CheckAPIAccess(t *testing.T) {
 		list := &core.NamespaceList{
 			Items: []core.Namespace{
 				{
-					ObjectMeta: metav1.ObjectMeta{Name: FooNs},
+					ObjectMeta: metav1.ObjectMeta{Name: FooNs, Labels: map[string]string{"env": "prod"}},
 				},
 				{
-					ObjectMeta: metav1.ObjectMeta{Name: BarNs},
+					ObjectMeta: metav1.ObjectMeta{Name: BarNs, Labels: map[string]string{"env": "prod"}},
 				},
 			},
 		}
@@ -282,6 +520,8 @@ func TestWhoCan_CheckAPIAccess(t *testing.T) {
 	data := []struct {
 		scenario    string
 		namespace   string
+		namespaces  []string
+		selector    string
 		permissions []permission
 
 		expectedWarnings []string
@@ -318,6 +558,24 @@ func TestWhoCan_CheckAPIAccess(t *testing.T) {
 				"The user is not allowed to list rolebindings in the foo namespace",
 			},
 		},
+		{
+			scenario:   "C",
+			namespaces: []string{BarNs},
+			selector:   "env=prod",
+			permissions: []permission{
+				// Permissions to list namespaces matching the selector
+				{verb: "list", resource: "namespaces", namespace: core.NamespaceAll, allowed: true},
+				// Permissions in the bar namespace
+				{verb: "list", resource: "roles", namespace: BarNs, allowed: true},
+				{verb: "list", resource: "rolebindings", namespace: BarNs, allowed: true},
+				// Permissions in the foo namespace
+				{verb: "list", resource: "roles", namespace: FooNs, allowed: false},
+				{verb: "list", resource: "rolebindings", namespace: FooNs, allowed: true},
+			},
+			expectedWarnings: []string{
+				"The user is not allowed to list roles in the foo namespace",
+			},
+		},
 	}
 
 	for _, tt := range data {
@@ -335,14 +593,16 @@ func TestWhoCan_CheckAPIAccess(t *testing.T) {
 			// given
 			wc := WhoCan{
 				clientNamespace:    client.CoreV1().Namespaces(),
-				clientRBAC:         client.RbacV1(),
+				rbacSource:         NewClientRBACSource(client.RbacV1()),
 				namespaceValidator: namespaceValidator,
 				resourceResolver:   resourceResolver,
 				accessChecker:      accessChecker,
 				policyRuleMatcher:  policyRuleMatcher,
 			}
 			action := Action{
-				Namespace: tt.namespace,
+				Namespace:         tt.namespace,
+				Namespaces:        tt.namespaces,
+				NamespaceSelector: tt.selector,
 			}
 
 			// when
@@ -404,7 +664,7 @@ func TestWhoCan_GetRolesFor(t *testing.T) {
 	policyRuleMatcher.On("MatchesRole", viewPodsRole, action).Return(false)
 
 	wc := WhoCan{
-		clientRBAC:        client.RbacV1(),
+		rbacSource:        NewClientRBACSource(client.RbacV1()),
 		policyRuleMatcher: policyRuleMatcher,
 	}
 
@@ -463,7 +723,7 @@ func TestWhoCan_GetClusterRolesFor(t *testing.T) {
 	policyRuleMatcher.On("MatchesClusterRole", getApiRole, action).Return(true)
 
 	wc := WhoCan{
-		clientRBAC:        client.RbacV1(),
+		rbacSource:        NewClientRBACSource(client.RbacV1()),
 		policyRuleMatcher: policyRuleMatcher,
 	}
 
@@ -516,7 +776,7 @@ func TestWhoCan_GetRoleBindings(t *testing.T) {
 	})
 
 	wc := WhoCan{
-		clientRBAC: client.RbacV1(),
+		rbacSource: NewClientRBACSource(client.RbacV1()),
 	}
 	action := resolvedAction{Action: Action{Namespace: namespace}}
 
@@ -566,14 +826,109 @@ func TestWhoCan_GetClusterRoleBindings(t *testing.T) {
 	})
 
 	wc := WhoCan{
-		clientRBAC: client.RbacV1(),
+		rbacSource: NewClientRBACSource(client.RbacV1()),
 	}
 
 	// when
-	bindings, err := wc.getClusterRoleBindings(clusterRoleNames)
+	bindings, err := wc.getClusterRoleBindings(resolvedAction{}, clusterRoleNames)
 
 	// then
 	require.NoError(t, err)
 	assert.Equal(t, 1, len(bindings))
 	assert.Contains(t, bindings, getHealthzBnd)
 }
+
+func TestWhoCan_Check_Selectors(t *testing.T) {
+	// given
+	payments := map[string]string{"team": "payments"}
+	rules := []rbac.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}}}
+	source, err := NewStaticRBACSource(
+		&rbac.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "read-secrets"}, Rules: rules},
+		&rbac.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "payments-read-secrets", Labels: payments}, Rules: rules},
+		&rbac.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "read-secrets"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "read-secrets"},
+		},
+		&rbac.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "payments-read-secrets", Labels: payments},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "read-secrets"},
+		},
+		&rbac.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "read-payments-secrets"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "payments-read-secrets"},
+		},
+	)
+	require.NoError(t, err)
+	wc := NewOfflineWhoCan(source)
+
+	bindingNames := func(action Action) []string {
+		_, clusterRoleBindings, err := wc.Check(action)
+		require.NoError(t, err)
+		var names []string
+		for _, crb := range clusterRoleBindings {
+			names = append(names, crb.Name)
+		}
+		return names
+	}
+
+	// when
+	all := bindingNames(Action{Verb: "get", Resource: "secrets", AllNamespaces: true})
+	byBinding := bindingNames(Action{Verb: "get", Resource: "secrets", AllNamespaces: true, BindingSelector: "team=payments"})
+	byRole := bindingNames(Action{Verb: "get", Resource: "secrets", AllNamespaces: true, RoleSelector: "team=payments"})
+	_, _, err = wc.Check(Action{Verb: "get", Resource: "secrets", AllNamespaces: true, RoleSelector: "team in payments"})
+
+	// then
+	assert.Equal(t, []string{"read-secrets", "payments-read-secrets", "read-payments-secrets"}, all)
+	assert.Equal(t, []string{"payments-read-secrets"}, byBinding)
+	assert.Equal(t, []string{"read-payments-secrets"}, byRole)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "parsing role selector: ")
+}
+
+func TestWhoCan_Check_Namespaces(t *testing.T) {
+	// given
+	prod := map[string]string{"env": "prod"}
+	client := fake.NewSimpleClientset(
+		&core.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "foo", Labels: prod}},
+		&core.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "bar", Labels: prod}},
+		&core.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "baz"}},
+		&rbac.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "read-secrets"},
+			Rules:      []rbac.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}}},
+		},
+	)
+	for _, ns := range []string{"foo", "bar", "baz"} {
+		_, err := client.RbacV1().RoleBindings(ns).Create(context.Background(), &rbac.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "read-secrets", Namespace: ns},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "read-secrets"},
+		}, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+	wc := &WhoCan{
+		clientNamespace:    client.CoreV1().Namespaces(),
+		rbacSource:         NewClientRBACSource(client.RbacV1()),
+		namespaceValidator: NewOfflineNamespaceValidator(),
+		resourceResolver:   NewOfflineResourceResolver(),
+		policyRuleMatcher:  NewPolicyRuleMatcher(),
+	}
+
+	bindingNamespaces := func(action Action) []string {
+		roleBindings, _, err := wc.Check(action)
+		require.NoError(t, err)
+		var namespaces []string
+		for _, rb := range roleBindings {
+			namespaces = append(namespaces, rb.Namespace)
+		}
+		return namespaces
+	}
+
+	// when
+	listed := bindingNamespaces(Action{Verb: "get", Resource: "secrets", Namespaces: []string{"baz", "foo"}})
+	selected := bindingNamespaces(Action{Verb: "get", Resource: "secrets", Namespaces: []string{"foo"}, NamespaceSelector: "env=prod"})
+	_, _, err := NewOfflineWhoCan(NewClientRBACSource(client.RbacV1())).Check(Action{Verb: "get", Resource: "secrets", NamespaceSelector: "env=prod"})
+
+	// then
+	assert.Equal(t, []string{"baz", "foo"}, listed)
+	assert.Equal(t, []string{"foo", "bar"}, selected)
+	assert.EqualError(t, err, "namespace selector requires access to the API server")
+}
@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// ManifestLocation is the position of an object in a manifest file.
+type ManifestLocation struct {
+	File string
+	Line int
+}
+
+// ObjectLocator wraps the LocationOf method.
+//
+// LocationOf returns the location of the manifest defining the object with the given kind, namespace and name.
+type ObjectLocator interface {
+	LocationOf(kind, namespace, name string) (ManifestLocation, bool)
+}
+
+// Manifests holds RBAC objects loaded from YAML or JSON manifest files along with their locations.
+type Manifests struct {
+	objects         []runtime.Object
+	namespaces      []core.Namespace
+	serviceAccounts []core.ServiceAccount
+	awsAuth         *core.ConfigMap
+	locations       map[string]ManifestLocation
+}
+
+// LoadManifests loads Roles, ClusterRoles, RoleBindings and ClusterRoleBindings, as well as the Namespaces and
+// ServiceAccounts they may refer to and the aws-auth ConfigMap, from the files with the specified paths. Directories are walked recursively for
+// files with the .yaml, .yml or .json extension. Documents defining other kinds of objects are ignored.
+func LoadManifests(paths []string) (*Manifests, error) {
+	m := &Manifests{
+		locations: make(map[string]ManifestLocation),
+	}
+	for _, path := range paths {
+		err := filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			if file != path && !isManifestFile(file) {
+				return nil
+			}
+			return m.loadFile(file)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("loading manifests: %v", err)
+		}
+	}
+	return m, nil
+}
+
+func isManifestFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+func (m *Manifests) loadFile(file string) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	for _, doc := range splitDocuments(data) {
+		if err := m.loadDocument(file, doc); err != nil {
+			return fmt.Errorf("%s:%d: %v", file, doc.line, err)
+		}
+	}
+	return nil
+}
+
+func (m *Manifests) loadDocument(file string, doc document) error {
+	var typeMeta metav1.TypeMeta
+	if err := yaml.Unmarshal(doc.data, &typeMeta); err != nil {
+		return err
+	}
+
+	if typeMeta.Kind == "List" {
+		var list struct {
+			Items []runtime.RawExtension `json:"items"`
+		}
+		if err := yaml.Unmarshal(doc.data, &list); err != nil {
+			return err
+		}
+		for _, item := range list.Items {
+			if err := m.loadDocument(file, document{data: item.Raw, line: doc.line}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if typeMeta.APIVersion == core.SchemeGroupVersion.String() {
+		return m.loadCoreDocument(file, doc, typeMeta)
+	}
+
+	if typeMeta.APIVersion != rbac.SchemeGroupVersion.String() {
+		klog.V(4).Infof("Ignoring %s %s in %s:%d", typeMeta.APIVersion, typeMeta.Kind, file, doc.line)
+		return nil
+	}
+
+	var object runtime.Object
+	switch typeMeta.Kind {
+	case RoleKind:
+		object = &rbac.Role{}
+	case ClusterRoleKind:
+		object = &rbac.ClusterRole{}
+	case RoleBindingKind:
+		object = &rbac.RoleBinding{}
+	case ClusterRoleBindingKind:
+		object = &rbac.ClusterRoleBinding{}
+	default:
+		klog.V(4).Infof("Ignoring %s %s in %s:%d", typeMeta.APIVersion, typeMeta.Kind, file, doc.line)
+		return nil
+	}
+	if err := yaml.Unmarshal(doc.data, object); err != nil {
+		return err
+	}
+
+	meta := object.(metav1.Object)
+	m.objects = append(m.objects, object)
+	m.locations[objectKey(typeMeta.Kind, meta.GetNamespace(), meta.GetName())] = ManifestLocation{File: file, Line: doc.line}
+	return nil
+}
+
+func (m *Manifests) loadCoreDocument(file string, doc document, typeMeta metav1.TypeMeta) error {
+	var meta metav1.Object
+	switch typeMeta.Kind {
+	case NamespaceKind:
+		var ns core.Namespace
+		if err := yaml.Unmarshal(doc.data, &ns); err != nil {
+			return err
+		}
+		m.namespaces = append(m.namespaces, ns)
+		meta = &ns
+	case rbac.ServiceAccountKind:
+		var sa core.ServiceAccount
+		if err := yaml.Unmarshal(doc.data, &sa); err != nil {
+			return err
+		}
+		m.serviceAccounts = append(m.serviceAccounts, sa)
+		meta = &sa
+	case configMapKind:
+		var cm core.ConfigMap
+		if err := yaml.Unmarshal(doc.data, &cm); err != nil {
+			return err
+		}
+		if cm.Namespace != awsAuthNamespace || cm.Name != awsAuthName {
+			klog.V(4).Infof("Ignoring ConfigMap %s/%s in %s:%d", cm.Namespace, cm.Name, file, doc.line)
+			return nil
+		}
+		m.awsAuth = &cm
+		meta = &cm
+	default:
+		klog.V(4).Infof("Ignoring %s %s in %s:%d", typeMeta.APIVersion, typeMeta.Kind, file, doc.line)
+		return nil
+	}
+	m.locations[objectKey(typeMeta.Kind, meta.GetNamespace(), meta.GetName())] = ManifestLocation{File: file, Line: doc.line}
+	return nil
+}
+
+// Source returns an RBACSource serving the loaded objects.
+func (m *Manifests) Source() (RBACSource, error) {
+	return NewStaticRBACSource(m.objects...)
+}
+
+// SubjectSource returns a SubjectSource serving the loaded Namespaces and ServiceAccounts.
+func (m *Manifests) SubjectSource() SubjectSource {
+	return &staticSubjectSource{namespaces: m.namespaces, serviceAccounts: m.serviceAccounts}
+}
+
+// AWSAuth returns the IAM identity mappings of the loaded aws-auth ConfigMap.
+func (m *Manifests) AWSAuth() (*AWSAuth, error) {
+	if m.awsAuth == nil {
+		return nil, fmt.Errorf("ConfigMap %s/%s not found in manifests", awsAuthNamespace, awsAuthName)
+	}
+	return NewAWSAuth(*m.awsAuth)
+}
+
+func (m *Manifests) LocationOf(kind, namespace, name string) (ManifestLocation, bool) {
+	location, ok := m.locations[objectKey(kind, namespace, name)]
+	return location, ok
+}
+
+func objectKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// document is a single YAML document of a manifest file starting at the given line.
+type document struct {
+	data []byte
+	line int
+}
+
+// splitDocuments splits the given YAML stream into documents. The line of each document is the first line which is
+// neither blank nor a comment, so that it points at the definition of the object rather than at the separator.
+func splitDocuments(data []byte) []document {
+	var docs []document
+	var current bytes.Buffer
+	start := 0
+
+	flush := func() {
+		if start > 0 {
+			docs = append(docs, document{data: append([]byte(nil), current.Bytes()...), line: start})
+		}
+		current.Reset()
+		start = 0
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if strings.HasPrefix(text, "---") && strings.TrimSpace(strings.TrimPrefix(text, "---")) == "" {
+			flush()
+			continue
+		}
+		trimmed := strings.TrimSpace(text)
+		if start == 0 && trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			start = line
+		}
+		current.WriteString(text)
+		current.WriteByte('\n')
+	}
+	flush()
+
+	return docs
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbac "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const manifestsYAML = `# RBAC for the foo team
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: view-pods
+  namespace: foo
+rules:
+- apiGroups: [""]
+  resources: ["pods"]
+  verbs: ["get", "list"]
+---
+
+# Bind the role to alice
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: view-pods
+  namespace: foo
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: view-pods
+subjects:
+- kind: User
+  name: alice
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: foo
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: operator
+  namespace: foo
+`
+
+const manifestsListJSON = `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {
+      "apiVersion": "rbac.authorization.k8s.io/v1",
+      "kind": "ClusterRole",
+      "metadata": {"name": "view-secrets"},
+      "rules": [{"apiGroups": [""], "resources": ["secrets"], "verbs": ["get"]}]
+    },
+    {
+      "apiVersion": "rbac.authorization.k8s.io/v1",
+      "kind": "ClusterRoleBinding",
+      "metadata": {"name": "view-secrets"},
+      "roleRef": {"apiGroup": "rbac.authorization.k8s.io", "kind": "ClusterRole", "name": "view-secrets"},
+      "subjects": [{"kind": "Group", "name": "auditors"}]
+    }
+  ]
+}
+`
+
+func writeManifest(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestLoadManifests(t *testing.T) {
+	dir := t.TempDir()
+	rbacFile := filepath.Join(dir, "foo", "rbac.yaml")
+	listFile := filepath.Join(dir, "cluster.json")
+	writeManifest(t, rbacFile, manifestsYAML)
+	writeManifest(t, listFile, manifestsListJSON)
+	writeManifest(t, filepath.Join(dir, "README.md"), "# Not a manifest\n")
+
+	manifests, err := LoadManifests([]string{dir})
+	require.NoError(t, err)
+
+	t.Run("Should load RBAC objects", func(t *testing.T) {
+		source, err := manifests.Source()
+		require.NoError(t, err)
+
+		roles, err := source.ListRoles("foo", labels.Everything())
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		assert.Equal(t, "view-pods", roles[0].Name)
+		assert.Equal(t, []string{"get", "list"}, roles[0].Rules[0].Verbs)
+
+		roleBindings, err := source.ListRoleBindings("", labels.Everything())
+		require.NoError(t, err)
+		require.Len(t, roleBindings, 1)
+		assert.Equal(t, []rbac.Subject{{Kind: rbac.UserKind, Name: "alice"}}, roleBindings[0].Subjects)
+
+		clusterRoles, err := source.ListClusterRoles(labels.Everything())
+		require.NoError(t, err)
+		require.Len(t, clusterRoles, 1)
+		assert.Equal(t, "view-secrets", clusterRoles[0].Name)
+
+		clusterRoleBindings, err := source.ListClusterRoleBindings(labels.Everything())
+		require.NoError(t, err)
+		require.Len(t, clusterRoleBindings, 1)
+		assert.Equal(t, "view-secrets", clusterRoleBindings[0].Name)
+	})
+
+	t.Run("Should load subjects", func(t *testing.T) {
+		source := manifests.SubjectSource()
+
+		serviceAccounts, err := source.ListServiceAccounts()
+		require.NoError(t, err)
+		require.Len(t, serviceAccounts, 1)
+		assert.Equal(t, "foo", serviceAccounts[0].Namespace)
+		assert.Equal(t, "operator", serviceAccounts[0].Name)
+
+		namespaces, err := source.ListNamespaces()
+		require.NoError(t, err)
+		assert.Empty(t, namespaces)
+	})
+
+	t.Run("Should locate objects", func(t *testing.T) {
+		data := []struct {
+			kind, namespace, name string
+			expected              ManifestLocation
+		}{
+			{RoleKind, "foo", "view-pods", ManifestLocation{File: rbacFile, Line: 3}},
+			{RoleBindingKind, "foo", "view-pods", ManifestLocation{File: rbacFile, Line: 15}},
+			{ClusterRoleKind, "", "view-secrets", ManifestLocation{File: listFile, Line: 1}},
+			{ClusterRoleBindingKind, "", "view-secrets", ManifestLocation{File: listFile, Line: 1}},
+			{rbac.ServiceAccountKind, "foo", "operator", ManifestLocation{File: rbacFile, Line: 34}},
+		}
+		for _, tt := range data {
+			location, ok := manifests.LocationOf(tt.kind, tt.namespace, tt.name)
+			assert.True(t, ok, "%s %s/%s", tt.kind, tt.namespace, tt.name)
+			assert.Equal(t, tt.expected, location)
+		}
+
+		_, ok := manifests.LocationOf("ConfigMap", "foo", "settings")
+		assert.False(t, ok)
+	})
+}
+
+func TestLoadManifests_ExplicitFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "rbac.txt")
+	writeManifest(t, file, manifestsYAML)
+
+	manifests, err := LoadManifests([]string{file})
+	require.NoError(t, err)
+
+	_, ok := manifests.LocationOf(RoleKind, "foo", "view-pods")
+	assert.True(t, ok)
+}
+
+func TestLoadManifests_Errors(t *testing.T) {
+	t.Run("Should return error when file does not exist", func(t *testing.T) {
+		_, err := LoadManifests([]string{filepath.Join(t.TempDir(), "missing.yaml")})
+		assert.Error(t, err)
+	})
+
+	t.Run("Should return error with location when document is invalid", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "rbac.yaml")
+		writeManifest(t, file, "apiVersion: rbac.authorization.k8s.io/v1\nkind: Role\nrules: foo\n")
+
+		_, err := LoadManifests([]string{file})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), file+":1:")
+	})
+}
+
+func TestSplitDocuments(t *testing.T) {
+	docs := splitDocuments([]byte("---\n# comment\n\na: 1\n---\n---\nb: 2\n---\n"))
+
+	assert.Equal(t, []document{
+		{data: []byte("# comment\n\na: 1\n"), line: 4},
+		{data: []byte("b: 2\n"), line: 7},
+	}, docs)
+}
@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	rbac "k8s.io/api/rbac/v1"
+	clioptions "k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+const (
+	matrixUsage = "matrix [TYPE | TYPE/NAME]"
+	matrixLong  = `Shows which of the get, list, watch, create, update, patch, delete and deletecollection verbs each subject can
+perform on a resource.
+
+The RBAC objects are read once and the verbs are checked one after another against them, so the grid reflects a single
+point in time. As with the who-can command, only ClusterRoleBindings are checked with --all-namespaces.`
+	matrixExample = `  # Show which verbs each subject can perform on pods in namespace "foo"
+  kubectl who-can matrix pods -n foo
+
+  # Export the permission matrix of secrets in any namespace as CSV
+  kubectl who-can matrix secrets -A -o csv`
+)
+
+const (
+	outputCSV = "csv"
+
+	matrixCheckMark = "✓"
+)
+
+// matrixVerbs are the verbs checked by the matrix command, in the order of its columns.
+var matrixVerbs = []string{"get", "list", "watch", "create", "update", "patch", "delete", "deletecollection"}
+
+// MatrixRow is a subject along with the verbs it can perform on a resource.
+type MatrixRow struct {
+	Subject rbac.Subject `json:"subject"`
+	Verbs   []string     `json:"verbs"`
+}
+
+// Can returns true if the subject can perform the specified verb.
+func (r MatrixRow) Can(verb string) bool {
+	return containsString(r.Verbs, verb)
+}
+
+// Matrix checks who can perform each of the matrix verbs on the resource of the specified Action, whose verb is
+// ignored, and returns one row for each subject sorted by kind, namespace and name. The RBAC objects are read only once
+// for all verbs.
+func (w *WhoCan) Matrix(action Action) ([]MatrixRow, error) {
+	if action.NonResourceURL != "" {
+		return nil, errors.New("matrix requires a resource rather than a non-resource URL")
+	}
+	action.Verb = rbac.VerbAll
+
+	if err := w.validate(action); err != nil {
+		return nil, fmt.Errorf("validation: %v", err)
+	}
+	resolved, err := w.resolve(action)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := w.namespacesOf(action)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := NewSnapshotRBACSource(w.rbacSource, namespaces)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &WhoCan{rbacSource: source, policyRuleMatcher: w.policyRuleMatcher}
+
+	var rows []*MatrixRow
+	rowOf := make(map[string]*MatrixRow)
+	for _, verb := range matrixVerbs {
+		verbAction := resolved
+		verbAction.Verb = verb
+		roleBindings, clusterRoleBindings, err := snapshot.checkResolved(verbAction, namespaces)
+		if err != nil {
+			return nil, err
+		}
+		for _, bs := range bindingSubjectsOf(roleBindings, clusterRoleBindings) {
+			key := subjectString(bs.Subject)
+			row, ok := rowOf[key]
+			if !ok {
+				row = &MatrixRow{Subject: bs.Subject}
+				rowOf[key] = row
+				rows = append(rows, row)
+			}
+			row.Verbs = appendUnique(row.Verbs, verb)
+		}
+	}
+
+	result := make([]MatrixRow, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, *row)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return lessSubject(result[i].Subject, result[j].Subject)
+	})
+	return result, nil
+}
+
+// matrixResource returns the resource of the specified Action along with its subresource and name, e.g.
+// `pods/log/web`.
+func matrixResource(action Action) string {
+	resource := action.Resource
+	if action.SubResource != "" {
+		resource += "/" + action.SubResource
+	}
+	if action.ResourceName != "" {
+		resource += "/" + action.ResourceName
+	}
+	return resource
+}
+
+// PrintMatrix prints a grid of the subjects and the verbs they can perform, with a check mark for each granted verb.
+func (p *Printer) PrintMatrix(action Action, rows []MatrixRow) {
+	if len(rows) == 0 {
+		_, _ = fmt.Fprintf(p.out, "No subjects found with permissions to %s %s\n", strings.Join(matrixVerbs, "/"), matrixResource(action))
+		return
+	}
+
+	wr := new(tabwriter.Writer)
+	wr.Init(p.out, 0, 8, 2, ' ', 0)
+
+	columns := []string{"SUBJECT", "TYPE", "SA-NAMESPACE"}
+	for _, verb := range matrixVerbs {
+		columns = append(columns, strings.ToUpper(verb))
+	}
+	_, _ = fmt.Fprintln(wr, strings.Join(columns, "\t"))
+
+	for _, row := range rows {
+		cells := []string{row.Subject.Name, row.Subject.Kind, row.Subject.Namespace}
+		for _, verb := range matrixVerbs {
+			mark := ""
+			if row.Can(verb) {
+				mark = matrixCheckMark
+			}
+			cells = append(cells, mark)
+		}
+		_, _ = fmt.Fprintln(wr, strings.Join(cells, "\t"))
+	}
+	_ = wr.Flush()
+}
+
+// ExportMatrixCSV exports the grid of the subjects and the verbs they can perform as CSV, with true or false for each
+// verb.
+func (p *Printer) ExportMatrixCSV(rows []MatrixRow) {
+	wr := csv.NewWriter(p.out)
+
+	_ = wr.Write(append([]string{"subject", "type", "sa-namespace"}, matrixVerbs...))
+	for _, row := range rows {
+		record := []string{row.Subject.Name, row.Subject.Kind, row.Subject.Namespace}
+		for _, verb := range matrixVerbs {
+			record = append(record, strconv.FormatBool(row.Can(verb)))
+		}
+		_ = wr.Write(record)
+	}
+	wr.Flush()
+}
+
+// ExportMatrix exports the checked verbs and the subjects along with the verbs they can perform as JSON.
+func (p *Printer) ExportMatrix(action Action, rows []MatrixRow) {
+	data := map[string]interface{}{
+		"resource": matrixResource(action),
+		"verbs":    matrixVerbs,
+		"subjects": rows,
+	}
+
+	encoder := json.NewEncoder(p.out)
+	encoder.SetIndent("", "    ")
+	_ = encoder.Encode(data)
+}
+
+// NewMatrixCommand constructs the matrix command with the specified IOStreams and ConfigFlags.
+func NewMatrixCommand(streams clioptions.IOStreams, configFlags *clioptions.ConfigFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          matrixUsage,
+		Short:        "Show which verbs each subject can perform on a resource",
+		Long:         matrixLong,
+		Example:      matrixExample,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			action, err := ActionFrom(configFlags.ToRawKubeConfigLoader(), cmd.Flags(), []string{rbac.VerbAll, args[0]})
+			if err != nil {
+				return err
+			}
+			files, err := cmd.Flags().GetStringSlice(fromFileFlag)
+			if err != nil {
+				return err
+			}
+			output, err := cmd.Flags().GetString(outputFlag)
+			if err != nil {
+				return err
+			}
+			output = strings.ToLower(output)
+			if output != "" && output != outputCSV && output != outputJson {
+				return fmt.Errorf("invalid output format: %v", output)
+			}
+
+			printer := NewPrinter(streams.Out, false)
+			var o *WhoCan
+			if len(files) > 0 {
+				manifests, err := LoadManifests(files)
+				if err != nil {
+					return err
+				}
+				if o, err = newManifestsWhoCan(manifests); err != nil {
+					return err
+				}
+			} else {
+				if o, err = NewWhoCan(configFlags, nil); err != nil {
+					return err
+				}
+				warnings, err := o.CheckAPIAccess(action)
+				if err != nil {
+					return err
+				}
+				var notes []string
+				action, notes, err = o.withResolvedResource(action)
+				if err != nil {
+					return err
+				}
+				// CSV and JSON are meant to be piped to other tools, hence warnings and notes go to stderr.
+				if output == "" {
+					printer.PrintWarnings(warnings)
+					printer.PrintNotes(notes)
+				} else {
+					NewPrinter(streams.ErrOut, false).PrintWarnings(warnings)
+					NewPrinter(streams.ErrOut, false).PrintNotes(notes)
+				}
+			}
+
+			rows, err := o.Matrix(action)
+			if err != nil {
+				return err
+			}
+			switch output {
+			case outputCSV:
+				printer.ExportMatrixCSV(rows)
+			case outputJson:
+				printer.ExportMatrix(action, rows)
+			default:
+				printer.PrintMatrix(action, rows)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String(subResourceFlag, "", "SubResource such as pod/log or deployment/scale")
+	cmd.Flags().String(apiGroupFlag, "", "If present, the API group of the resource, e.g. apps. Use core for the core API group and * for rules granting all API groups")
+	cmd.Flags().Bool(allAPIGroupsFlag, false, "If true, check a resource served by several API groups in each of them instead of in the preferred one")
+	cmd.Flags().BoolP(allNamespacesFlag, "A", false, "If true, check the verbs in any of the available namespaces")
+	cmd.Flags().String(namespaceSelectorFlag, "", "If present, check the verbs in each namespace matching the label selector, e.g. env=prod")
+	cmd.Flags().String(roleSelectorFlag, "", "If present, only check Roles and ClusterRoles matching the label selector, e.g. team=payments")
+	cmd.Flags().String(bindingSelectorFlag, "", "If present, only check RoleBindings and ClusterRoleBindings matching the label selector, e.g. team=payments")
+	cmd.Flags().StringSliceP(fromFileFlag, "f", nil, "Check RBAC objects defined in manifest files or directories instead of the cluster")
+	cmd.Flags().StringP(outputFlag, "o", "", "Output format. One of: csv, json.")
+
+	return cmd
+}
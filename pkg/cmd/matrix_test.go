@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var matrixRows = []MatrixRow{
+	{Subject: rbac.Subject{Kind: rbac.GroupKind, Name: "admins"}, Verbs: matrixVerbs},
+	{Subject: rbac.Subject{Kind: rbac.ServiceAccountKind, Namespace: "foo", Name: "deployer"}, Verbs: []string{"get", "list", "watch", "create", "update", "patch", "delete"}},
+	{Subject: rbac.Subject{Kind: rbac.UserKind, Name: "alice"}, Verbs: []string{"get", "list", "watch"}},
+}
+
+func TestWhoCan_Matrix(t *testing.T) {
+	// given
+	client := fake.NewSimpleClientset(
+		&rbac.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod-reader"},
+			Rules:      []rbac.PolicyRule{{Verbs: []string{"get", "list", "watch"}, APIGroups: []string{""}, Resources: []string{"pods"}}},
+		},
+		&rbac.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin"},
+			Rules:      []rbac.PolicyRule{{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}}},
+		},
+		&rbac.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod-editor", Namespace: "foo"},
+			Rules:      []rbac.PolicyRule{{Verbs: []string{"create", "update", "patch", "delete"}, APIGroups: []string{""}, Resources: []string{"pods"}}},
+		},
+		&rbac.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "read-pods", Namespace: "foo"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "pod-reader"},
+			Subjects: []rbac.Subject{
+				{Kind: rbac.UserKind, Name: "alice"},
+				{Kind: rbac.ServiceAccountKind, Namespace: "foo", Name: "deployer"},
+			},
+		},
+		&rbac.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "edit-pods", Namespace: "foo"},
+			RoleRef:    rbac.RoleRef{Kind: RoleKind, Name: "pod-editor"},
+			Subjects:   []rbac.Subject{{Kind: rbac.ServiceAccountKind, Namespace: "foo", Name: "deployer"}},
+		},
+		&rbac.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "read-pods", Namespace: "bar"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "pod-reader"},
+			Subjects:   []rbac.Subject{{Kind: rbac.UserKind, Name: "bob"}},
+		},
+		&rbac.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "cluster-admin"},
+			Subjects:   []rbac.Subject{{Kind: rbac.GroupKind, Name: "admins"}},
+		},
+	)
+	wc := NewOfflineWhoCan(NewClientRBACSource(client.RbacV1()))
+
+	// when
+	rows, err := wc.Matrix(Action{Resource: "pods", Namespace: "foo"})
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, matrixRows, rows)
+	assert.Len(t, client.Actions(), 4, "RBAC objects should be listed once for all verbs")
+
+	// when
+	_, err = wc.Matrix(Action{NonResourceURL: "/healthz"})
+
+	// then
+	assert.EqualError(t, err, "matrix requires a resource rather than a non-resource URL")
+}
+
+func TestPrinter_PrintMatrix(t *testing.T) {
+	action := Action{Resource: "pods", Namespace: "foo"}
+
+	t.Run("Should print a check mark for each granted verb", func(t *testing.T) {
+		var buf bytes.Buffer
+		NewPrinter(&buf, false).PrintMatrix(action, matrixRows)
+
+		assert.Equal(t, `SUBJECT   TYPE            SA-NAMESPACE  GET  LIST  WATCH  CREATE  UPDATE  PATCH  DELETE  DELETECOLLECTION
+admins    Group                         ✓    ✓     ✓      ✓       ✓       ✓      ✓       ✓
+deployer  ServiceAccount  foo           ✓    ✓     ✓      ✓       ✓       ✓      ✓       
+alice     User                          ✓    ✓     ✓                                     
+`, buf.String())
+	})
+
+	t.Run("Should print message when there are no subjects", func(t *testing.T) {
+		var buf bytes.Buffer
+		NewPrinter(&buf, false).PrintMatrix(action, nil)
+
+		assert.Equal(t, "No subjects found with permissions to get/list/watch/create/update/patch/delete/deletecollection pods\n", buf.String())
+	})
+}
+
+func TestPrinter_ExportMatrixCSV(t *testing.T) {
+	// when
+	var buf bytes.Buffer
+	NewPrinter(&buf, false).ExportMatrixCSV(matrixRows[1:])
+
+	// then
+	assert.Equal(t, `subject,type,sa-namespace,get,list,watch,create,update,patch,delete,deletecollection
+deployer,ServiceAccount,foo,true,true,true,true,true,true,true,false
+alice,User,,true,true,true,false,false,false,false,false
+`, buf.String())
+}
+
+func TestPrinter_ExportMatrix(t *testing.T) {
+	// when
+	var buf bytes.Buffer
+	NewPrinter(&buf, false).ExportMatrix(Action{Resource: "pods", SubResource: "log"}, matrixRows[2:])
+
+	// then
+	assert.JSONEq(t, `{
+  "resource": "pods/log",
+  "verbs": ["get", "list", "watch", "create", "update", "patch", "delete", "deletecollection"],
+  "subjects": [{"subject": {"kind": "User", "name": "alice"}, "verbs": ["get", "list", "watch"]}]
+}`, buf.String())
+}
@@ -0,0 +1,319 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	rbac "k8s.io/api/rbac/v1"
+	clioptions "k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+const (
+	metricsUsage = `metrics --config FILE [--listen ADDRESS] [--interval DURATION]`
+	metricsLong  = `Periodically checks who can perform the actions listed in the config file and exports the results
+as Prometheus metrics on /metrics.
+
+The config file is a YAML document listing the actions to be checked, for example:
+
+  actions:
+  - verb: create
+    resource: pods
+    subResource: exec
+  - verb: get
+    resource: secrets
+    namespace: prod
+
+An action without namespace is checked in each existing namespace, so that the subjects granted the action
+through RoleBindings are exported along with their namespace.`
+	metricsExample = `  # Export metrics on port 9090 and re-evaluate the actions every 5 minutes
+  kubectl who-can metrics --config actions.yaml --listen :9090 --interval 5m`
+)
+
+const (
+	configFlag   = "config"
+	intervalFlag = "interval"
+)
+
+// subjectsSeries identifies a series of the whocan_subjects gauge.
+type subjectsSeries struct {
+	action      string
+	subjectKind string
+	namespace   string
+	bindingKind string
+}
+
+// wildcardSeries identifies a series of the whocan_wildcard_grants gauge.
+type wildcardSeries struct {
+	action      string
+	bindingKind string
+}
+
+// MetricsExporter periodically checks who can perform a list of actions and exports the results in the Prometheus
+// text exposition format.
+type MetricsExporter struct {
+	whoCan  *WhoCan
+	actions []Action
+
+	mu             sync.Mutex
+	subjects       map[string]map[subjectsSeries]int
+	wildcardGrants map[string]map[wildcardSeries]int
+	errors         map[string]int
+}
+
+// NewMetricsExporter constructs a new MetricsExporter which checks the specified actions with the given WhoCan checker.
+func NewMetricsExporter(whoCan *WhoCan, actions []Action) *MetricsExporter {
+	return &MetricsExporter{
+		whoCan:         whoCan,
+		actions:        actions,
+		subjects:       make(map[string]map[subjectsSeries]int),
+		wildcardGrants: make(map[string]map[wildcardSeries]int),
+		errors:         make(map[string]int),
+	}
+}
+
+// Run evaluates the actions immediately and then every interval until the given context is done. The interval must
+// be positive.
+func (e *MetricsExporter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		e.Evaluate()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Evaluate checks each action and replaces the exported gauges of the action with the current results. The gauges of
+// an action which cannot be checked keep their previous values and the error counter of the action is incremented
+// instead.
+func (e *MetricsExporter) Evaluate() {
+	for _, action := range e.actions {
+		label := actionLabel(action)
+		subjects, wildcardGrants, err := e.evaluate(action)
+
+		e.mu.Lock()
+		if err != nil {
+			klog.Errorf("Error while checking %s: %v", label, err)
+			e.errors[label]++
+		} else {
+			e.subjects[label] = subjects
+			e.wildcardGrants[label] = wildcardGrants
+		}
+		e.mu.Unlock()
+	}
+}
+
+// evaluate returns the number of unique subjects that can perform the specified action, and the number of binding
+// subjects granted the action through wildcard rules by binding kind.
+func (e *MetricsExporter) evaluate(action Action) (map[subjectsSeries]int, map[wildcardSeries]int, error) {
+	label := actionLabel(action)
+	action, err := e.whoCan.expandAllNamespaces(action)
+	if err != nil {
+		return nil, nil, err
+	}
+	roleBindings, clusterRoleBindings, err := e.whoCan.Check(action)
+	if err != nil {
+		return nil, nil, err
+	}
+	rules, err := e.whoCan.MatchingRules(action)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	unique := make(map[subjectsSeries]map[rbac.Subject]struct{})
+	wildcardGrants := make(map[wildcardSeries]int)
+	for _, bs := range bindingSubjectsOf(roleBindings, clusterRoleBindings) {
+		series := subjectsSeries{action: label, subjectKind: bs.Subject.Kind, namespace: bs.Namespace, bindingKind: bs.BindingKind}
+		if _, ok := unique[series]; !ok {
+			unique[series] = make(map[rbac.Subject]struct{})
+		}
+		unique[series][bs.Subject] = struct{}{}
+
+		for _, rule := range rules.For(bs.Namespace, bs.RoleRef) {
+			if isWildcardRule(rule) {
+				wildcardGrants[wildcardSeries{action: label, bindingKind: bs.BindingKind}]++
+				break
+			}
+		}
+	}
+
+	subjects := make(map[subjectsSeries]int, len(unique))
+	for series, set := range unique {
+		subjects[series] = len(set)
+	}
+	return subjects, wildcardGrants, nil
+}
+
+// ServeHTTP writes the current metrics in the Prometheus text exposition format.
+func (e *MetricsExporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	e.WriteMetrics(w)
+}
+
+// WriteMetrics writes the current metrics in the Prometheus text exposition format to the given io.Writer.
+func (e *MetricsExporter) WriteMetrics(out io.Writer) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var lines []string
+	for _, series := range e.subjects {
+		for s, count := range series {
+			lines = append(lines, fmt.Sprintf("whocan_subjects{action=%s,binding_kind=%s,namespace=%s,subject_kind=%s} %d",
+				quoteLabel(s.action), quoteLabel(s.bindingKind), quoteLabel(s.namespace), quoteLabel(s.subjectKind), count))
+		}
+	}
+	writeMetric(out, "whocan_subjects", "gauge", "Number of subjects which can perform the action.", lines)
+
+	lines = nil
+	for _, series := range e.wildcardGrants {
+		for s, count := range series {
+			lines = append(lines, fmt.Sprintf("whocan_wildcard_grants{action=%s,binding_kind=%s} %d",
+				quoteLabel(s.action), quoteLabel(s.bindingKind), count))
+		}
+	}
+	writeMetric(out, "whocan_wildcard_grants", "gauge", "Number of binding subjects granted the action through a wildcard rule.", lines)
+
+	lines = nil
+	for action, count := range e.errors {
+		lines = append(lines, fmt.Sprintf("whocan_check_errors_total{action=%s} %d", quoteLabel(action), count))
+	}
+	writeMetric(out, "whocan_check_errors_total", "counter", "Number of failed checks of the action.", lines)
+}
+
+func writeMetric(out io.Writer, name, metricType, help string, lines []string) {
+	sort.Strings(lines)
+	_, _ = fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+	for _, line := range lines {
+		_, _ = fmt.Fprintln(out, line)
+	}
+}
+
+// quoteLabel quotes the given label value as required by the Prometheus text exposition format.
+func quoteLabel(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
+
+// actionLabel returns the value of the action label for the specified Action, e.g. `create pods/exec -n prod`.
+func actionLabel(action Action) string {
+	label := action.Verb + " "
+	if action.NonResourceURL != "" {
+		return label + action.NonResourceURL
+	}
+	label += action.Resource
+	if action.SubResource != "" {
+		label += "/" + action.SubResource
+	}
+	if action.ResourceName != "" {
+		label += " " + action.ResourceName
+	}
+	if action.Namespace != "" {
+		label += " -n " + action.Namespace
+	}
+	if len(action.Namespaces) > 0 {
+		label += " -n " + strings.Join(action.Namespaces, ",")
+	}
+	if action.NamespaceSelector != "" {
+		label += " --namespace-selector " + action.NamespaceSelector
+	}
+	return label
+}
+
+// NewMetricsCommand constructs the metrics command with the specified ConfigFlags.
+func NewMetricsCommand(configFlags *clioptions.ConfigFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          metricsUsage,
+		Short:        "Export who-can results as Prometheus metrics",
+		Long:         metricsLong,
+		Example:      metricsExample,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := cmd.Flags().GetString(configFlag)
+			if err != nil {
+				return err
+			}
+			listen, err := cmd.Flags().GetString(listenFlag)
+			if err != nil {
+				return err
+			}
+			interval, err := cmd.Flags().GetDuration(intervalFlag)
+			if err != nil {
+				return err
+			}
+			if interval <= 0 {
+				return fmt.Errorf("invalid interval: %v: must be positive", interval)
+			}
+
+			actions, err := ReadActionsFile(config)
+			if err != nil {
+				return err
+			}
+
+			restConfig, err := configFlags.ToRESTConfig()
+			if err != nil {
+				return fmt.Errorf("getting rest config: %v", err)
+			}
+
+			client, err := kubernetes.NewForConfig(restConfig)
+			if err != nil {
+				return err
+			}
+			factory := informers.NewSharedInformerFactory(client, 0)
+
+			o, err := NewWhoCan(configFlags, NewInformerRBACSource(factory))
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if err := startInformers(ctx, factory); err != nil {
+				return err
+			}
+
+			exporter := NewMetricsExporter(o, actions)
+			go exporter.Run(ctx, interval)
+
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", exporter)
+			server := newHTTPServer(listen, mux)
+			go func() {
+				<-ctx.Done()
+				_ = server.Shutdown(context.Background())
+			}()
+
+			klog.V(1).Infof("Serving metrics on %s/metrics", listen)
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String(configFlag, "", "Path to the file listing the actions to be checked")
+	cmd.Flags().String(listenFlag, ":9090", "The address to serve metrics on")
+	cmd.Flags().Duration(intervalFlag, time.Minute, "How often the actions are checked")
+	_ = cmd.MarkFlagRequired(configFlag)
+
+	return cmd
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clioptions "k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+func TestMetricsExporter(t *testing.T) {
+	// given
+	source, err := NewStaticRBACSource(
+		&rbac.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin"},
+			Rules:      []rbac.PolicyRule{{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}}},
+		},
+		&rbac.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: "read-secrets", Namespace: "prod"},
+			Rules:      []rbac.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}}},
+		},
+		&rbac.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "cluster-admin"},
+			Subjects: []rbac.Subject{
+				{Kind: rbac.GroupKind, Name: "system:masters"},
+				{Kind: rbac.UserKind, Name: "alice"},
+			},
+		},
+		&rbac.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "operators-can-read-secrets", Namespace: "prod"},
+			RoleRef:    rbac.RoleRef{Kind: RoleKind, Name: "read-secrets"},
+			Subjects: []rbac.Subject{
+				{Kind: rbac.ServiceAccountKind, Name: "operator", Namespace: "prod"},
+				{Kind: rbac.ServiceAccountKind, Name: "backup", Namespace: "prod"},
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	namespaceValidator := new(namespaceValidatorMock)
+	namespaceValidator.On("Validate", "prod").Return(nil)
+	namespaceValidator.On("Validate", core.NamespaceAll).Return(nil)
+	resourceResolver := new(resourceResolverMock)
+	resourceResolver.On("Resolve", "get", "secrets", "", false).Return([]schema.GroupResource{{Resource: "secrets"}}, nil, nil)
+	resourceResolver.On("Resolve", "get", "foo", "", false).Return(nil, nil, assert.AnError)
+
+	wc := &WhoCan{
+		rbacSource:         source,
+		namespaceValidator: namespaceValidator,
+		resourceResolver:   resourceResolver,
+		policyRuleMatcher:  NewPolicyRuleMatcher(),
+	}
+	exporter := NewMetricsExporter(wc, []Action{
+		{Verb: "get", Resource: "secrets", Namespace: "prod"},
+		{Verb: "get", Resource: "secrets", AllNamespaces: true},
+		{Verb: "get", Resource: "foo", AllNamespaces: true},
+	})
+
+	// when
+	exporter.Evaluate()
+	exporter.Evaluate()
+	var buf bytes.Buffer
+	exporter.WriteMetrics(&buf)
+
+	// then
+	assert.Equal(t, `# HELP whocan_subjects Number of subjects which can perform the action.
+# TYPE whocan_subjects gauge
+whocan_subjects{action="get secrets -n prod",binding_kind="ClusterRoleBinding",namespace="",subject_kind="Group"} 1
+whocan_subjects{action="get secrets -n prod",binding_kind="ClusterRoleBinding",namespace="",subject_kind="User"} 1
+whocan_subjects{action="get secrets -n prod",binding_kind="RoleBinding",namespace="prod",subject_kind="ServiceAccount"} 2
+whocan_subjects{action="get secrets",binding_kind="ClusterRoleBinding",namespace="",subject_kind="Group"} 1
+whocan_subjects{action="get secrets",binding_kind="ClusterRoleBinding",namespace="",subject_kind="User"} 1
+whocan_subjects{action="get secrets",binding_kind="RoleBinding",namespace="prod",subject_kind="ServiceAccount"} 2
+# HELP whocan_wildcard_grants Number of binding subjects granted the action through a wildcard rule.
+# TYPE whocan_wildcard_grants gauge
+whocan_wildcard_grants{action="get secrets -n prod",binding_kind="ClusterRoleBinding"} 2
+whocan_wildcard_grants{action="get secrets",binding_kind="ClusterRoleBinding"} 2
+# HELP whocan_check_errors_total Number of failed checks of the action.
+# TYPE whocan_check_errors_total counter
+whocan_check_errors_total{action="get foo"} 2
+`, buf.String())
+}
+
+func TestQuoteLabel(t *testing.T) {
+	assert.Equal(t, `"a\\b\"c\nd"`, quoteLabel("a\\b\"c\nd"))
+}
+
+func TestMetricsCommand_Interval(t *testing.T) {
+	// given
+	cmd := NewMetricsCommand(clioptions.NewConfigFlags(false))
+	cmd.SetArgs([]string{"--config", "actions.yaml", "--interval", "0s"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+
+	// when
+	err := cmd.Execute()
+
+	// then
+	assert.EqualError(t, err, "invalid interval: 0s: must be positive")
+}
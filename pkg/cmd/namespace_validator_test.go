@@ -41,7 +41,7 @@ func TestNamespaceValidator_Validate(t *testing.T) {
 				},
 			},
 
-			ExpectedErr: errors.New("\"my.namespace\" not found"),
+			ExpectedErr: invalidActionError{errors.New("\"my.namespace\" not found")},
 		}, {
 			TestName: "Should return error when namespace is not active",
 
@@ -52,7 +52,7 @@ func TestNamespaceValidator_Validate(t *testing.T) {
 			},
 			APIReturnedErr: nil,
 
-			ExpectedErr: errors.New("invalid status: Terminating"),
+			ExpectedErr: invalidActionError{errors.New("invalid status: Terminating")},
 		}, {
 			TestName: "Should return nil when namespace is active",
 
@@ -94,3 +94,10 @@ func newGetNamespacesReactionFunc(ns *v1.Namespace, err error) k8stesting.Reacti
 		return true, ns, err
 	}
 }
+
+func TestOfflineNamespaceValidator_Validate(t *testing.T) {
+	validator := NewOfflineNamespaceValidator()
+
+	assert.NoError(t, validator.Validate("foo"))
+	assert.NoError(t, validator.Validate(""))
+}
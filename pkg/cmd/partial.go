@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	rbac "k8s.io/api/rbac/v1"
+)
+
+const showPartialFlag = "show-partial"
+
+// partialBindings holds the bindings which grant an action on specific resource names only.
+type partialBindings struct {
+	roleBindings        []rbac.RoleBinding
+	clusterRoleBindings []rbac.ClusterRoleBinding
+}
+
+// CheckPartial returns the bindings which allow the specified Action to be performed on specific resource names only,
+// i.e. through PolicyRules restricted to resourceNames, leaving out the subjects which are granted the Action in full
+// by other bindings. Nothing is returned for an Action with a resource name or a non-resource URL.
+func (w *WhoCan) CheckPartial(action Action) (roleBindings []rbac.RoleBinding, clusterRoleBindings []rbac.ClusterRoleBinding, err error) {
+	if action.ResourceName != "" || action.NonResourceURL != "" {
+		return
+	}
+
+	err = w.validate(action)
+	if err != nil {
+		err = wrapActionError("validation", err)
+		return
+	}
+	resolved, err := w.resolve(action)
+	if err != nil {
+		return
+	}
+	namespaces, err := w.namespacesOf(action)
+	if err != nil {
+		return
+	}
+
+	fullRoleBindings, fullClusterRoleBindings, err := w.checkResolved(resolved, namespaces)
+	if err != nil {
+		return
+	}
+	resolved.anyResourceName = true
+	anyRoleBindings, anyClusterRoleBindings, err := w.checkResolved(resolved, namespaces)
+	if err != nil {
+		return
+	}
+
+	clusterWide := make(map[string]bool)
+	for _, crb := range fullClusterRoleBindings {
+		for _, s := range crb.Subjects {
+			clusterWide[subjectString(s)] = true
+		}
+	}
+	inNamespace := make(map[string]bool)
+	for _, rb := range fullRoleBindings {
+		for _, s := range rb.Subjects {
+			inNamespace[rb.Namespace+"/"+subjectString(s)] = true
+		}
+	}
+
+	for _, rb := range anyRoleBindings {
+		rb.Subjects = partialSubjects(rb.Subjects, func(key string) bool {
+			return clusterWide[key] || inNamespace[rb.Namespace+"/"+key]
+		})
+		if len(rb.Subjects) > 0 {
+			roleBindings = append(roleBindings, rb)
+		}
+	}
+	for _, crb := range anyClusterRoleBindings {
+		crb.Subjects = partialSubjects(crb.Subjects, func(key string) bool {
+			return clusterWide[key]
+		})
+		if len(crb.Subjects) > 0 {
+			clusterRoleBindings = append(clusterRoleBindings, crb)
+		}
+	}
+	return
+}
+
+// partialSubjects returns the subjects which are not granted the action in full according to the given function.
+func partialSubjects(subjects []rbac.Subject, full func(key string) bool) []rbac.Subject {
+	var partial []rbac.Subject
+	for _, s := range subjects {
+		if !full(subjectString(s)) {
+			partial = append(partial, s)
+		}
+	}
+	return partial
+}
+
+// SetPartial makes the Printer show the bindings which grant the checked action on specific resource names only in a
+// separate section of table output, and under the partial property of JSON output.
+func (p *Printer) SetPartial(roleBindings []rbac.RoleBinding, clusterRoleBindings []rbac.ClusterRoleBinding) {
+	p.partial = &partialBindings{roleBindings: roleBindings, clusterRoleBindings: clusterRoleBindings}
+}
+
+// printPartial prints the bindings which grant the action on specific resource names only, if any.
+func (p *Printer) printPartial(action Action) {
+	if p.partial == nil || len(p.partial.roleBindings) == 0 && len(p.partial.clusterRoleBindings) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(p.out, "\nSubjects with permissions to %s restricted to specific resource names:\n\n", action)
+
+	wr := new(tabwriter.Writer)
+	wr.Init(p.out, 0, 8, 2, ' ', 0)
+
+	if len(p.partial.roleBindings) > 0 {
+		p.printBindingsHeader(wr)
+		for _, row := range p.roleBindingRows(p.partial.roleBindings) {
+			p.printBindingRow(wr, row.binding, row.subject)
+		}
+		if len(p.partial.clusterRoleBindings) > 0 {
+			_, _ = fmt.Fprintln(wr)
+		}
+	}
+	if len(p.partial.clusterRoleBindings) > 0 {
+		p.printClusterBindingsHeader(wr)
+		for _, row := range p.clusterRoleBindingRows(p.partial.clusterRoleBindings) {
+			p.printClusterBindingRow(wr, row.binding, row.subject)
+		}
+	}
+	_ = wr.Flush()
+}
+
+// partialData returns the exported bindings which grant the action on specific resource names only, or nil if there
+// are none.
+func (p *Printer) partialData() map[string]interface{} {
+	if p.partial == nil || len(p.partial.roleBindings) == 0 && len(p.partial.clusterRoleBindings) == 0 {
+		return nil
+	}
+	data := make(map[string]interface{})
+	if len(p.partial.roleBindings) > 0 {
+		data["roleBindings"] = p.roleBindingsData(p.partial.roleBindings)
+	}
+	if len(p.partial.clusterRoleBindings) > 0 {
+		data["clusterRoleBindings"] = p.clusterRoleBindingsData(p.partial.clusterRoleBindings)
+	}
+	return data
+}
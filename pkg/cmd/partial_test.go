@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	partialRoleBinding = rbac.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "read-db-secret", Namespace: "foo"},
+		RoleRef:    rbac.RoleRef{Kind: RoleKind, Name: "db-secret-reader"},
+		Subjects:   []rbac.Subject{{Kind: rbac.UserKind, Name: "alice"}, {Kind: rbac.UserKind, Name: "bob"}},
+	}
+	partialClusterRoleBinding = rbac.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "read-tls-secrets"},
+		RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "tls-secret-reader"},
+		Subjects:   []rbac.Subject{{Kind: rbac.ServiceAccountKind, Namespace: "ingress", Name: "controller"}},
+	}
+)
+
+func TestWhoCan_CheckPartial(t *testing.T) {
+	// given
+	source, err := NewStaticRBACSource(
+		&rbac.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: "db-secret-reader", Namespace: "foo"},
+			Rules:      []rbac.PolicyRule{{Verbs: []string{"get", "create"}, APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: []string{"db"}}},
+		},
+		&rbac.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "tls-secret-reader"},
+			Rules:      []rbac.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: []string{"tls"}}},
+		},
+		&rbac.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "secret-reader"},
+			Rules:      []rbac.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}}},
+		},
+		&partialRoleBinding,
+		&rbac.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "read-secrets", Namespace: "foo"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "secret-reader"},
+			Subjects:   []rbac.Subject{{Kind: rbac.UserKind, Name: "bob"}},
+		},
+		&partialClusterRoleBinding,
+	)
+	require.NoError(t, err)
+	wc := NewOfflineWhoCan(source)
+
+	// when
+	roleBindings, clusterRoleBindings, err := wc.CheckPartial(Action{Verb: "get", Resource: "secrets", Namespace: "foo"})
+
+	// then
+	require.NoError(t, err)
+	expected := partialRoleBinding
+	expected.Subjects = []rbac.Subject{{Kind: rbac.UserKind, Name: "alice"}}
+	assert.Equal(t, []rbac.RoleBinding{expected}, roleBindings)
+	assert.Equal(t, []rbac.ClusterRoleBinding{partialClusterRoleBinding}, clusterRoleBindings)
+
+	// when the verb cannot be restricted to resource names
+	roleBindings, clusterRoleBindings, err = wc.CheckPartial(Action{Verb: "create", Resource: "secrets", Namespace: "foo"})
+
+	// then
+	require.NoError(t, err)
+	assert.Empty(t, roleBindings)
+	assert.Empty(t, clusterRoleBindings)
+
+	// when the action is restricted to a resource name
+	roleBindings, clusterRoleBindings, err = wc.CheckPartial(Action{Verb: "get", Resource: "secrets", ResourceName: "db", Namespace: "foo"})
+
+	// then
+	require.NoError(t, err)
+	assert.Empty(t, roleBindings)
+	assert.Empty(t, clusterRoleBindings)
+
+	// when the namespace does not exist
+	namespaceValidator := new(namespaceValidatorMock)
+	namespaceValidator.On("Validate", "bar").Return(invalidActionError{errors.New("\"bar\" not found")})
+	wc.namespaceValidator = namespaceValidator
+	_, _, err = wc.CheckPartial(Action{Verb: "get", Resource: "secrets", Namespace: "bar"})
+
+	// then
+	assert.EqualError(t, err, "validation: validating namespace: \"bar\" not found")
+	assert.True(t, isInvalidAction(err))
+}
+
+func TestPrinter_Partial(t *testing.T) {
+	// given
+	action := Action{Verb: "get", Resource: "secrets", Namespace: "foo"}
+	var buf bytes.Buffer
+	printer := NewPrinter(&buf, false)
+	printer.SetPartial([]rbac.RoleBinding{partialRoleBinding}, []rbac.ClusterRoleBinding{partialClusterRoleBinding})
+
+	// when
+	printer.PrintChecks(action, nil, nil)
+
+	// then
+	assert.Equal(t, `No subjects found with permissions to get secrets assigned through RoleBindings
+
+No subjects found with permissions to get secrets assigned through ClusterRoleBindings
+
+Subjects with permissions to get secrets restricted to specific resource names:
+
+ROLEBINDING     NAMESPACE  SUBJECT  TYPE  SA-NAMESPACE
+read-db-secret  foo        alice    User  
+read-db-secret  foo        bob      User  
+
+CLUSTERROLEBINDING  SUBJECT     TYPE            SA-NAMESPACE
+read-tls-secrets    controller  ServiceAccount  ingress
+`, buf.String())
+
+	// when
+	buf.Reset()
+	printer.SetPartial(nil, []rbac.ClusterRoleBinding{partialClusterRoleBinding})
+	printer.ExportData(action, nil, nil)
+
+	// then
+	assert.JSONEq(t, `{
+  "partial": {
+    "clusterRoleBindings": [{
+      "name": "read-tls-secrets",
+      "roleRef": {"apiGroup": "", "kind": "ClusterRole", "name": "tls-secret-reader"},
+      "subjects": [{"kind": "ServiceAccount", "namespace": "ingress", "name": "controller"}]
+    }]
+  }
+}`, buf.String())
+}
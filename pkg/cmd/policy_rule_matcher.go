@@ -10,11 +10,18 @@ import (
 // MatchesRole returns `true` if any PolicyRule defined by the given Role matches the specified Action, `false` otherwise.
 //
 // MatchesClusterRole returns `true` if any PolicyRule defined by the given ClusterRole matches the specified Action, `false` otherwise.
+//
+// MatchingRules returns the PolicyRules from the given list which match the specified Action.
 type PolicyRuleMatcher interface {
 	MatchesRole(role rbac.Role, action resolvedAction) bool
 	MatchesClusterRole(role rbac.ClusterRole, action resolvedAction) bool
+	MatchingRules(rules []rbac.PolicyRule, action resolvedAction) []rbac.PolicyRule
 }
 
+// namelessVerbs are the verbs whose requests for a resource never carry a resource name, hence PolicyRules
+// restricted to resource names cannot grant them.
+var namelessVerbs = []string{"create", "deletecollection"}
+
 type matcher struct {
 }
 
@@ -48,6 +55,16 @@ func (m *matcher) MatchesClusterRole(role rbac.ClusterRole, action resolvedActio
 	return false
 }
 
+func (m *matcher) MatchingRules(rules []rbac.PolicyRule, action resolvedAction) []rbac.PolicyRule {
+	var matching []rbac.PolicyRule
+	for _, rule := range rules {
+		if m.matches(rule, action) {
+			matching = append(matching, rule)
+		}
+	}
+	return matching
+}
+
 // matches returns `true` if the given PolicyRule matches the specified Action, `false` otherwise.
 func (m *matcher) matches(rule rbac.PolicyRule, action resolvedAction) bool {
 	if action.NonResourceURL != "" {
@@ -55,15 +72,21 @@ func (m *matcher) matches(rule rbac.PolicyRule, action resolvedAction) bool {
 			m.matchesNonResourceURL(rule, action.NonResourceURL)
 	}
 
-	resource := action.gr.Resource
-	if action.SubResource != "" {
-		resource += "/" + action.SubResource
+	if !m.matchesVerb(rule, action.Verb) || !m.matchesResourceName(rule, action) {
+		return false
 	}
 
-	return m.matchesVerb(rule, action.Verb) &&
-		m.matchesResource(rule, resource) &&
-		m.matchesAPIGroup(rule, action.gr.Group) &&
-		m.matchesResourceName(rule, action.ResourceName)
+	// A resource served by several API groups is matched by a rule granting it in any of them.
+	for _, gr := range action.groupResources() {
+		resource := gr.Resource
+		if action.SubResource != "" {
+			resource += "/" + action.SubResource
+		}
+		if m.matchesResource(rule, resource) && m.matchesAPIGroup(rule, gr.Group) {
+			return true
+		}
+	}
+	return false
 }
 
 func (m *matcher) matchesAPIGroup(rule rbac.PolicyRule, actionGroup string) bool {
@@ -93,19 +116,21 @@ func (m *matcher) matchesResource(rule rbac.PolicyRule, actionResource string) b
 	return false
 }
 
-func (m *matcher) matchesResourceName(rule rbac.PolicyRule, actionResourceName string) bool {
-	if actionResourceName == "" && len(rule.ResourceNames) == 0 {
-		return true
-	}
+// matchesResourceName returns `true` if the given PolicyRule grants the specified Action regardless of resource names,
+// or if it is restricted to resource names which include the one of the Action. As with the RBAC authorizer, a rule
+// restricted to resource names never grants an Action without resource name, such as listing all objects or creating
+// one, unless the resolvedAction asks for rules restricted to any resource name.
+func (m *matcher) matchesResourceName(rule rbac.PolicyRule, action resolvedAction) bool {
 	if len(rule.ResourceNames) == 0 {
 		return true
 	}
-	for _, name := range rule.ResourceNames {
-		if name == actionResourceName {
-			return true
-		}
+	if action.SubResource == "" && containsString(namelessVerbs, action.Verb) {
+		return false
 	}
-	return false
+	if action.ResourceName == "" {
+		return action.anyResourceName
+	}
+	return containsString(rule.ResourceNames, action.ResourceName)
 }
 
 func (m *matcher) matchesNonResourceURL(rule rbac.PolicyRule, actionNonResourceURL string) bool {
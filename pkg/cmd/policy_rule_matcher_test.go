@@ -178,6 +178,106 @@ func TestMatcher_matches(t *testing.T) {
 			},
 			matches: false,
 		},
+		{
+			scenario: "Should return false when action without name is restricted to names",
+			action: resolvedAction{
+				Action: Action{Verb: "list"},
+				gr:     servicesGR,
+			},
+			rule: rbac.PolicyRule{
+				Verbs:         []string{"list"},
+				APIGroups:     []string{""},
+				Resources:     []string{"services"},
+				ResourceNames: []string{"nginx"},
+			},
+			matches: false,
+		},
+		{
+			scenario: "Should return true when list action with name is restricted to the name",
+			action: resolvedAction{
+				Action: Action{Verb: "list", ResourceName: "nginx"},
+				gr:     servicesGR,
+			},
+			rule: rbac.PolicyRule{
+				Verbs:         []string{"list", "watch"},
+				APIGroups:     []string{""},
+				Resources:     []string{"services"},
+				ResourceNames: []string{"nginx"},
+			},
+			matches: true,
+		},
+		{
+			scenario: "Should return false when create action with name is restricted to the name",
+			action: resolvedAction{
+				Action: Action{Verb: "create", ResourceName: "nginx"},
+				gr:     servicesGR,
+			},
+			rule: rbac.PolicyRule{
+				Verbs:         []string{"create"},
+				APIGroups:     []string{""},
+				Resources:     []string{"services"},
+				ResourceNames: []string{"nginx"},
+			},
+			matches: false,
+		},
+		{
+			scenario: "Should return false when deletecollection action with name is restricted to the name",
+			action: resolvedAction{
+				Action: Action{Verb: "deletecollection", ResourceName: "nginx"},
+				gr:     servicesGR,
+			},
+			rule: rbac.PolicyRule{
+				Verbs:         []string{"*"},
+				APIGroups:     []string{""},
+				Resources:     []string{"services"},
+				ResourceNames: []string{"nginx"},
+			},
+			matches: false,
+		},
+		{
+			scenario: "Should return true when create action on subresource with name is restricted to the name",
+			action: resolvedAction{
+				Action: Action{Verb: "create", SubResource: "exec", ResourceName: "nginx"},
+				gr:     schema.GroupResource{Resource: "pods"},
+			},
+			rule: rbac.PolicyRule{
+				Verbs:         []string{"create"},
+				APIGroups:     []string{""},
+				Resources:     []string{"pods/exec"},
+				ResourceNames: []string{"nginx"},
+			},
+			matches: true,
+		},
+		{
+			scenario: "Should return true when action without name matches any resource name",
+			action: resolvedAction{
+				Action:          Action{Verb: "get"},
+				gr:              servicesGR,
+				anyResourceName: true,
+			},
+			rule: rbac.PolicyRule{
+				Verbs:         []string{"get"},
+				APIGroups:     []string{""},
+				Resources:     []string{"services"},
+				ResourceNames: []string{"nginx"},
+			},
+			matches: true,
+		},
+		{
+			scenario: "Should return false when create action matches any resource name",
+			action: resolvedAction{
+				Action:          Action{Verb: "create"},
+				gr:              servicesGR,
+				anyResourceName: true,
+			},
+			rule: rbac.PolicyRule{
+				Verbs:         []string{"create"},
+				APIGroups:     []string{""},
+				Resources:     []string{"services"},
+				ResourceNames: []string{"nginx"},
+			},
+			matches: false,
+		},
 		{
 			scenario: "H",
 			action: resolvedAction{
@@ -270,6 +370,59 @@ func TestMatcher_matches(t *testing.T) {
 			},
 			matches: false,
 		},
+		{
+			scenario: "Should return true when PolicyRule's APIGroup matches any group serving the resource",
+			action: resolvedAction{
+				Action: Action{Verb: "list"},
+				gr:     schema.GroupResource{Resource: "events"},
+				grs:    []schema.GroupResource{{Resource: "events"}, {Resource: "events", Group: "events.k8s.io"}},
+			},
+			rule: rbac.PolicyRule{
+				Verbs:     []string{"list"},
+				APIGroups: []string{"events.k8s.io"},
+				Resources: []string{"events"},
+			},
+			matches: true,
+		},
+		{
+			scenario: "Should return true when PolicyRule grants all resources of the group",
+			action: resolvedAction{
+				Action: Action{Verb: "delete"},
+				gr:     schema.GroupResource{Resource: "*", Group: "apps"},
+			},
+			rule: rbac.PolicyRule{
+				Verbs:     []string{"delete"},
+				APIGroups: []string{"apps"},
+				Resources: []string{"*"},
+			},
+			matches: true,
+		},
+		{
+			scenario: "Should return false when PolicyRule grants a single resource of the group",
+			action: resolvedAction{
+				Action: Action{Verb: "delete"},
+				gr:     schema.GroupResource{Resource: "*", Group: "apps"},
+			},
+			rule: rbac.PolicyRule{
+				Verbs:     []string{"delete"},
+				APIGroups: []string{"apps"},
+				Resources: []string{"deployments"},
+			},
+			matches: false,
+		},
+		{
+			scenario: "Should return false when PolicyRule doesn't grant all API groups",
+			action: resolvedAction{
+				Action: Action{Verb: "get"},
+				gr:     schema.GroupResource{Resource: "deployments", Group: "*"},
+			},
+			rule: rbac.PolicyRule{
+				Verbs:     []string{"get"},
+				APIGroups: []string{"apps"},
+				Resources: []string{"deployments"},
+			},
+			matches: false,
+		},
 	}
 
 	// given
@@ -286,3 +439,19 @@ func TestMatcher_matches(t *testing.T) {
 	}
 
 }
+
+func TestMatcher_MatchingRules(t *testing.T) {
+	// given
+	matcher := NewPolicyRuleMatcher()
+	getPods := rbac.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}}
+	listPods := rbac.PolicyRule{Verbs: []string{"list"}, APIGroups: []string{""}, Resources: []string{"pods"}}
+	all := rbac.PolicyRule{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}}
+	action := resolvedAction{
+		Action: Action{Verb: "get"},
+		gr:     schema.GroupResource{Resource: "pods"},
+	}
+
+	// then
+	assert.Equal(t, []rbac.PolicyRule{getPods, all}, matcher.MatchingRules([]rbac.PolicyRule{getPods, listPods, all}, action))
+	assert.Empty(t, matcher.MatchingRules([]rbac.PolicyRule{listPods}, action))
+}
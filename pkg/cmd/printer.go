@@ -12,8 +12,14 @@ import (
 
 // Printer formats and prints check results and warnings.
 type Printer struct {
-	out  io.Writer
-	wide bool
+	out         io.Writer
+	wide        bool
+	awsAuth     *AWSAuth
+	identityMap *IdentityMap
+	sortBy      string
+	partial     *partialBindings
+
+	watchHeaderPrinted bool
 }
 
 // NewPrinter constructs a new Printer with the specified output io.Writer
@@ -27,39 +33,44 @@ func NewPrinter(out io.Writer, wide bool) *Printer {
 
 // Struct to hold either rb or crb objects
 type rowData struct {
-	Name     string         `json:"name"`
-	RoleRef  rbac.RoleRef   `json:"roleRef" protobuf:"bytes,3,opt,name=roleRef"`
-	Subjects []rbac.Subject `json:"subjects,omitempty" protobuf:"bytes,2,rep,name=subjects"`
+	Name     string        `json:"name"`
+	RoleRef  rbac.RoleRef  `json:"roleRef" protobuf:"bytes,3,opt,name=roleRef"`
+	Subjects []subjectData `json:"subjects,omitempty" protobuf:"bytes,2,rep,name=subjects"`
+
+	namespace string
 }
 
 // ExportData exports data to a file.
 func (p *Printer) ExportData(action Action, roleBindings []rbac.RoleBinding, clusterRoleBindings []rbac.ClusterRoleBinding) {
+	p.exportData(action, roleBindings, clusterRoleBindings, HiddenSubjects{})
+}
+
+// ExportDataWithHidden exports data to a file along with the number of binding subjects hidden from it by reason.
+func (p *Printer) ExportDataWithHidden(action Action, roleBindings []rbac.RoleBinding, clusterRoleBindings []rbac.ClusterRoleBinding, hidden HiddenSubjects) {
+	p.exportData(action, roleBindings, clusterRoleBindings, hidden)
+}
+
+func (p *Printer) exportData(action Action, roleBindings []rbac.RoleBinding, clusterRoleBindings []rbac.ClusterRoleBinding, hidden HiddenSubjects) {
 	// Final data to be exported as JSON
 	data := make(map[string]interface{})
 
 	if action.Resource != "" {
 		// NonResourceURL permissions can only be granted through ClusterRoles. Hence no point in printing RoleBindings section.
 		if len(roleBindings) != 0 {
-			rbData := []rowData{}
-			// Get required data from each roleBinding
-			for _, rb := range roleBindings {
-				if len(rb.Subjects) != 0 {
-					rbData = append(rbData, rowData{rb.Name, rb.RoleRef, rb.Subjects})
-				}
-			}
-			data["roleBindings"] = rbData
+			data["roleBindings"] = p.roleBindingsData(roleBindings)
 		}
 	}
 
 	if len(clusterRoleBindings) != 0 {
-		crbData := []rowData{}
-		// Get required data from each roleBinding
-		for _, crb := range clusterRoleBindings {
-			if len(crb.Subjects) != 0 {
-				crbData = append(crbData, rowData{crb.Name, crb.RoleRef, crb.Subjects})
-			}
-		}
-		data["clusterRoleBindings"] = crbData
+		data["clusterRoleBindings"] = p.clusterRoleBindingsData(clusterRoleBindings)
+	}
+
+	if partial := p.partialData(); partial != nil {
+		data["partial"] = partial
+	}
+
+	if hidden.Total > 0 {
+		data["hidden"] = hidden
 	}
 
 	// get encoder to write data into output stream
@@ -72,6 +83,33 @@ func (p *Printer) ExportData(action Action, roleBindings []rbac.RoleBinding, clu
 	_ = encoder.Encode(data)
 }
 
+// roleBindingsData returns the exported data of the RoleBindings with subjects, sorted by the sort key of the Printer.
+func (p *Printer) roleBindingsData(roleBindings []rbac.RoleBinding) []rowData {
+	rbData := []rowData{}
+	// Get required data from each roleBinding
+	for _, rb := range roleBindings {
+		if len(rb.Subjects) != 0 {
+			rbData = append(rbData, rowData{Name: rb.Name, RoleRef: rb.RoleRef, Subjects: p.subjectsData(uniqueSubjects(rb.Subjects, p.sortBy)), namespace: rb.Namespace})
+		}
+	}
+	p.sortRowData(rbData)
+	return rbData
+}
+
+// clusterRoleBindingsData returns the exported data of the ClusterRoleBindings with subjects, sorted by the sort key
+// of the Printer.
+func (p *Printer) clusterRoleBindingsData(clusterRoleBindings []rbac.ClusterRoleBinding) []rowData {
+	crbData := []rowData{}
+	// Get required data from each clusterRoleBinding
+	for _, crb := range clusterRoleBindings {
+		if len(crb.Subjects) != 0 {
+			crbData = append(crbData, rowData{Name: crb.Name, RoleRef: crb.RoleRef, Subjects: p.subjectsData(uniqueSubjects(crb.Subjects, p.sortBy))})
+		}
+	}
+	p.sortRowData(crbData)
+	return crbData
+}
+
 func (p *Printer) PrintChecks(action Action, roleBindings []rbac.RoleBinding, clusterRoleBindings []rbac.ClusterRoleBinding) {
 	wr := new(tabwriter.Writer)
 	wr.Init(p.out, 0, 8, 2, ' ', 0)
@@ -82,10 +120,8 @@ func (p *Printer) PrintChecks(action Action, roleBindings []rbac.RoleBinding, cl
 			_, _ = fmt.Fprintf(p.out, "No subjects found with permissions to %s assigned through RoleBindings\n", action)
 		} else {
 			p.printBindingsHeader(wr)
-			for _, rb := range roleBindings {
-				for _, s := range rb.Subjects {
-					p.printBindingRow(wr, rb, s)
-				}
+			for _, row := range p.roleBindingRows(roleBindings) {
+				p.printBindingRow(wr, row.binding, row.subject)
 			}
 		}
 
@@ -96,13 +132,13 @@ func (p *Printer) PrintChecks(action Action, roleBindings []rbac.RoleBinding, cl
 		_, _ = fmt.Fprintf(p.out, "No subjects found with permissions to %s assigned through ClusterRoleBindings\n", action)
 	} else {
 		p.printClusterBindingsHeader(wr)
-		for _, rb := range clusterRoleBindings {
-			for _, s := range rb.Subjects {
-				p.printClusterBindingRow(wr, rb, s)
-			}
+		for _, row := range p.clusterRoleBindingRows(clusterRoleBindings) {
+			p.printClusterBindingRow(wr, row.binding, row.subject)
 		}
 	}
 	_ = wr.Flush()
+
+	p.printPartial(action)
 }
 
 func (p *Printer) printBindingsHeader(wr *tabwriter.Writer) {
@@ -112,6 +148,7 @@ func (p *Printer) printBindingsHeader(wr *tabwriter.Writer) {
 	} else {
 		columns = []string{"ROLEBINDING", "NAMESPACE", "SUBJECT", "TYPE", "SA-NAMESPACE"}
 	}
+	columns = append(columns, p.subjectColumnHeaders()...)
 	_, _ = fmt.Fprintln(wr, strings.Join(columns, "\t"))
 }
 
@@ -120,13 +157,13 @@ func (p *Printer) printBindingRow(wr *tabwriter.Writer, rb rbac.RoleBinding, s r
 	var args []interface{}
 
 	if p.wide {
-		format = "%s\t%s/%s\t%s\t%s\t%s\t%s\n"
+		format = "%s\t%s/%s\t%s\t%s\t%s\t%s"
 		args = []interface{}{rb.Name, rb.RoleRef.Kind, rb.RoleRef.Name, rb.Namespace, s.Name, s.Kind, s.Namespace}
 	} else {
-		format = "%s\t%s\t%s\t%s\t%s\n"
+		format = "%s\t%s\t%s\t%s\t%s"
 		args = []interface{}{rb.Name, rb.Namespace, s.Name, s.Kind, s.Namespace}
 	}
-	_, _ = fmt.Fprintf(wr, format, args...)
+	p.printRow(wr, format, args, s)
 }
 
 func (p *Printer) printClusterBindingsHeader(wr *tabwriter.Writer) {
@@ -136,6 +173,7 @@ func (p *Printer) printClusterBindingsHeader(wr *tabwriter.Writer) {
 	} else {
 		columns = []string{"CLUSTERROLEBINDING", "SUBJECT", "TYPE", "SA-NAMESPACE"}
 	}
+	columns = append(columns, p.subjectColumnHeaders()...)
 	_, _ = fmt.Fprintln(wr, strings.Join(columns, "\t"))
 }
 
@@ -143,13 +181,141 @@ func (p *Printer) printClusterBindingRow(wr *tabwriter.Writer, crb rbac.ClusterR
 	var format string
 	var args []interface{}
 	if p.wide {
-		format = "%s\t%s/%s\t%s\t%s\t%s\n"
+		format = "%s\t%s/%s\t%s\t%s\t%s"
 		args = []interface{}{crb.Name, crb.RoleRef.Kind, crb.RoleRef.Name, s.Name, s.Kind, s.Namespace}
 	} else {
-		format = "%s\t%s\t%s\t%s\n"
+		format = "%s\t%s\t%s\t%s"
 		args = []interface{}{crb.Name, s.Name, s.Kind, s.Namespace}
 	}
-	_, _ = fmt.Fprintf(wr, format, args...)
+	p.printRow(wr, format, args, s)
+}
+
+// printRow prints a table row of a binding subject followed by the optional columns describing the subject.
+func (p *Printer) printRow(wr *tabwriter.Writer, format string, args []interface{}, s rbac.Subject) {
+	for _, column := range p.subjectColumns(s) {
+		format += "\t%s"
+		args = append(args, column)
+	}
+	_, _ = fmt.Fprintf(wr, format+"\n", args...)
+}
+
+// subjectColumnHeaders returns the headers of the optional columns describing binding subjects, i.e. the owner in
+// wide output if an IdentityMap is set, and the IAM identities if the aws-auth ConfigMap is set.
+func (p *Printer) subjectColumnHeaders() []string {
+	var headers []string
+	if p.wide && p.identityMap != nil {
+		headers = append(headers, "OWNER")
+	}
+	if p.awsAuth != nil {
+		headers = append(headers, "AWS-IDENTITIES")
+	}
+	return headers
+}
+
+// subjectColumns returns the values of the optional columns describing the given binding subject.
+func (p *Printer) subjectColumns(s rbac.Subject) []interface{} {
+	var columns []interface{}
+	if p.wide && p.identityMap != nil {
+		columns = append(columns, p.identityMap.OwnerOf(s))
+	}
+	if p.awsAuth != nil {
+		columns = append(columns, strings.Join(p.awsAuth.ARNsFor(s), ","))
+	}
+	return columns
+}
+
+// subjectData is a binding subject along with its owner and the IAM identities mapped onto it.
+type subjectData struct {
+	rbac.Subject
+	Owner         string   `json:"owner,omitempty"`
+	AWSIdentities []string `json:"awsIdentities,omitempty"`
+}
+
+// subjectsData returns the specified subjects along with their owners and the IAM identities mapped onto them.
+func (p *Printer) subjectsData(subjects []rbac.Subject) []subjectData {
+	data := make([]subjectData, 0, len(subjects))
+	for _, s := range subjects {
+		data = append(data, p.subjectData(s))
+	}
+	return data
+}
+
+// subjectData returns the specified subject along with its owner and the IAM identities mapped onto it.
+func (p *Printer) subjectData(s rbac.Subject) subjectData {
+	d := subjectData{Subject: s}
+	if p.identityMap != nil {
+		d.Owner = p.identityMap.OwnerOf(s)
+	}
+	if p.awsAuth != nil {
+		d.AWSIdentities = p.awsAuth.ARNsFor(s)
+	}
+	return d
+}
+
+// watchColumnWidths are the widths of the columns of watch events by header. Watch events are printed as they occur,
+// hence they are aligned with fixed widths rather than by a tabwriter. A longer value only widens its own cell.
+var watchColumnWidths = map[string]int{
+	"EVENT":        9,
+	"BINDING":      48,
+	"NAMESPACE":    20,
+	"ROLE":         40,
+	"SUBJECT":      32,
+	"TYPE":         16,
+	"SA-NAMESPACE": 20,
+	"OWNER":        24,
+}
+
+// PrintWatchEvents prints the given watch events as table rows with fixed column widths, so that the rows of
+// successive events are aligned. The header is printed only once. Wide output adds the role of each binding.
+func (p *Printer) PrintWatchEvents(events []WatchEvent) {
+	headers := []string{"EVENT", "BINDING", "NAMESPACE"}
+	if p.wide {
+		headers = append(headers, "ROLE")
+	}
+	headers = append(headers, "SUBJECT", "TYPE", "SA-NAMESPACE")
+	headers = append(headers, p.subjectColumnHeaders()...)
+
+	if !p.watchHeaderPrinted {
+		_, _ = fmt.Fprintln(p.out)
+		p.printWatchRow(headers, headers)
+		p.watchHeaderPrinted = true
+	}
+	for _, e := range events {
+		columns := []string{e.Type, e.BindingKind + "/" + e.Binding, e.Namespace}
+		if p.wide {
+			columns = append(columns, e.RoleRef.Kind+"/"+e.RoleRef.Name)
+		}
+		columns = append(columns, e.Subject.Name, e.Subject.Kind, e.Subject.Namespace)
+		for _, column := range p.subjectColumns(e.Subject) {
+			columns = append(columns, fmt.Sprint(column))
+		}
+		p.printWatchRow(headers, columns)
+	}
+}
+
+// printWatchRow prints the given columns padded to the widths of the columns with the given headers.
+func (p *Printer) printWatchRow(headers, columns []string) {
+	var row strings.Builder
+	for i, column := range columns {
+		row.WriteString(column)
+		if i == len(columns)-1 {
+			break
+		}
+		padding := watchColumnWidths[headers[i]] - len(column)
+		if padding < 2 {
+			padding = 2
+		}
+		row.WriteString(strings.Repeat(" ", padding))
+	}
+	_, _ = fmt.Fprintln(p.out, strings.TrimRight(row.String(), " "))
+}
+
+// ExportWatchEvents exports the given watch events as newline delimited JSON.
+func (p *Printer) ExportWatchEvents(events []WatchEvent) {
+	encoder := json.NewEncoder(p.out)
+	for _, e := range events {
+		_ = encoder.Encode(e)
+	}
 }
 
 // PrintWarnings prints warnings, if any, returned by CheckAPIAccess.
@@ -162,3 +328,15 @@ func (p *Printer) PrintWarnings(warnings []string) {
 		_, _ = fmt.Fprintln(p.out)
 	}
 }
+
+// PrintNotes prints notes, if any, about the API groups of the resource returned by WhoCan.ResolveResource. Unlike
+// warnings, they don't mean that the list might not be complete.
+func (p *Printer) PrintNotes(notes []string) {
+	if len(notes) > 0 {
+		_, _ = fmt.Fprintln(p.out, "Note: Resolving the API groups of the resource:")
+		for _, note := range notes {
+			_, _ = fmt.Fprintf(p.out, "\t%s\n", note)
+		}
+		_, _ = fmt.Fprintln(p.out)
+	}
+}
@@ -43,6 +43,16 @@ func TestPrinter_PrintWarnings(t *testing.T) {
 	}
 }
 
+func TestPrinter_PrintNotes(t *testing.T) {
+	var buf bytes.Buffer
+	cmd.NewPrinter(&buf, false).PrintNotes([]string{"n1", "n2"})
+	assert.Equal(t, "Note: Resolving the API groups of the resource:\n\tn1\n\tn2\n\n", buf.String())
+
+	buf.Reset()
+	cmd.NewPrinter(&buf, false).PrintNotes(nil)
+	assert.Empty(t, buf.String())
+}
+
 // TODO Use more descriptive names for test cases rather than A, B, C, ...
 func TestPrinter_PrintChecks(t *testing.T) {
 	testCases := []struct {
@@ -105,8 +115,8 @@ No subjects found with permissions to get pods/my-pod assigned through ClusterRo
 				},
 			},
 			output: `ROLEBINDING           NAMESPACE  SUBJECT  TYPE   SA-NAMESPACE
-Alice-can-view-pods   default    Alice    User   
 Admins-can-view-pods  bar        Admins   Group  
+Alice-can-view-pods   default    Alice    User   
 
 CLUSTERROLEBINDING         SUBJECT  TYPE            SA-NAMESPACE
 Bob-and-Eve-can-view-pods  Bob      ServiceAccount  foo
@@ -151,8 +161,8 @@ Bob-and-Eve-can-view-pods  Eve      User
 			},
 			wide: true,
 			output: `ROLEBINDING           ROLE              NAMESPACE  SUBJECT  TYPE   SA-NAMESPACE
-Alice-can-view-pods   Role/view-pods    default    Alice    User   
 Admins-can-view-pods  ClusterRole/view  bar        Admins   Group  
+Alice-can-view-pods   Role/view-pods    default    Alice    User   
 
 CLUSTERROLEBINDING         ROLE              SUBJECT  TYPE            SA-NAMESPACE
 Bob-and-Eve-can-view-pods  ClusterRole/view  Bob      ServiceAccount  foo
@@ -238,7 +248,7 @@ func TestPrinter_ExportData(t *testing.T) {
 					},
 				},
 			},
-			output: "{\n    \"clusterRoleBindings\": [\n        {\n            \"name\": \"Bob-and-Eve-can-view-pods\",\n            \"roleRef\": {\n                \"apiGroup\": \"\",\n                \"kind\": \"\",\n                \"name\": \"\"\n            },\n            \"subjects\": [\n                {\n                    \"kind\": \"ServiceAccount\",\n                    \"name\": \"Bob\",\n                    \"namespace\": \"foo\"\n                },\n                {\n                    \"kind\": \"User\",\n                    \"name\": \"Eve\"\n                }\n            ]\n        }\n    ],\n    \"roleBindings\": [\n        {\n            \"name\": \"Alice-can-view-pods\",\n            \"roleRef\": {\n                \"apiGroup\": \"\",\n                \"kind\": \"\",\n                \"name\": \"\"\n            },\n            \"subjects\": [\n                {\n                    \"kind\": \"User\",\n                    \"name\": \"Alice\"\n                }\n            ]\n        },\n        {\n            \"name\": \"Admins-can-view-pods\",\n            \"roleRef\": {\n                \"apiGroup\": \"\",\n                \"kind\": \"\",\n                \"name\": \"\"\n            },\n            \"subjects\": [\n                {\n                    \"kind\": \"Group\",\n                    \"name\": \"Admins\"\n                }\n            ]\n        }\n    ]\n}\n",
+			output: "{\n    \"clusterRoleBindings\": [\n        {\n            \"name\": \"Bob-and-Eve-can-view-pods\",\n            \"roleRef\": {\n                \"apiGroup\": \"\",\n                \"kind\": \"\",\n                \"name\": \"\"\n            },\n            \"subjects\": [\n                {\n                    \"kind\": \"ServiceAccount\",\n                    \"name\": \"Bob\",\n                    \"namespace\": \"foo\"\n                },\n                {\n                    \"kind\": \"User\",\n                    \"name\": \"Eve\"\n                }\n            ]\n        }\n    ],\n    \"roleBindings\": [\n        {\n            \"name\": \"Admins-can-view-pods\",\n            \"roleRef\": {\n                \"apiGroup\": \"\",\n                \"kind\": \"\",\n                \"name\": \"\"\n            },\n            \"subjects\": [\n                {\n                    \"kind\": \"Group\",\n                    \"name\": \"Admins\"\n                }\n            ]\n        },\n        {\n            \"name\": \"Alice-can-view-pods\",\n            \"roleRef\": {\n                \"apiGroup\": \"\",\n                \"kind\": \"\",\n                \"name\": \"\"\n            },\n            \"subjects\": [\n                {\n                    \"kind\": \"User\",\n                    \"name\": \"Alice\"\n                }\n            ]\n        }\n    ]\n}\n",
 		},
 		{
 			scenario: "E",
@@ -277,7 +287,7 @@ func TestPrinter_ExportData(t *testing.T) {
 				},
 			},
 			wide:   true,
-			output: "{\n    \"clusterRoleBindings\": [\n        {\n            \"name\": \"Bob-and-Eve-can-view-pods\",\n            \"roleRef\": {\n                \"apiGroup\": \"\",\n                \"kind\": \"ClusterRole\",\n                \"name\": \"view\"\n            },\n            \"subjects\": [\n                {\n                    \"kind\": \"ServiceAccount\",\n                    \"name\": \"Bob\",\n                    \"namespace\": \"foo\"\n                },\n                {\n                    \"kind\": \"User\",\n                    \"name\": \"Eve\"\n                }\n            ]\n        }\n    ],\n    \"roleBindings\": [\n        {\n            \"name\": \"Alice-can-view-pods\",\n            \"roleRef\": {\n                \"apiGroup\": \"\",\n                \"kind\": \"Role\",\n                \"name\": \"view-pods\"\n            },\n            \"subjects\": [\n                {\n                    \"kind\": \"User\",\n                    \"name\": \"Alice\"\n                }\n            ]\n        },\n        {\n            \"name\": \"Admins-can-view-pods\",\n            \"roleRef\": {\n                \"apiGroup\": \"\",\n                \"kind\": \"ClusterRole\",\n                \"name\": \"view\"\n            },\n            \"subjects\": [\n                {\n                    \"kind\": \"Group\",\n                    \"name\": \"Admins\"\n                }\n            ]\n        }\n    ]\n}\n",
+			output: "{\n    \"clusterRoleBindings\": [\n        {\n            \"name\": \"Bob-and-Eve-can-view-pods\",\n            \"roleRef\": {\n                \"apiGroup\": \"\",\n                \"kind\": \"ClusterRole\",\n                \"name\": \"view\"\n            },\n            \"subjects\": [\n                {\n                    \"kind\": \"ServiceAccount\",\n                    \"name\": \"Bob\",\n                    \"namespace\": \"foo\"\n                },\n                {\n                    \"kind\": \"User\",\n                    \"name\": \"Eve\"\n                }\n            ]\n        }\n    ],\n    \"roleBindings\": [\n        {\n            \"name\": \"Admins-can-view-pods\",\n            \"roleRef\": {\n                \"apiGroup\": \"\",\n                \"kind\": \"ClusterRole\",\n                \"name\": \"view\"\n            },\n            \"subjects\": [\n                {\n                    \"kind\": \"Group\",\n                    \"name\": \"Admins\"\n                }\n            ]\n        },\n        {\n            \"name\": \"Alice-can-view-pods\",\n            \"roleRef\": {\n                \"apiGroup\": \"\",\n                \"kind\": \"Role\",\n                \"name\": \"view-pods\"\n            },\n            \"subjects\": [\n                {\n                    \"kind\": \"User\",\n                    \"name\": \"Alice\"\n                }\n            ]\n        }\n    ]\n}\n",
 		},
 		{
 			scenario: "F",
@@ -318,3 +328,65 @@ func TestPrinter_ExportData(t *testing.T) {
 		})
 	}
 }
+
+func TestPrinter_ExportDataWithHidden(t *testing.T) {
+	// given
+	var buf bytes.Buffer
+	action := cmd.Action{Verb: "get", NonResourceURL: "/logs"}
+	hidden := cmd.HiddenSubjects{Total: 2, Reasons: map[string]int{"Kubelet node identity": 2}}
+
+	// when
+	cmd.NewPrinter(&buf, false).ExportDataWithHidden(action, nil, nil, hidden)
+
+	// then
+	assert.Equal(t, `{
+    "hidden": {
+        "total": 2,
+        "reasons": {
+            "Kubelet node identity": 2
+        }
+    }
+}
+`, buf.String())
+}
+
+func TestPrinter_PrintWatchEvents(t *testing.T) {
+	// given
+	var buf bytes.Buffer
+	printer := cmd.NewPrinter(&buf, false)
+	added := cmd.WatchEvent{Type: cmd.WatchEventAdded}
+	added.BindingKind = "RoleBinding"
+	added.Binding = "alice-can-read-secrets"
+	added.Namespace = "foo"
+	added.RoleRef = rbac.RoleRef{Kind: cmd.RoleKind, Name: "read-secrets"}
+	added.Subject = rbac.Subject{Kind: rbac.UserKind, Name: "Alice"}
+	removed := cmd.WatchEvent{Type: cmd.WatchEventRemoved}
+	removed.BindingKind = "ClusterRoleBinding"
+	removed.Binding = "bob-can-read-secrets"
+	removed.RoleRef = rbac.RoleRef{Kind: cmd.ClusterRoleKind, Name: "read-secrets"}
+	removed.Subject = rbac.Subject{Kind: rbac.ServiceAccountKind, Name: "bob", Namespace: "bar"}
+
+	// when
+	printer.PrintWatchEvents([]cmd.WatchEvent{added})
+	printer.PrintWatchEvents([]cmd.WatchEvent{removed})
+
+	// then
+	assert.Equal(t, `
+EVENT    BINDING                                         NAMESPACE           SUBJECT                         TYPE            SA-NAMESPACE
+ADDED    RoleBinding/alice-can-read-secrets              foo                 Alice                           User
+REMOVED  ClusterRoleBinding/bob-can-read-secrets                             bob                             ServiceAccount  bar
+`, buf.String())
+
+	// when
+	buf.Reset()
+	printer = cmd.NewPrinter(&buf, true)
+	printer.PrintWatchEvents([]cmd.WatchEvent{added})
+	printer.PrintWatchEvents([]cmd.WatchEvent{removed})
+
+	// then
+	assert.Equal(t, `
+EVENT    BINDING                                         NAMESPACE           ROLE                                    SUBJECT                         TYPE            SA-NAMESPACE
+ADDED    RoleBinding/alice-can-read-secrets              foo                 Role/read-secrets                       Alice                           User
+REMOVED  ClusterRoleBinding/bob-can-read-secrets                             ClusterRole/read-secrets                bob                             ServiceAccount  bar
+`, buf.String())
+}
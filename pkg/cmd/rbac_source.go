@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	clientrbac "k8s.io/client-go/kubernetes/typed/rbac/v1"
+	listerrbac "k8s.io/client-go/listers/rbac/v1"
+)
+
+// RBACSource wraps the methods used to read RBAC objects.
+//
+// Each method returns the objects whose labels match the specified selector. ListRoles and ListRoleBindings return
+// the objects defined in the specified namespace. Specifying "" as namespace returns the objects defined in all
+// namespaces.
+type RBACSource interface {
+	ListRoles(namespace string, selector labels.Selector) ([]rbac.Role, error)
+	ListClusterRoles(selector labels.Selector) ([]rbac.ClusterRole, error)
+	ListRoleBindings(namespace string, selector labels.Selector) ([]rbac.RoleBinding, error)
+	ListClusterRoleBindings(selector labels.Selector) ([]rbac.ClusterRoleBinding, error)
+}
+
+type clientRBACSource struct {
+	client clientrbac.RbacV1Interface
+}
+
+// NewClientRBACSource constructs an RBACSource which lists RBAC objects directly from the API server.
+func NewClientRBACSource(client clientrbac.RbacV1Interface) RBACSource {
+	return &clientRBACSource{
+		client: client,
+	}
+}
+
+func (s *clientRBACSource) ListRoles(namespace string, selector labels.Selector) ([]rbac.Role, error) {
+	list, err := s.client.Roles(namespace).List(context.Background(), listOptions(selector))
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (s *clientRBACSource) ListClusterRoles(selector labels.Selector) ([]rbac.ClusterRole, error) {
+	list, err := s.client.ClusterRoles().List(context.Background(), listOptions(selector))
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (s *clientRBACSource) ListRoleBindings(namespace string, selector labels.Selector) ([]rbac.RoleBinding, error) {
+	list, err := s.client.RoleBindings(namespace).List(context.Background(), listOptions(selector))
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (s *clientRBACSource) ListClusterRoleBindings(selector labels.Selector) ([]rbac.ClusterRoleBinding, error) {
+	list, err := s.client.ClusterRoleBindings().List(context.Background(), listOptions(selector))
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// listOptions returns the ListOptions selecting objects by the given label selector.
+func listOptions(selector labels.Selector) metav1.ListOptions {
+	if selector.Empty() {
+		return metav1.ListOptions{}
+	}
+	return metav1.ListOptions{LabelSelector: selector.String()}
+}
+
+// startInformers starts the informers registered with the given factory and waits for their caches to sync.
+func startInformers(ctx context.Context, factory informers.SharedInformerFactory) error {
+	factory.Start(ctx.Done())
+	for informerType, synced := range factory.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			return fmt.Errorf("waiting for %v cache to sync", informerType)
+		}
+	}
+	return nil
+}
+
+type informerRBACSource struct {
+	roles               listerrbac.RoleLister
+	clusterRoles        listerrbac.ClusterRoleLister
+	roleBindings        listerrbac.RoleBindingLister
+	clusterRoleBindings listerrbac.ClusterRoleBindingLister
+}
+
+// NewInformerRBACSource constructs an RBACSource which reads RBAC objects from the shared informer cache of the
+// given factory. The informers are registered with the factory by this call, therefore the caller is responsible
+// for starting the factory and waiting for the caches to sync afterwards.
+func NewInformerRBACSource(factory informers.SharedInformerFactory) RBACSource {
+	rbacInformers := factory.Rbac().V1()
+	return &informerRBACSource{
+		roles:               rbacInformers.Roles().Lister(),
+		clusterRoles:        rbacInformers.ClusterRoles().Lister(),
+		roleBindings:        rbacInformers.RoleBindings().Lister(),
+		clusterRoleBindings: rbacInformers.ClusterRoleBindings().Lister(),
+	}
+}
+
+func (s *informerRBACSource) ListRoles(namespace string, selector labels.Selector) ([]rbac.Role, error) {
+	var items []*rbac.Role
+	var err error
+	if namespace == core.NamespaceAll {
+		items, err = s.roles.List(selector)
+	} else {
+		items, err = s.roles.Roles(namespace).List(selector)
+	}
+	if err != nil {
+		return nil, err
+	}
+	roles := make([]rbac.Role, 0, len(items))
+	for _, item := range items {
+		roles = append(roles, *item.DeepCopy())
+	}
+	return roles, nil
+}
+
+func (s *informerRBACSource) ListClusterRoles(selector labels.Selector) ([]rbac.ClusterRole, error) {
+	items, err := s.clusterRoles.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	clusterRoles := make([]rbac.ClusterRole, 0, len(items))
+	for _, item := range items {
+		clusterRoles = append(clusterRoles, *item.DeepCopy())
+	}
+	return clusterRoles, nil
+}
+
+func (s *informerRBACSource) ListRoleBindings(namespace string, selector labels.Selector) ([]rbac.RoleBinding, error) {
+	var items []*rbac.RoleBinding
+	var err error
+	if namespace == core.NamespaceAll {
+		items, err = s.roleBindings.List(selector)
+	} else {
+		items, err = s.roleBindings.RoleBindings(namespace).List(selector)
+	}
+	if err != nil {
+		return nil, err
+	}
+	roleBindings := make([]rbac.RoleBinding, 0, len(items))
+	for _, item := range items {
+		roleBindings = append(roleBindings, *item.DeepCopy())
+	}
+	return roleBindings, nil
+}
+
+func (s *informerRBACSource) ListClusterRoleBindings(selector labels.Selector) ([]rbac.ClusterRoleBinding, error) {
+	items, err := s.clusterRoleBindings.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	clusterRoleBindings := make([]rbac.ClusterRoleBinding, 0, len(items))
+	for _, item := range items {
+		clusterRoleBindings = append(clusterRoleBindings, *item.DeepCopy())
+	}
+	return clusterRoleBindings, nil
+}
+
+type staticRBACSource struct {
+	roles               []rbac.Role
+	clusterRoles        []rbac.ClusterRole
+	roleBindings        []rbac.RoleBinding
+	clusterRoleBindings []rbac.ClusterRoleBinding
+}
+
+// NewStaticRBACSource constructs an RBACSource which serves a fixed set of RBAC objects.
+// Each object must be a *Role, *ClusterRole, *RoleBinding or *ClusterRoleBinding.
+func NewStaticRBACSource(objects ...runtime.Object) (RBACSource, error) {
+	s := &staticRBACSource{}
+	for _, object := range objects {
+		switch o := object.(type) {
+		case *rbac.Role:
+			s.roles = append(s.roles, *o)
+		case *rbac.ClusterRole:
+			s.clusterRoles = append(s.clusterRoles, *o)
+		case *rbac.RoleBinding:
+			s.roleBindings = append(s.roleBindings, *o)
+		case *rbac.ClusterRoleBinding:
+			s.clusterRoleBindings = append(s.clusterRoleBindings, *o)
+		default:
+			return nil, fmt.Errorf("unsupported object type: %T", object)
+		}
+	}
+	return s, nil
+}
+
+func (s *staticRBACSource) ListRoles(namespace string, selector labels.Selector) ([]rbac.Role, error) {
+	var roles []rbac.Role
+	for _, role := range s.roles {
+		if (namespace == core.NamespaceAll || role.Namespace == namespace) && selector.Matches(labels.Set(role.Labels)) {
+			roles = append(roles, role)
+		}
+	}
+	return roles, nil
+}
+
+func (s *staticRBACSource) ListClusterRoles(selector labels.Selector) ([]rbac.ClusterRole, error) {
+	var clusterRoles []rbac.ClusterRole
+	for _, clusterRole := range s.clusterRoles {
+		if selector.Matches(labels.Set(clusterRole.Labels)) {
+			clusterRoles = append(clusterRoles, clusterRole)
+		}
+	}
+	return clusterRoles, nil
+}
+
+func (s *staticRBACSource) ListRoleBindings(namespace string, selector labels.Selector) ([]rbac.RoleBinding, error) {
+	var roleBindings []rbac.RoleBinding
+	for _, roleBinding := range s.roleBindings {
+		if (namespace == core.NamespaceAll || roleBinding.Namespace == namespace) && selector.Matches(labels.Set(roleBinding.Labels)) {
+			roleBindings = append(roleBindings, roleBinding)
+		}
+	}
+	return roleBindings, nil
+}
+
+func (s *staticRBACSource) ListClusterRoleBindings(selector labels.Selector) ([]rbac.ClusterRoleBinding, error) {
+	var clusterRoleBindings []rbac.ClusterRoleBinding
+	for _, clusterRoleBinding := range s.clusterRoleBindings {
+		if selector.Matches(labels.Set(clusterRoleBinding.Labels)) {
+			clusterRoleBindings = append(clusterRoleBindings, clusterRoleBinding)
+		}
+	}
+	return clusterRoleBindings, nil
+}
+
+// NewSnapshotRBACSource reads the ClusterRoles and ClusterRoleBindings, as well as the Roles and RoleBindings defined
+// in the specified namespaces, from the given RBACSource once and returns an RBACSource serving them. RoleBindings are
+// not read for the "" namespace, since they are not checked across all namespaces.
+func NewSnapshotRBACSource(source RBACSource, namespaces []string) (RBACSource, error) {
+	s := &staticRBACSource{}
+
+	var err error
+	if s.clusterRoles, err = source.ListClusterRoles(labels.Everything()); err != nil {
+		return nil, fmt.Errorf("listing ClusterRoles: %v", err)
+	}
+	if s.clusterRoleBindings, err = source.ListClusterRoleBindings(labels.Everything()); err != nil {
+		return nil, fmt.Errorf("listing ClusterRoleBindings: %v", err)
+	}
+	for _, namespace := range namespaces {
+		roles, err := source.ListRoles(namespace, labels.Everything())
+		if err != nil {
+			return nil, fmt.Errorf("listing Roles: %v", err)
+		}
+		s.roles = append(s.roles, roles...)
+
+		if namespace == core.NamespaceAll {
+			continue
+		}
+		roleBindings, err := source.ListRoleBindings(namespace, labels.Everything())
+		if err != nil {
+			return nil, fmt.Errorf("listing RoleBindings: %v", err)
+		}
+		s.roleBindings = append(s.roleBindings, roleBindings...)
+	}
+	return s, nil
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var (
+	viewPodsFooRole = &rbac.Role{
+		ObjectMeta: metav1.ObjectMeta{Name: "view-pods", Namespace: "foo"},
+	}
+	viewPodsBarRole = &rbac.Role{
+		ObjectMeta: metav1.ObjectMeta{Name: "view-pods", Namespace: "bar", Labels: map[string]string{"team": "payments"}},
+	}
+	viewClusterRole = &rbac.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "view"},
+	}
+	viewPodsFooBinding = &rbac.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "view-pods-bnd", Namespace: "foo"},
+		RoleRef:    rbac.RoleRef{Kind: RoleKind, Name: "view-pods"},
+	}
+	viewClusterBinding = &rbac.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "view-bnd", Labels: map[string]string{"team": "payments"}},
+		RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "view"},
+	}
+)
+
+// assertRBACSource asserts that the given RBACSource serves the objects defined above.
+func assertRBACSource(t *testing.T, source RBACSource) {
+	t.Helper()
+
+	everything := labels.Everything()
+	payments := labels.SelectorFromSet(labels.Set{"team": "payments"})
+
+	roles, err := source.ListRoles("foo", everything)
+	require.NoError(t, err)
+	assert.Equal(t, []rbac.Role{*viewPodsFooRole}, roles)
+
+	roles, err = source.ListRoles(core.NamespaceAll, everything)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []rbac.Role{*viewPodsFooRole, *viewPodsBarRole}, roles)
+
+	roles, err = source.ListRoles(core.NamespaceAll, payments)
+	require.NoError(t, err)
+	assert.Equal(t, []rbac.Role{*viewPodsBarRole}, roles)
+
+	clusterRoles, err := source.ListClusterRoles(everything)
+	require.NoError(t, err)
+	assert.Equal(t, []rbac.ClusterRole{*viewClusterRole}, clusterRoles)
+
+	clusterRoles, err = source.ListClusterRoles(payments)
+	require.NoError(t, err)
+	assert.Empty(t, clusterRoles)
+
+	roleBindings, err := source.ListRoleBindings("foo", everything)
+	require.NoError(t, err)
+	assert.Equal(t, []rbac.RoleBinding{*viewPodsFooBinding}, roleBindings)
+
+	roleBindings, err = source.ListRoleBindings("bar", everything)
+	require.NoError(t, err)
+	assert.Empty(t, roleBindings)
+
+	roleBindings, err = source.ListRoleBindings("foo", payments)
+	require.NoError(t, err)
+	assert.Empty(t, roleBindings)
+
+	clusterRoleBindings, err := source.ListClusterRoleBindings(everything)
+	require.NoError(t, err)
+	assert.Equal(t, []rbac.ClusterRoleBinding{*viewClusterBinding}, clusterRoleBindings)
+
+	clusterRoleBindings, err = source.ListClusterRoleBindings(payments)
+	require.NoError(t, err)
+	assert.Equal(t, []rbac.ClusterRoleBinding{*viewClusterBinding}, clusterRoleBindings)
+}
+
+func TestClientRBACSource(t *testing.T) {
+	client := fake.NewSimpleClientset(viewPodsFooRole, viewPodsBarRole, viewClusterRole, viewPodsFooBinding, viewClusterBinding)
+
+	assertRBACSource(t, NewClientRBACSource(client.RbacV1()))
+}
+
+func TestInformerRBACSource(t *testing.T) {
+	client := fake.NewSimpleClientset(viewPodsFooRole, viewPodsBarRole, viewClusterRole, viewPodsFooBinding, viewClusterBinding)
+	factory := informers.NewSharedInformerFactory(client, time.Minute)
+	source := NewInformerRBACSource(factory)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	factory.Start(stopCh)
+	for informer, synced := range factory.WaitForCacheSync(stopCh) {
+		require.True(t, synced, "cache not synced for %v", informer)
+	}
+
+	assertRBACSource(t, source)
+}
+
+func TestStaticRBACSource(t *testing.T) {
+	t.Run("Should serve the given objects", func(t *testing.T) {
+		source, err := NewStaticRBACSource(viewPodsFooRole, viewPodsBarRole, viewClusterRole, viewPodsFooBinding, viewClusterBinding)
+		require.NoError(t, err)
+
+		assertRBACSource(t, source)
+	})
+
+	t.Run("Should return error when object type is not supported", func(t *testing.T) {
+		_, err := NewStaticRBACSource(&core.Namespace{})
+		assert.Equal(t, errors.New("unsupported object type: *v1.Namespace"), err)
+	})
+}
+
+func TestSnapshotRBACSource(t *testing.T) {
+	client := fake.NewSimpleClientset(viewPodsFooRole, viewPodsBarRole, viewClusterRole, viewPodsFooBinding, viewClusterBinding)
+
+	t.Run("Should serve the objects read once from the given source", func(t *testing.T) {
+		client.ClearActions()
+		source, err := NewSnapshotRBACSource(NewClientRBACSource(client.RbacV1()), []string{"foo", "bar"})
+		require.NoError(t, err)
+
+		assertRBACSource(t, source)
+		assert.Len(t, client.Actions(), 6)
+	})
+
+	t.Run("Should not read RoleBindings across all namespaces", func(t *testing.T) {
+		source, err := NewSnapshotRBACSource(NewClientRBACSource(client.RbacV1()), []string{core.NamespaceAll})
+		require.NoError(t, err)
+
+		roles, err := source.ListRoles(core.NamespaceAll, labels.Everything())
+		require.NoError(t, err)
+		assert.Len(t, roles, 2)
+		roleBindings, err := source.ListRoleBindings(core.NamespaceAll, labels.Everything())
+		require.NoError(t, err)
+		assert.Empty(t, roleBindings)
+	})
+}
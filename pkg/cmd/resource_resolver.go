@@ -13,10 +13,13 @@ import (
 
 // ResourceResolver wraps the Resolve method.
 //
-// Resolve attempts to resolve a GroupResource by `resource` and `subResource`.
-// It also validates that the specified `verb` is supported by the resolved resource.
+// Resolve attempts to resolve the GroupResources by `resource` and `subResource`, i.e. the preferred one, followed by
+// the ones of the other API groups serving a resource which is not qualified by an API group if `allGroups` is true.
+// It also validates that the specified `verb` is supported by the resolved resources, and returns warnings about the
+// API groups which are skipped since their resources cannot be discovered, or which are not checked since `allGroups`
+// is false.
 type ResourceResolver interface {
-	Resolve(verb, resource, subResource string) (schema.GroupResource, error)
+	Resolve(verb, resource, subResource string, allGroups bool) ([]schema.GroupResource, []string, error)
 }
 
 type resourceResolver struct {
@@ -24,7 +27,9 @@ type resourceResolver struct {
 	mapper meta.RESTMapper
 }
 
-// NewResourceResolver constructs the default ResourceResolver.
+// NewResourceResolver constructs the default ResourceResolver. Given a discovery.CachedDiscoveryInterface, such as the
+// one of kubectl caching API resources on disk, the API server is not queried on every run. An API group whose
+// resources cannot be discovered, e.g. an aggregated API which is down, is skipped and reported as a warning.
 func NewResourceResolver(client discovery.DiscoveryInterface, mapper meta.RESTMapper) ResourceResolver {
 	return &resourceResolver{
 		client: client,
@@ -32,9 +37,9 @@ func NewResourceResolver(client discovery.DiscoveryInterface, mapper meta.RESTMa
 	}
 }
 
-func (rv *resourceResolver) Resolve(verb, resource, subResource string) (schema.GroupResource, error) {
-	if resource == rbac.ResourceAll {
-		return schema.GroupResource{Resource: resource}, nil
+func (rv *resourceResolver) Resolve(verb, resource, subResource string, allGroups bool) ([]schema.GroupResource, []string, error) {
+	if isResourceAll(resource) {
+		return []schema.GroupResource{schema.ParseGroupResource(resource)}, nil, nil
 	}
 
 	name := resource
@@ -42,23 +47,100 @@ func (rv *resourceResolver) Resolve(verb, resource, subResource string) (schema.
 		name = name + "/" + subResource
 	}
 
-	gvr, err := rv.resolveGVR(resource)
+	gvrs, others, err := rv.resolveGVRs(resource, allGroups)
 	if err != nil {
 		klog.V(3).Infof("Error while resolving GVR for resource %s: %v", resource, err)
-		return schema.GroupResource{}, fmt.Errorf("the server doesn't have a resource type \"%s\"", name)
+		if meta.IsNoMatchError(err) {
+			return nil, nil, invalidActionError{fmt.Errorf("the server doesn't have a resource type \"%s\"", name)}
+		}
+		return nil, nil, fmt.Errorf("discovering resource type \"%s\": %v", name, err)
+	}
+
+	var grs []schema.GroupResource
+	var warnings []string
+	if len(others) > 0 {
+		warnings = append(warnings, otherGroupsWarning(name, others))
+	}
+	var discoveryErr error
+	for i, gvr := range gvrs {
+		index, err := rv.indexResources(gvr)
+		if err != nil {
+			// An aggregated API which is down should not prevent checking the API groups which are served.
+			warnings = append(warnings, fmt.Sprintf("Skipping the API group %s of the resource %s: %v", gvr.GroupVersion(), name, err))
+			discoveryErr = err
+			continue
+		}
+
+		apiResource, err := rv.resolveAPIResource(index, gvr, subResource)
+		if err != nil {
+			klog.V(3).Infof("Error while resolving APIResource for GVR %v and subResource %s: %v", gvr, subResource, err)
+			if i == 0 {
+				return nil, nil, invalidActionError{fmt.Errorf("the server doesn't have a resource type \"%s\"", name)}
+			}
+			continue
+		}
+
+		if !rv.isVerbSupportedBy(verb, apiResource) {
+			if i == 0 {
+				return nil, nil, invalidActionError{fmt.Errorf("the \"%s\" resource does not support the \"%s\" verb, only %v", apiResource.Name, verb, apiResource.Verbs)}
+			}
+			continue
+		}
+
+		grs = append(grs, gvr.GroupResource())
+	}
+
+	if len(grs) == 0 {
+		return nil, nil, fmt.Errorf("discovering resource type \"%s\": %v", name, discoveryErr)
+	}
+	return grs, warnings, nil
+}
+
+// isResourceAll returns true if the given resource stands for all resources, optionally qualified by an API group,
+// e.g. `*` or `*.apps`.
+func isResourceAll(resource string) bool {
+	return resource == rbac.ResourceAll || strings.HasPrefix(resource, rbac.ResourceAll+".")
+}
+
+// resolveGVRs returns the preferred GroupVersionResource of the given resource, followed by one GroupVersionResource
+// for each other API group serving it if allGroups is true and the resource is not qualified by an API group.
+// Otherwise, the other API groups serving the resource are returned separately so that they can be reported.
+func (rv *resourceResolver) resolveGVRs(resource string, allGroups bool) ([]schema.GroupVersionResource, []string, error) {
+	preferred, err := rv.resolveGVR(resource)
+	if err != nil {
+		return nil, nil, err
 	}
+	gvrs := []schema.GroupVersionResource{preferred}
 
-	apiResource, err := rv.resolveAPIResource(gvr, subResource)
+	if _, groupResource := schema.ParseResourceArg(strings.ToLower(resource)); groupResource.Group != "" {
+		return gvrs, nil, nil
+	}
+	all, err := rv.mapper.ResourcesFor(schema.GroupVersionResource{Resource: preferred.Resource})
 	if err != nil {
-		klog.V(3).Infof("Error while resolving APIResource for GVR %v and subResource %s: %v", gvr, subResource, err)
-		return schema.GroupResource{}, fmt.Errorf("the server doesn't have a resource type \"%s\"", name)
+		klog.V(3).Infof("Error while listing API groups serving resource %s: %v", preferred.Resource, err)
+		return gvrs, nil, nil
 	}
 
-	if !rv.isVerbSupportedBy(verb, apiResource) {
-		return schema.GroupResource{}, fmt.Errorf("the \"%s\" resource does not support the \"%s\" verb, only %v", apiResource.Name, verb, apiResource.Verbs)
+	var others []string
+	groups := map[string]bool{preferred.Group: true}
+	for _, gvr := range all {
+		if !groups[gvr.Group] {
+			groups[gvr.Group] = true
+			gvrs = append(gvrs, gvr)
+			others = append(others, gvr.Group)
+		}
+	}
+	if !allGroups {
+		return gvrs[:1], others, nil
 	}
+	return gvrs, nil, nil
+}
 
-	return gvr.GroupResource(), nil
+// otherGroupsWarning returns the warning about the other API groups serving the given resource, which are not checked
+// unless all API groups are requested.
+func otherGroupsWarning(resource string, groups []string) string {
+	return fmt.Sprintf("The resource %s is also served by the API group(s) %s, which are only checked with --%s",
+		resource, strings.Join(groups, ", "), allAPIGroupsFlag)
 }
 
 func (rv *resourceResolver) resolveGVR(resource string) (schema.GroupVersionResource, error) {
@@ -83,12 +165,7 @@ func (rv *resourceResolver) resolveGVR(resource string) (schema.GroupVersionReso
 	return gvr, nil
 }
 
-func (rv *resourceResolver) resolveAPIResource(gvr schema.GroupVersionResource, subResource string) (apismeta.APIResource, error) {
-	index, err := rv.indexResources(gvr)
-	if err != nil {
-		return apismeta.APIResource{}, err
-	}
-
+func (rv *resourceResolver) resolveAPIResource(index map[string]apismeta.APIResource, gvr schema.GroupVersionResource, subResource string) (apismeta.APIResource, error) {
 	apiResource, err := rv.lookupResource(index, gvr.Resource)
 	if err != nil {
 		return apismeta.APIResource{}, err
@@ -154,3 +231,32 @@ func (rv *resourceResolver) isVerbSupportedBy(verb string, resource apismeta.API
 	}
 	return supported
 }
+
+type offlineResourceResolver struct {
+}
+
+// NewOfflineResourceResolver constructs a ResourceResolver which works without access to the API server.
+// The standard resources are resolved by their plural or singular name or shortcut, optionally qualified by the API
+// group, e.g. `deploy` or `deployments.apps`, from a built-in catalogue. Any other resource, e.g. a custom resource,
+// must be specified by its plural name qualified by the API group. The verb and subresource are not validated.
+func NewOfflineResourceResolver() ResourceResolver {
+	return &offlineResourceResolver{}
+}
+
+func (rv *offlineResourceResolver) Resolve(_, resource, _ string, allGroups bool) ([]schema.GroupResource, []string, error) {
+	if isResourceAll(resource) {
+		return []schema.GroupResource{schema.ParseGroupResource(resource)}, nil, nil
+	}
+	gr := schema.ParseGroupResource(strings.ToLower(resource))
+	if grs := lookupStandardResource(gr); len(grs) > 0 {
+		if !allGroups && len(grs) > 1 {
+			var others []string
+			for _, gr := range grs[1:] {
+				others = append(others, gr.Group)
+			}
+			return grs[:1], []string{otherGroupsWarning(grs[0].Resource, others)}, nil
+		}
+		return grs, nil, nil
+	}
+	return []schema.GroupResource{gr}, nil, nil
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	rbac "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	apismeta "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -96,7 +97,7 @@ func TestResourceResolver_Resolve(t *testing.T) {
 				argGVR:    schema.GroupVersionResource{Resource: "pods"},
 				returnGVR: podsGVR,
 			},
-			expectedError: errors.New("the \"pods\" resource does not support the \"eat\" verb, only [list create delete]"),
+			expectedError: invalidActionError{errors.New("the \"pods\" resource does not support the \"eat\" verb, only [list create delete]")},
 		},
 		{
 			name:   "D",
@@ -123,16 +124,25 @@ func TestResourceResolver_Resolve(t *testing.T) {
 				argGVR:    schema.GroupVersionResource{Resource: "pods"},
 				returnGVR: podsGVR,
 			},
-			expectedError: errors.New("the server doesn't have a resource type \"pods/logz\""),
+			expectedError: invalidActionError{errors.New("the server doesn't have a resource type \"pods/logz\"")},
 		},
 		{
 			name:   "G",
 			action: Action{Verb: "list", Resource: "bees"},
+			mappingResult: &mappingResult{
+				argGVR:      schema.GroupVersionResource{Resource: "bees"},
+				returnError: &meta.NoResourceMatchError{PartialResource: schema.GroupVersionResource{Resource: "bees"}},
+			},
+			expectedError: invalidActionError{errors.New("the server doesn't have a resource type \"bees\"")},
+		},
+		{
+			name:   "Should return error when resources cannot be discovered",
+			action: Action{Verb: "list", Resource: "bees"},
 			mappingResult: &mappingResult{
 				argGVR:      schema.GroupVersionResource{Resource: "bees"},
 				returnError: errors.New("mapping failed"),
 			},
-			expectedError: errors.New("the server doesn't have a resource type \"bees\""),
+			expectedError: errors.New("discovering resource type \"bees\": mapping failed"),
 		},
 		{
 			name:   "H",
@@ -164,7 +174,7 @@ func TestResourceResolver_Resolve(t *testing.T) {
 				argGVR:    schema.GroupVersionResource{Resource: "psp"},
 				returnGVR: pspGVR,
 			},
-			expectedError: errors.New("the \"podsecuritypolicies\" resource does not support the \"cook\" verb, only [list get]"),
+			expectedError: invalidActionError{errors.New("the \"podsecuritypolicies\" resource does not support the \"cook\" verb, only [list get]")},
 		},
 	}
 
@@ -179,12 +189,159 @@ func TestResourceResolver_Resolve(t *testing.T) {
 
 			resolver := NewResourceResolver(client.Discovery(), mapper)
 
-			resource, err := resolver.Resolve(tc.action.Verb, tc.action.Resource, tc.action.SubResource)
+			resource, warnings, err := resolver.Resolve(tc.action.Verb, tc.action.Resource, tc.action.SubResource, false)
 
 			assert.Equal(t, tc.expectedError, err)
-			assert.Equal(t, tc.expectedGR, resource)
+			assert.Empty(t, warnings)
+			if tc.expectedError == nil {
+				assert.Equal(t, []schema.GroupResource{tc.expectedGR}, resource)
+			} else {
+				assert.Nil(t, resource)
+			}
 
 			mapper.AssertExpectations(t)
 		})
 	}
 }
+
+func TestOfflineResourceResolver_Resolve(t *testing.T) {
+	data := []struct {
+		resource         string
+		allGroups        bool
+		expected         []schema.GroupResource
+		expectedWarnings []string
+	}{
+		{resource: "pods", expected: []schema.GroupResource{{Resource: "pods"}}},
+		{resource: "po", expected: []schema.GroupResource{{Resource: "pods"}}},
+		{resource: "Deployments.apps", expected: []schema.GroupResource{{Group: "apps", Resource: "deployments"}}},
+		{resource: "deploy", expected: []schema.GroupResource{{Group: "apps", Resource: "deployments"}}},
+		{resource: "Deployment", expected: []schema.GroupResource{{Group: "apps", Resource: "deployments"}}},
+		{resource: "events", expected: []schema.GroupResource{{Resource: "events"}},
+			expectedWarnings: []string{"The resource events is also served by the API group(s) events.k8s.io, which are only checked with --all-api-groups"}},
+		{resource: "events", allGroups: true, expected: []schema.GroupResource{{Resource: "events"}, {Group: "events.k8s.io", Resource: "events"}}},
+		{resource: "pods.metrics.k8s.io", expected: []schema.GroupResource{{Group: "metrics.k8s.io", Resource: "pods"}}},
+		{resource: "certificates.cert-manager.io", expected: []schema.GroupResource{{Group: "cert-manager.io", Resource: "certificates"}}},
+		{resource: "*", expected: []schema.GroupResource{{Resource: "*"}}},
+		{resource: "*.apps", expected: []schema.GroupResource{{Group: "apps", Resource: "*"}}},
+	}
+
+	resolver := NewOfflineResourceResolver()
+	for _, tt := range data {
+		t.Run(tt.resource, func(t *testing.T) {
+			grs, warnings, err := resolver.Resolve("get", tt.resource, "", tt.allGroups)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedWarnings, warnings)
+			assert.Equal(t, tt.expected, grs)
+		})
+	}
+}
+
+func TestResourceResolver_Resolve_ServedByMoreGroups(t *testing.T) {
+	// given
+	client := fake.NewSimpleClientset()
+	client.Resources = []*apismeta.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []apismeta.APIResource{
+				{Name: "events", Verbs: []string{"list", "create"}},
+			},
+		},
+		{
+			GroupVersion: "events.k8s.io/v1",
+			APIResources: []apismeta.APIResource{
+				{Name: "events", Verbs: []string{"list"}},
+			},
+		},
+	}
+
+	coreGV := schema.GroupVersion{Version: "v1"}
+	eventsGV := schema.GroupVersion{Group: "events.k8s.io", Version: "v1"}
+	defaultMapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{coreGV, eventsGV})
+	defaultMapper.Add(coreGV.WithKind("Event"), meta.RESTScopeNamespace)
+	defaultMapper.Add(eventsGV.WithKind("Event"), meta.RESTScopeNamespace)
+	mapper := meta.PriorityRESTMapper{
+		Delegate:         defaultMapper,
+		ResourcePriority: []schema.GroupVersionResource{{Group: "", Version: meta.AnyVersion, Resource: meta.AnyResource}},
+	}
+
+	resolver := NewResourceResolver(client.Discovery(), mapper)
+
+	// when
+	grs, warnings, err := resolver.Resolve("list", "events", "", false)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []schema.GroupResource{{Resource: "events"}}, grs)
+	assert.Equal(t, []string{"The resource events is also served by the API group(s) events.k8s.io, which are only checked with --all-api-groups"}, warnings)
+
+	// when all API groups are requested
+	grs, warnings, err = resolver.Resolve("list", "events", "", true)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []schema.GroupResource{{Resource: "events"}, {Group: "events.k8s.io", Resource: "events"}}, grs)
+	assert.Empty(t, warnings)
+
+	// when the verb is not supported by each group
+	grs, _, err = resolver.Resolve("create", "events", "", true)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []schema.GroupResource{{Resource: "events"}}, grs)
+
+	// when the resource is qualified by an API group
+	grs, _, err = resolver.Resolve("list", "events.events.k8s.io", "", true)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []schema.GroupResource{{Group: "events.k8s.io", Resource: "events"}}, grs)
+
+	// when all resources of an API group are specified
+	grs, _, err = resolver.Resolve("delete", "*.apps", "", false)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []schema.GroupResource{{Group: "apps", Resource: "*"}}, grs)
+}
+
+func TestResourceResolver_Resolve_SkipsFailingGroups(t *testing.T) {
+	// given the metrics API is not served
+	client := fake.NewSimpleClientset()
+	client.Resources = []*apismeta.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []apismeta.APIResource{
+				{Name: "pods", Verbs: []string{"list"}},
+			},
+		},
+	}
+
+	coreGV := schema.GroupVersion{Version: "v1"}
+	metricsGV := schema.GroupVersion{Group: "metrics.k8s.io", Version: "v1beta1"}
+	defaultMapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{coreGV, metricsGV})
+	defaultMapper.Add(coreGV.WithKind("Pod"), meta.RESTScopeNamespace)
+	defaultMapper.Add(metricsGV.WithKind("Pod"), meta.RESTScopeNamespace)
+	mapper := meta.PriorityRESTMapper{
+		Delegate:         defaultMapper,
+		ResourcePriority: []schema.GroupVersionResource{{Group: "", Version: meta.AnyVersion, Resource: meta.AnyResource}},
+	}
+
+	resolver := NewResourceResolver(client.Discovery(), mapper)
+
+	// when
+	grs, warnings, err := resolver.Resolve("list", "pods", "", true)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []schema.GroupResource{{Resource: "pods"}}, grs)
+	assert.Equal(t, []string{"Skipping the API group metrics.k8s.io/v1beta1 of the resource pods: getting API groups: the server could not find the requested resource, GroupVersion \"metrics.k8s.io/v1beta1\" not found"}, warnings)
+
+	// when only the failing group is specified
+	grs, warnings, err = resolver.Resolve("list", "pods.metrics.k8s.io", "", false)
+
+	// then
+	assert.EqualError(t, err, "discovering resource type \"pods.metrics.k8s.io\": getting API groups: the server could not find the requested resource, GroupVersion \"metrics.k8s.io/v1beta1\" not found")
+	assert.False(t, isInvalidAction(err))
+	assert.Nil(t, grs)
+	assert.Nil(t, warnings)
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+
+	rbac "k8s.io/api/rbac/v1"
+)
+
+// RoleRules holds the PolicyRules of Roles and ClusterRoles which match an Action.
+type RoleRules struct {
+	roles        map[string][]rbac.PolicyRule
+	clusterRoles map[string][]rbac.PolicyRule
+}
+
+// For returns the matching PolicyRules of the role referenced by a binding defined in the specified namespace.
+// Specify "" as namespace for ClusterRoleBindings.
+func (r RoleRules) For(namespace string, roleRef rbac.RoleRef) []rbac.PolicyRule {
+	if roleRef.Kind == RoleKind {
+		return r.roles[namespace+"/"+roleRef.Name]
+	}
+	return r.clusterRoles[roleRef.Name]
+}
+
+// MatchingRules returns the PolicyRules of Roles and ClusterRoles which match the specified Action.
+// It complements Check by explaining why each returned binding allows the Action.
+func (w *WhoCan) MatchingRules(action Action) (RoleRules, error) {
+	resolved, err := w.resolve(action)
+	if err != nil {
+		return RoleRules{}, err
+	}
+
+	roles, err := w.rbacSource.ListRoles(action.Namespace, resolved.roles())
+	if err != nil {
+		return RoleRules{}, fmt.Errorf("getting Roles: %v", err)
+	}
+	clusterRoles, err := w.rbacSource.ListClusterRoles(resolved.roles())
+	if err != nil {
+		return RoleRules{}, fmt.Errorf("getting ClusterRoles: %v", err)
+	}
+
+	rules := RoleRules{
+		roles:        make(map[string][]rbac.PolicyRule),
+		clusterRoles: make(map[string][]rbac.PolicyRule),
+	}
+	for _, role := range roles {
+		if matching := w.policyRuleMatcher.MatchingRules(role.Rules, resolved); len(matching) > 0 {
+			rules.roles[role.Namespace+"/"+role.Name] = matching
+		}
+	}
+	for _, clusterRole := range clusterRoles {
+		if matching := w.policyRuleMatcher.MatchingRules(clusterRole.Rules, resolved); len(matching) > 0 {
+			rules.clusterRoles[clusterRole.Name] = matching
+		}
+	}
+
+	return rules, nil
+}
+
+// isWildcardRule returns `true` if the given PolicyRule grants any verb, resource or API group, `false` otherwise.
+func isWildcardRule(rule rbac.PolicyRule) bool {
+	for _, verb := range rule.Verbs {
+		if verb == rbac.VerbAll {
+			return true
+		}
+	}
+	for _, resource := range rule.Resources {
+		if resource == rbac.ResourceAll {
+			return true
+		}
+	}
+	for _, group := range rule.APIGroups {
+		if group == rbac.APIGroupAll {
+			return true
+		}
+	}
+	for _, url := range rule.NonResourceURLs {
+		if url == rbac.NonResourceAll {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestWhoCan_MatchingRules(t *testing.T) {
+	// given
+	getSecrets := rbac.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}}
+	listPods := rbac.PolicyRule{Verbs: []string{"list"}, APIGroups: []string{""}, Resources: []string{"pods"}}
+	all := rbac.PolicyRule{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}}
+
+	source, err := NewStaticRBACSource(
+		&rbac.Role{ObjectMeta: metav1.ObjectMeta{Name: "reader", Namespace: "foo"}, Rules: []rbac.PolicyRule{getSecrets, listPods}},
+		&rbac.Role{ObjectMeta: metav1.ObjectMeta{Name: "pod-lister", Namespace: "foo"}, Rules: []rbac.PolicyRule{listPods}},
+		&rbac.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin"}, Rules: []rbac.PolicyRule{all}},
+	)
+	require.NoError(t, err)
+
+	resourceResolver := new(resourceResolverMock)
+	resourceResolver.On("Resolve", "get", "secrets", "", false).Return([]schema.GroupResource{{Resource: "secrets"}}, nil, nil)
+
+	wc := &WhoCan{
+		rbacSource:        source,
+		resourceResolver:  resourceResolver,
+		policyRuleMatcher: NewPolicyRuleMatcher(),
+	}
+
+	// when
+	rules, err := wc.MatchingRules(Action{Verb: "get", Resource: "secrets", Namespace: "foo"})
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []rbac.PolicyRule{getSecrets}, rules.For("foo", rbac.RoleRef{Kind: RoleKind, Name: "reader"}))
+	assert.Empty(t, rules.For("foo", rbac.RoleRef{Kind: RoleKind, Name: "pod-lister"}))
+	assert.Empty(t, rules.For("bar", rbac.RoleRef{Kind: RoleKind, Name: "reader"}))
+	assert.Equal(t, []rbac.PolicyRule{all}, rules.For("", rbac.RoleRef{Kind: ClusterRoleKind, Name: "cluster-admin"}))
+}
+
+func TestIsWildcardRule(t *testing.T) {
+	assert.True(t, isWildcardRule(rbac.PolicyRule{Verbs: []string{"*"}, Resources: []string{"pods"}}))
+	assert.True(t, isWildcardRule(rbac.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{"*"}}))
+	assert.True(t, isWildcardRule(rbac.PolicyRule{Verbs: []string{"get"}, NonResourceURLs: []string{"*"}}))
+	assert.False(t, isWildcardRule(rbac.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}}))
+}
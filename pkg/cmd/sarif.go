@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	rbac "k8s.io/api/rbac/v1"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "kubectl-who-can"
+	toolURI      = "https://github.com/aquasecurity/kubectl-who-can"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string              `json:"id"`
+	ShortDescription     sarifMessage        `json:"shortDescription"`
+	DefaultConfiguration *sarifConfiguration `json:"defaultConfiguration,omitempty"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID           string             `json:"ruleId"`
+	Level            string             `json:"level"`
+	Message          sarifMessage       `json:"message"`
+	Locations        []sarifLocation    `json:"locations"`
+	RelatedLocations []sarifLocation    `json:"relatedLocations,omitempty"`
+	Suppressions     []sarifSuppression `json:"suppressions,omitempty"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+	Message          *sarifMessage          `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// sarifLocationOf returns the location of the object with the given kind, namespace and name. The physical location
+// is only set if the object was loaded from a manifest file.
+func sarifLocationOf(locator ObjectLocator, kind, namespace, name string) sarifLocation {
+	qualifiedName := name
+	if namespace != "" {
+		qualifiedName = namespace + "/" + name
+	}
+	location := sarifLocation{
+		LogicalLocations: []sarifLogicalLocation{
+			{Name: name, FullyQualifiedName: kind + "/" + qualifiedName, Kind: "object"},
+		},
+	}
+	if locator != nil {
+		if manifest, ok := locator.LocationOf(kind, namespace, name); ok {
+			location.PhysicalLocation = &sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(manifest.File)},
+				Region:           sarifRegion{StartLine: manifest.Line},
+			}
+		}
+	}
+	return location
+}
+
+// sarifRuleID returns the ID of the SARIF rule reported for the specified Action, e.g. `who-can:create:pods/exec`.
+func sarifRuleID(action Action) string {
+	target := action.NonResourceURL
+	if target == "" {
+		target = action.Resource
+		if action.SubResource != "" {
+			target += "/" + action.SubResource
+		}
+		if action.ResourceName != "" {
+			target += "/" + action.ResourceName
+		}
+	}
+	return "who-can:" + action.Verb + ":" + target
+}
+
+// subjectString returns a human readable representation of the given subject, e.g. `ServiceAccount foo/bar`.
+func subjectString(s rbac.Subject) string {
+	if s.Namespace != "" {
+		return s.Kind + " " + s.Namespace + "/" + s.Name
+	}
+	return s.Kind + " " + s.Name
+}
+
+// PrintSARIF prints the bindings which allow the Action as results of a SARIF log. If the given ObjectLocator is not
+// nil, the results point at the manifest files defining the bindings and their roles.
+func (p *Printer) PrintSARIF(action Action, roleBindings []rbac.RoleBinding, clusterRoleBindings []rbac.ClusterRoleBinding, rules RoleRules, locator ObjectLocator) {
+	ruleID := sarifRuleID(action)
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           toolName,
+			InformationURI: toolURI,
+			Rules: []sarifRule{
+				{ID: ruleID, ShortDescription: sarifMessage{Text: fmt.Sprintf("Subjects allowed to %s", action)}},
+			},
+		}},
+		Results: []sarifResult{},
+	}
+
+	type binding struct {
+		kind      string
+		namespace string
+		name      string
+		roleRef   rbac.RoleRef
+		subjects  []rbac.Subject
+	}
+	var bindings []binding
+	if action.NonResourceURL == "" {
+		for _, rb := range roleBindings {
+			bindings = append(bindings, binding{RoleBindingKind, rb.Namespace, rb.Name, rb.RoleRef, rb.Subjects})
+		}
+	}
+	for _, crb := range clusterRoleBindings {
+		bindings = append(bindings, binding{ClusterRoleBindingKind, "", crb.Name, crb.RoleRef, crb.Subjects})
+	}
+
+	for _, b := range bindings {
+		if len(b.subjects) == 0 {
+			continue
+		}
+		var subjects []string
+		for _, s := range b.subjects {
+			subjects = append(subjects, subjectString(s))
+		}
+		var ruleStrings []string
+		for _, rule := range rules.For(b.namespace, b.roleRef) {
+			ruleStrings = append(ruleStrings, ruleString(rule))
+		}
+
+		qualifiedName := b.name
+		if b.namespace != "" {
+			qualifiedName = b.namespace + "/" + b.name
+		}
+		roleNamespace := ""
+		if b.roleRef.Kind == RoleKind {
+			roleNamespace = b.namespace
+		}
+		roleLocation := sarifLocationOf(locator, b.roleRef.Kind, roleNamespace, b.roleRef.Name)
+		roleLocation.Message = &sarifMessage{Text: fmt.Sprintf("%s %s", b.roleRef.Kind, b.roleRef.Name)}
+
+		run.Results = append(run.Results, sarifResult{
+			RuleID: ruleID,
+			Level:  "warning",
+			Message: sarifMessage{Text: fmt.Sprintf("%s %s allows %s to %s through %s %s with rule(s): %s",
+				b.kind, qualifiedName, strings.Join(subjects, ", "), action, b.roleRef.Kind, b.roleRef.Name, strings.Join(ruleStrings, "; "))},
+			Locations:        []sarifLocation{sarifLocationOf(locator, b.kind, b.namespace, b.name)},
+			RelatedLocations: []sarifLocation{roleLocation},
+		})
+	}
+
+	p.printSARIF(run)
+}
+
+func (p *Printer) printSARIF(runs ...sarifRun) {
+	encoder := json.NewEncoder(p.out)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: runs})
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type locatorStub map[string]ManifestLocation
+
+func (l locatorStub) LocationOf(kind, namespace, name string) (ManifestLocation, bool) {
+	location, ok := l[objectKey(kind, namespace, name)]
+	return location, ok
+}
+
+func TestSarifRuleID(t *testing.T) {
+	data := []struct {
+		action   Action
+		expected string
+	}{
+		{action: Action{Verb: "get", Resource: "secrets"}, expected: "who-can:get:secrets"},
+		{action: Action{Verb: "create", Resource: "pods", SubResource: "exec"}, expected: "who-can:create:pods/exec"},
+		{action: Action{Verb: "get", Resource: "secrets", ResourceName: "db"}, expected: "who-can:get:secrets/db"},
+		{action: Action{Verb: "get", NonResourceURL: "/logs"}, expected: "who-can:get:/logs"},
+	}
+	for _, tt := range data {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, sarifRuleID(tt.action))
+		})
+	}
+}
+
+func TestPrinter_PrintSARIF(t *testing.T) {
+	// given
+	var buf bytes.Buffer
+	locator := locatorStub{
+		objectKey(RoleBindingKind, "bar", "admins"):                {File: "manifests/bar.yaml", Line: 12},
+		objectKey(ClusterRoleKind, "", "admin"):                    {File: "manifests/cluster.yaml", Line: 1},
+		objectKey(ClusterRoleBindingKind, "", "read-secrets"):      {File: "manifests/cluster.yaml", Line: 20},
+		objectKey(ClusterRoleKind, "", "read-secrets"):             {File: "manifests/cluster.yaml", Line: 9},
+		objectKey(RoleBindingKind, "unused", "unused-rolebinding"): {File: "manifests/unused.yaml", Line: 1},
+	}
+
+	// when
+	NewPrinter(&buf, false).PrintSARIF(graphAction, graphRoleBindings, graphClusterRoleBindings, graphRules, locator)
+
+	// then
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	assert.Equal(t, sarifVersion, log.Version)
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	assert.Equal(t, []sarifRule{
+		{ID: "who-can:get:secrets", ShortDescription: sarifMessage{Text: "Subjects allowed to get secrets"}},
+	}, run.Tool.Driver.Rules)
+	require.Len(t, run.Results, 3)
+
+	assert.Equal(t, sarifResult{
+		RuleID:  "who-can:get:secrets",
+		Level:   "warning",
+		Message: sarifMessage{Text: "RoleBinding foo/admins allows User alice, ServiceAccount foo/operator to get secrets through ClusterRole admin with rule(s): * *.*"},
+		Locations: []sarifLocation{{
+			LogicalLocations: []sarifLogicalLocation{{Name: "admins", FullyQualifiedName: "RoleBinding/foo/admins", Kind: "object"}},
+		}},
+		RelatedLocations: []sarifLocation{{
+			PhysicalLocation: &sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: "manifests/cluster.yaml"},
+				Region:           sarifRegion{StartLine: 1},
+			},
+			LogicalLocations: []sarifLogicalLocation{{Name: "admin", FullyQualifiedName: "ClusterRole/admin", Kind: "object"}},
+			Message:          &sarifMessage{Text: "ClusterRole admin"},
+		}},
+	}, run.Results[0])
+
+	assert.Equal(t, &sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: "manifests/bar.yaml"},
+		Region:           sarifRegion{StartLine: 12},
+	}, run.Results[1].Locations[0].PhysicalLocation)
+	assert.Equal(t, "ClusterRoleBinding read-secrets allows Group auditors to get secrets through ClusterRole read-secrets with rule(s): get,list secrets [db]",
+		run.Results[2].Message.Text)
+	assert.Equal(t, 20, run.Results[2].Locations[0].PhysicalLocation.Region.StartLine)
+}
+
+func TestPrinter_PrintSARIF_NoResults(t *testing.T) {
+	var buf bytes.Buffer
+	NewPrinter(&buf, false).PrintSARIF(graphAction, nil, nil, RoleRules{}, nil)
+
+	assert.Contains(t, buf.String(), `"results": []`)
+}
@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	clioptions "k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+const (
+	serveUsage = `serve [--listen ADDRESS]`
+	serveLong  = `Serves who-can queries over HTTP, backed by an informer cache of RBAC objects.
+
+The following endpoints are available:
+
+  GET /v1/whocan?verb=VERB&resource=TYPE[&name=NAME][&subresource=SUBRESOURCE][&namespace=NAMESPACE]
+  GET /v1/whocan?verb=VERB&nonResourceURL=NONRESOURCEURL
+      Returns the same JSON document as 'kubectl who-can VERB TYPE -o json'.
+      If the namespace parameter is omitted, RoleBindings in each existing namespace are checked.
+      Invalid parameters, e.g. an unknown resource, are answered with 400 and failures to check with 500.
+
+  GET /v1/subjects/{kind}/{namespace}/{name}/permissions
+      Returns the rules granted to the given User, Group or ServiceAccount by each binding.
+      Use '-' as the namespace of Users and Groups.`
+	serveExample = `  # Serve who-can queries on port 8080
+  kubectl who-can serve --listen :8080
+
+  # Query who can get secrets in the namespace "prod"
+  curl 'http://localhost:8080/v1/whocan?verb=get&resource=secrets&namespace=prod'
+
+  # Query the permissions of the service account "default" in the namespace "prod"
+  curl 'http://localhost:8080/v1/subjects/serviceaccount/prod/default/permissions'`
+)
+
+const (
+	listenFlag = "listen"
+
+	// noNamespace is the placeholder for the namespace of subjects which are not namespaced.
+	noNamespace = "-"
+
+	// serverReadHeaderTimeout and serverReadTimeout bound the time clients may take to send a request, so that slow
+	// clients cannot hold connections open.
+	serverReadHeaderTimeout = 10 * time.Second
+	serverReadTimeout       = 30 * time.Second
+)
+
+// Server exposes who-can queries as a REST API.
+type Server struct {
+	whoCan *WhoCan
+	mux    *http.ServeMux
+}
+
+// NewServer constructs a new Server answering queries with the specified WhoCan checker.
+func NewServer(whoCan *WhoCan) *Server {
+	s := &Server{
+		whoCan: whoCan,
+		mux:    http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/v1/whocan", s.handleWhoCan)
+	s.mux.HandleFunc("/v1/subjects/", s.handleSubjectPermissions)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleWhoCan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	action, err := actionFromQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	action, err = s.whoCan.expandAllNamespaces(action)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	roleBindings, clusterRoleBindings, err := s.whoCan.Check(action)
+	if err != nil {
+		writeError(w, checkErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	NewPrinter(w, false).ExportData(action, roleBindings, clusterRoleBindings)
+}
+
+func (s *Server) handleSubjectPermissions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	// /v1/subjects/{kind}/{namespace}/{name}/permissions
+	tokens := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/subjects/"), "/")
+	if len(tokens) != 4 || tokens[3] != "permissions" {
+		writeError(w, http.StatusNotFound, fmt.Errorf("not found: %s", r.URL.Path))
+		return
+	}
+
+	subject, err := subjectFrom(tokens[0], tokens[1], tokens[2])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	permissions, err := s.whoCan.PermissionsFor(subject)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, permissions)
+}
+
+// checkErrorStatus returns the HTTP status code of the given error returned by Check, i.e. 400 Bad Request if the
+// action is invalid, or 500 Internal Server Error if it could not be checked.
+func checkErrorStatus(err error) int {
+	if isInvalidAction(err) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// actionFromQuery builds the Action specified by the given query parameters.
+func actionFromQuery(query url.Values) (Action, error) {
+	action := Action{
+		Verb:           query.Get("verb"),
+		Resource:       query.Get("resource"),
+		ResourceName:   query.Get("name"),
+		SubResource:    query.Get("subresource"),
+		NonResourceURL: query.Get("nonResourceURL"),
+		Namespace:      query.Get("namespace"),
+	}
+	if action.Verb == "" {
+		return Action{}, errors.New("the verb parameter is required")
+	}
+	if (action.Resource == "") == (action.NonResourceURL == "") {
+		return Action{}, errors.New("exactly one of the resource or nonResourceURL parameters is required")
+	}
+	if !query.Has("namespace") {
+		action.AllNamespaces = true
+		action.Namespace = core.NamespaceAll
+	}
+	return action, nil
+}
+
+// subjectFrom builds the subject specified by the given path parameters.
+func subjectFrom(kind, namespace, name string) (rbac.Subject, error) {
+	var subject rbac.Subject
+	switch strings.ToLower(kind) {
+	case "user":
+		subject = rbac.Subject{Kind: rbac.UserKind, APIGroup: rbac.GroupName}
+	case "group":
+		subject = rbac.Subject{Kind: rbac.GroupKind, APIGroup: rbac.GroupName}
+	case "serviceaccount", "sa":
+		subject = rbac.Subject{Kind: rbac.ServiceAccountKind}
+	default:
+		return rbac.Subject{}, fmt.Errorf("invalid subject kind: %s", kind)
+	}
+	subject.Name = name
+	if subject.Kind == rbac.ServiceAccountKind {
+		if namespace == noNamespace {
+			return rbac.Subject{}, errors.New("the namespace of a ServiceAccount is required")
+		}
+		subject.Namespace = namespace
+	}
+	return subject, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		klog.V(2).Infof("Error while writing response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// newHTTPServer constructs an http.Server listening on the specified address with read timeouts.
+func newHTTPServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: serverReadHeaderTimeout,
+		ReadTimeout:       serverReadTimeout,
+	}
+}
+
+// NewServeCommand constructs the serve command with the specified ConfigFlags.
+func NewServeCommand(configFlags *clioptions.ConfigFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          serveUsage,
+		Short:        "Serve who-can queries over HTTP",
+		Long:         serveLong,
+		Example:      serveExample,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			listen, err := cmd.Flags().GetString(listenFlag)
+			if err != nil {
+				return err
+			}
+
+			restConfig, err := configFlags.ToRESTConfig()
+			if err != nil {
+				return fmt.Errorf("getting rest config: %v", err)
+			}
+
+			client, err := kubernetes.NewForConfig(restConfig)
+			if err != nil {
+				return err
+			}
+			factory := informers.NewSharedInformerFactory(client, 0)
+
+			o, err := NewWhoCan(configFlags, NewInformerRBACSource(factory))
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if err := startInformers(ctx, factory); err != nil {
+				return err
+			}
+
+			server := newHTTPServer(listen, NewServer(o))
+			go func() {
+				<-ctx.Done()
+				_ = server.Shutdown(context.Background())
+			}()
+
+			klog.V(1).Infof("Serving who-can queries on %s", listen)
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String(listenFlag, ":8080", "The address to serve HTTP requests on")
+
+	return cmd
+}
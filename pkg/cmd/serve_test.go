@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestServer(t *testing.T) {
+	// given
+	readSecretsRole := &rbac.Role{
+		ObjectMeta: metav1.ObjectMeta{Name: "read-secrets", Namespace: "prod"},
+		Rules: []rbac.PolicyRule{
+			{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}},
+		},
+	}
+	readSecretsBinding := &rbac.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "operator-can-read-secrets", Namespace: "prod"},
+		RoleRef:    rbac.RoleRef{Kind: RoleKind, Name: "read-secrets"},
+		Subjects:   []rbac.Subject{{Kind: rbac.ServiceAccountKind, Name: "operator", Namespace: "prod"}},
+	}
+	viewClusterRole := &rbac.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "view"},
+		Rules: []rbac.PolicyRule{
+			{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+		},
+	}
+	viewClusterBinding := &rbac.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "operator-can-view"},
+		RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "view"},
+		Subjects:   []rbac.Subject{{Kind: rbac.ServiceAccountKind, Name: "operator", Namespace: "prod"}},
+	}
+
+	client := fake.NewSimpleClientset(readSecretsRole, readSecretsBinding, viewClusterRole, viewClusterBinding)
+	factory := informers.NewSharedInformerFactory(client, 0)
+	source := NewInformerRBACSource(factory)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, startInformers(ctx, factory))
+
+	namespaceValidator := new(namespaceValidatorMock)
+	namespaceValidator.On("Validate", "prod").Return(nil)
+	namespaceValidator.On("Validate", core.NamespaceAll).Return(nil)
+	resourceResolver := new(resourceResolverMock)
+	resourceResolver.On("Resolve", "get", "secrets", "", false).Return([]schema.GroupResource{{Resource: "secrets"}}, nil, nil)
+	resourceResolver.On("Resolve", "get", "bees", "", false).Return(nil, nil, invalidActionError{errors.New("the server doesn't have a resource type \"bees\"")})
+	resourceResolver.On("Resolve", "get", "pods.metrics.k8s.io", "", false).Return(nil, nil, errors.New("discovering resource type \"pods.metrics.k8s.io\": the server is currently unable to handle the request"))
+
+	server := httptest.NewServer(NewServer(&WhoCan{
+		rbacSource:         source,
+		namespaceValidator: namespaceValidator,
+		resourceResolver:   resourceResolver,
+		policyRuleMatcher:  NewPolicyRuleMatcher(),
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		scenario string
+		path     string
+
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			scenario:       "Should return bindings which allow the action",
+			path:           "/v1/whocan?verb=get&resource=secrets&namespace=prod",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "{\n    \"roleBindings\": [\n        {\n            \"name\": \"operator-can-read-secrets\",\n            \"roleRef\": {\n                \"apiGroup\": \"\",\n                \"kind\": \"Role\",\n                \"name\": \"read-secrets\"\n            },\n            \"subjects\": [\n                {\n                    \"kind\": \"ServiceAccount\",\n                    \"name\": \"operator\",\n                    \"namespace\": \"prod\"\n                }\n            ]\n        }\n    ]\n}\n",
+		},
+		{
+			scenario:       "Should return bindings in each namespace when namespace is omitted",
+			path:           "/v1/whocan?verb=get&resource=secrets",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "{\n    \"roleBindings\": [\n        {\n            \"name\": \"operator-can-read-secrets\",\n            \"roleRef\": {\n                \"apiGroup\": \"\",\n                \"kind\": \"Role\",\n                \"name\": \"read-secrets\"\n            },\n            \"subjects\": [\n                {\n                    \"kind\": \"ServiceAccount\",\n                    \"name\": \"operator\",\n                    \"namespace\": \"prod\"\n                }\n            ]\n        }\n    ]\n}\n",
+		},
+		{
+			scenario:       "Should return bad request when resource is unknown",
+			path:           "/v1/whocan?verb=get&resource=bees&namespace=prod",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "{\"error\":\"resolving resource: the server doesn't have a resource type \\\"bees\\\"\"}\n",
+		},
+		{
+			scenario:       "Should return internal server error when action cannot be checked",
+			path:           "/v1/whocan?verb=get&resource=pods.metrics.k8s.io&namespace=prod",
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "{\"error\":\"resolving resource: discovering resource type \\\"pods.metrics.k8s.io\\\": the server is currently unable to handle the request\"}\n",
+		},
+		{
+			scenario:       "Should return error when verb is missing",
+			path:           "/v1/whocan?resource=secrets",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "{\"error\":\"the verb parameter is required\"}\n",
+		},
+		{
+			scenario:       "Should return permissions of the subject",
+			path:           "/v1/subjects/serviceaccount/prod/operator/permissions",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"bindingKind":"RoleBinding","binding":"operator-can-read-secrets","namespace":"prod","roleRef":{"apiGroup":"","kind":"Role","name":"read-secrets"},"rules":[{"verbs":["get"],"apiGroups":[""],"resources":["secrets"]}]},{"bindingKind":"ClusterRoleBinding","binding":"operator-can-view","roleRef":{"apiGroup":"","kind":"ClusterRole","name":"view"},"rules":[{"verbs":["get","list"],"apiGroups":[""],"resources":["pods"]}]}]` + "\n",
+		},
+		{
+			scenario:       "Should return no permissions of unknown subject",
+			path:           "/v1/subjects/user/-/alice/permissions",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "[]\n",
+		},
+		{
+			scenario:       "Should return error when subject kind is invalid",
+			path:           "/v1/subjects/robot/-/alice/permissions",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "{\"error\":\"invalid subject kind: robot\"}\n",
+		},
+		{
+			scenario:       "Should return not found for unknown path",
+			path:           "/v1/subjects/user/alice",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "{\"error\":\"not found: /v1/subjects/user/alice\"}\n",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.scenario, func(t *testing.T) {
+			// when
+			resp, err := http.Get(server.URL + tt.path)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			// then
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+			assert.Equal(t, tt.expectedBody, string(body))
+		})
+	}
+}
+
+func TestActionFromQuery(t *testing.T) {
+	action, err := actionFromQuery(map[string][]string{"verb": {"get"}, "resource": {"pods"}, "name": {"nginx"}})
+	require.NoError(t, err)
+	assert.Equal(t, Action{Verb: "get", Resource: "pods", ResourceName: "nginx", AllNamespaces: true}, action)
+
+	_, err = actionFromQuery(map[string][]string{"verb": {"get"}, "resource": {"pods"}, "nonResourceURL": {"/logs"}})
+	assert.EqualError(t, err, "exactly one of the resource or nonResourceURL parameters is required")
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	rbac "k8s.io/api/rbac/v1"
+)
+
+const (
+	sortByFlag = "sort-by"
+
+	sortBySubject   = "subject"
+	sortByKind      = "kind"
+	sortByBinding   = "binding"
+	sortByNamespace = "namespace"
+	sortByRole      = "role"
+)
+
+var sortByKeys = []string{sortBySubject, sortByKind, sortByBinding, sortByNamespace, sortByRole}
+
+// ValidateSortBy returns an error if the specified key is neither empty nor one of the supported sort keys.
+func ValidateSortBy(sortBy string) error {
+	if sortBy != "" && !containsString(sortByKeys, sortBy) {
+		return fmt.Errorf("invalid sort key: %v: must be one of %s", sortBy, strings.Join(sortByKeys, ", "))
+	}
+	return nil
+}
+
+// SetSortBy sets the key by which the Printer sorts binding subjects. By default, they are sorted by namespace,
+// binding and subject.
+func (p *Printer) SetSortBy(sortBy string) {
+	p.sortBy = sortBy
+}
+
+// rowKey holds the fields by which binding subjects are sorted. The namespace is empty for ClusterRoleBindings.
+type rowKey struct {
+	namespace string
+	binding   string
+	roleRef   rbac.RoleRef
+	subject   rbac.Subject
+}
+
+// fields returns the values of the key in the order in which they are compared for the specified sort key.
+func (k rowKey) fields(sortBy string) []string {
+	binding := []string{k.namespace, k.binding}
+	subject := []string{k.subject.Name, k.subject.Kind, k.subject.Namespace}
+	role := []string{k.roleRef.Kind, k.roleRef.Name}
+
+	switch sortBy {
+	case sortBySubject:
+		return concat(subject, binding, role)
+	case sortByKind:
+		return concat([]string{k.subject.Kind, k.subject.Name, k.subject.Namespace}, binding, role)
+	case sortByBinding:
+		return concat([]string{k.binding, k.namespace}, subject, role)
+	case sortByRole:
+		return concat(role, binding, subject)
+	default:
+		return concat(binding, subject, role)
+	}
+}
+
+func concat(slices ...[]string) []string {
+	var result []string
+	for _, s := range slices {
+		result = append(result, s...)
+	}
+	return result
+}
+
+// lessRow returns true if the binding subject with the key a is sorted before the one with the key b.
+func lessRow(sortBy string, a, b rowKey) bool {
+	fa, fb := a.fields(sortBy), b.fields(sortBy)
+	for i := range fa {
+		if fa[i] != fb[i] {
+			return fa[i] < fb[i]
+		}
+	}
+	return false
+}
+
+// uniqueSubjects returns the specified subjects without duplicates, sorted by the specified key.
+func uniqueSubjects(subjects []rbac.Subject, sortBy string) []rbac.Subject {
+	unique := make([]rbac.Subject, 0, len(subjects))
+	seen := make(map[string]bool, len(subjects))
+	for _, s := range subjects {
+		if key := subjectString(s); !seen[key] {
+			seen[key] = true
+			unique = append(unique, s)
+		}
+	}
+	sort.SliceStable(unique, func(i, j int) bool {
+		return lessRow(sortBy, rowKey{subject: unique[i]}, rowKey{subject: unique[j]})
+	})
+	return unique
+}
+
+// roleBindingRow is a subject of a RoleBinding, printed as a table row.
+type roleBindingRow struct {
+	binding rbac.RoleBinding
+	subject rbac.Subject
+}
+
+// roleBindingRows returns a row for each distinct subject of the specified RoleBindings, sorted by the sort key of the
+// Printer.
+func (p *Printer) roleBindingRows(roleBindings []rbac.RoleBinding) []roleBindingRow {
+	var rows []roleBindingRow
+	for _, rb := range roleBindings {
+		for _, s := range uniqueSubjects(rb.Subjects, p.sortBy) {
+			rows = append(rows, roleBindingRow{binding: rb, subject: s})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return lessRow(p.sortBy, rows[i].key(), rows[j].key())
+	})
+	return rows
+}
+
+func (r roleBindingRow) key() rowKey {
+	return rowKey{namespace: r.binding.Namespace, binding: r.binding.Name, roleRef: r.binding.RoleRef, subject: r.subject}
+}
+
+// clusterRoleBindingRow is a subject of a ClusterRoleBinding, printed as a table row.
+type clusterRoleBindingRow struct {
+	binding rbac.ClusterRoleBinding
+	subject rbac.Subject
+}
+
+// clusterRoleBindingRows returns a row for each distinct subject of the specified ClusterRoleBindings, sorted by the
+// sort key of the Printer.
+func (p *Printer) clusterRoleBindingRows(clusterRoleBindings []rbac.ClusterRoleBinding) []clusterRoleBindingRow {
+	var rows []clusterRoleBindingRow
+	for _, crb := range clusterRoleBindings {
+		for _, s := range uniqueSubjects(crb.Subjects, p.sortBy) {
+			rows = append(rows, clusterRoleBindingRow{binding: crb, subject: s})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return lessRow(p.sortBy, rows[i].key(), rows[j].key())
+	})
+	return rows
+}
+
+func (r clusterRoleBindingRow) key() rowKey {
+	return rowKey{binding: r.binding.Name, roleRef: r.binding.RoleRef, subject: r.subject}
+}
+
+// sortRowData sorts the exported bindings by the sort key of the Printer, where bindings are compared by their first
+// subject.
+func (p *Printer) sortRowData(rows []rowData) {
+	key := func(r rowData) rowKey {
+		return rowKey{namespace: r.namespace, binding: r.Name, roleRef: r.RoleRef, subject: r.Subjects[0].Subject}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return lessRow(p.sortBy, key(rows[i]), key(rows[j]))
+	})
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	sortByRoleBindings = []rbac.RoleBinding{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "viewers", Namespace: "foo"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "view"},
+			Subjects: []rbac.Subject{
+				{Kind: rbac.UserKind, APIGroup: rbac.GroupName, Name: "carol"},
+				{Kind: rbac.GroupKind, APIGroup: rbac.GroupName, Name: "auditors"},
+				{Kind: rbac.UserKind, Name: "carol"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "admins", Namespace: "foo"},
+			RoleRef:    rbac.RoleRef{Kind: RoleKind, Name: "admin"},
+			Subjects:   []rbac.Subject{{Kind: rbac.UserKind, Name: "bob"}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "viewers", Namespace: "bar"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "view"},
+			Subjects:   []rbac.Subject{{Kind: rbac.ServiceAccountKind, Namespace: "bar", Name: "alice"}},
+		},
+	}
+)
+
+func TestValidateSortBy(t *testing.T) {
+	for _, key := range append([]string{""}, sortByKeys...) {
+		assert.NoError(t, ValidateSortBy(key))
+	}
+	assert.EqualError(t, ValidateSortBy("age"), "invalid sort key: age: must be one of subject, kind, binding, namespace, role")
+}
+
+func TestUniqueSubjects(t *testing.T) {
+	// when
+	subjects := uniqueSubjects(sortByRoleBindings[0].Subjects, "")
+
+	// then
+	assert.Equal(t, []rbac.Subject{
+		{Kind: rbac.GroupKind, APIGroup: rbac.GroupName, Name: "auditors"},
+		{Kind: rbac.UserKind, APIGroup: rbac.GroupName, Name: "carol"},
+	}, subjects)
+}
+
+func TestPrinter_PrintChecks_SortBy(t *testing.T) {
+	action := Action{Verb: "get", Resource: "pods", AllNamespaces: true}
+
+	data := []struct {
+		sortBy string
+		output string
+	}{
+		{
+			sortBy: "",
+			output: `ROLEBINDING  NAMESPACE  SUBJECT   TYPE            SA-NAMESPACE
+viewers      bar        alice     ServiceAccount  bar
+admins       foo        bob       User            
+viewers      foo        auditors  Group           
+viewers      foo        carol     User            
+`,
+		},
+		{
+			sortBy: sortBySubject,
+			output: `ROLEBINDING  NAMESPACE  SUBJECT   TYPE            SA-NAMESPACE
+viewers      bar        alice     ServiceAccount  bar
+viewers      foo        auditors  Group           
+admins       foo        bob       User            
+viewers      foo        carol     User            
+`,
+		},
+		{
+			sortBy: sortByKind,
+			output: `ROLEBINDING  NAMESPACE  SUBJECT   TYPE            SA-NAMESPACE
+viewers      foo        auditors  Group           
+viewers      bar        alice     ServiceAccount  bar
+admins       foo        bob       User            
+viewers      foo        carol     User            
+`,
+		},
+		{
+			sortBy: sortByBinding,
+			output: `ROLEBINDING  NAMESPACE  SUBJECT   TYPE            SA-NAMESPACE
+admins       foo        bob       User            
+viewers      bar        alice     ServiceAccount  bar
+viewers      foo        auditors  Group           
+viewers      foo        carol     User            
+`,
+		},
+		{
+			sortBy: sortByRole,
+			output: `ROLEBINDING  NAMESPACE  SUBJECT   TYPE            SA-NAMESPACE
+viewers      bar        alice     ServiceAccount  bar
+viewers      foo        auditors  Group           
+viewers      foo        carol     User            
+admins       foo        bob       User            
+`,
+		},
+	}
+
+	for _, tt := range data {
+		t.Run("sort by "+tt.sortBy, func(t *testing.T) {
+			// given
+			var buf bytes.Buffer
+			printer := NewPrinter(&buf, false)
+			printer.SetSortBy(tt.sortBy)
+
+			// when
+			printer.PrintChecks(action, sortByRoleBindings, nil)
+
+			// then
+			assert.Equal(t, tt.output+"\nNo subjects found with permissions to get pods assigned through ClusterRoleBindings\n", buf.String())
+		})
+	}
+}
+
+func TestPrinter_ExportData_SortBy(t *testing.T) {
+	// given
+	var buf bytes.Buffer
+	printer := NewPrinter(&buf, false)
+	printer.SetSortBy(sortByBinding)
+
+	// when
+	printer.ExportData(Action{Verb: "get", Resource: "pods", AllNamespaces: true}, sortByRoleBindings, nil)
+
+	// then
+	assert.JSONEq(t, `{
+  "roleBindings": [
+    {
+      "name": "admins",
+      "roleRef": {"apiGroup": "", "kind": "Role", "name": "admin"},
+      "subjects": [{"kind": "User", "name": "bob"}]
+    },
+    {
+      "name": "viewers",
+      "roleRef": {"apiGroup": "", "kind": "ClusterRole", "name": "view"},
+      "subjects": [{"kind": "ServiceAccount", "namespace": "bar", "name": "alice"}]
+    },
+    {
+      "name": "viewers",
+      "roleRef": {"apiGroup": "", "kind": "ClusterRole", "name": "view"},
+      "subjects": [
+        {"kind": "Group", "apiGroup": "rbac.authorization.k8s.io", "name": "auditors"},
+        {"kind": "User", "apiGroup": "rbac.authorization.k8s.io", "name": "carol"}
+      ]
+    }
+  ]
+}`, buf.String())
+}
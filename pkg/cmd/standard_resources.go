@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// standardResource is an API resource served by every Kubernetes API server, known without access to it.
+type standardResource struct {
+	group      string
+	name       string
+	kind       string
+	shortNames []string
+}
+
+// matches returns true if the given GroupResource refers to the standard resource by its plural or singular name or
+// shortcut, in its API group or in an unspecified one.
+func (r standardResource) matches(gr schema.GroupResource) bool {
+	if gr.Group != "" && gr.Group != r.group {
+		return false
+	}
+	return gr.Resource == r.name || gr.Resource == strings.ToLower(r.kind) || containsString(r.shortNames, gr.Resource)
+}
+
+// standardResources is the catalogue of the built-in API resources, i.e. the output of `kubectl api-resources` for a
+// cluster without extensions. A resource served by several API groups is listed in the preferred group first.
+var standardResources = []standardResource{
+	{group: "", name: "bindings", kind: "Binding"},
+	{group: "", name: "componentstatuses", kind: "ComponentStatus", shortNames: []string{"cs"}},
+	{group: "", name: "configmaps", kind: "ConfigMap", shortNames: []string{"cm"}},
+	{group: "", name: "endpoints", kind: "Endpoints", shortNames: []string{"ep"}},
+	{group: "", name: "events", kind: "Event", shortNames: []string{"ev"}},
+	{group: "", name: "limitranges", kind: "LimitRange", shortNames: []string{"limits"}},
+	{group: "", name: "namespaces", kind: "Namespace", shortNames: []string{"ns"}},
+	{group: "", name: "nodes", kind: "Node", shortNames: []string{"no"}},
+	{group: "", name: "persistentvolumeclaims", kind: "PersistentVolumeClaim", shortNames: []string{"pvc"}},
+	{group: "", name: "persistentvolumes", kind: "PersistentVolume", shortNames: []string{"pv"}},
+	{group: "", name: "pods", kind: "Pod", shortNames: []string{"po"}},
+	{group: "", name: "podtemplates", kind: "PodTemplate"},
+	{group: "", name: "replicationcontrollers", kind: "ReplicationController", shortNames: []string{"rc"}},
+	{group: "", name: "resourcequotas", kind: "ResourceQuota", shortNames: []string{"quota"}},
+	{group: "", name: "secrets", kind: "Secret"},
+	{group: "", name: "serviceaccounts", kind: "ServiceAccount", shortNames: []string{"sa"}},
+	{group: "", name: "services", kind: "Service", shortNames: []string{"svc"}},
+	{group: "admissionregistration.k8s.io", name: "mutatingwebhookconfigurations", kind: "MutatingWebhookConfiguration"},
+	{group: "admissionregistration.k8s.io", name: "validatingwebhookconfigurations", kind: "ValidatingWebhookConfiguration"},
+	{group: "apiextensions.k8s.io", name: "customresourcedefinitions", kind: "CustomResourceDefinition", shortNames: []string{"crd", "crds"}},
+	{group: "apiregistration.k8s.io", name: "apiservices", kind: "APIService"},
+	{group: "apps", name: "controllerrevisions", kind: "ControllerRevision"},
+	{group: "apps", name: "daemonsets", kind: "DaemonSet", shortNames: []string{"ds"}},
+	{group: "apps", name: "deployments", kind: "Deployment", shortNames: []string{"deploy"}},
+	{group: "apps", name: "replicasets", kind: "ReplicaSet", shortNames: []string{"rs"}},
+	{group: "apps", name: "statefulsets", kind: "StatefulSet", shortNames: []string{"sts"}},
+	{group: "authentication.k8s.io", name: "tokenreviews", kind: "TokenReview"},
+	{group: "authorization.k8s.io", name: "localsubjectaccessreviews", kind: "LocalSubjectAccessReview"},
+	{group: "authorization.k8s.io", name: "selfsubjectaccessreviews", kind: "SelfSubjectAccessReview"},
+	{group: "authorization.k8s.io", name: "selfsubjectrulesreviews", kind: "SelfSubjectRulesReview"},
+	{group: "authorization.k8s.io", name: "subjectaccessreviews", kind: "SubjectAccessReview"},
+	{group: "autoscaling", name: "horizontalpodautoscalers", kind: "HorizontalPodAutoscaler", shortNames: []string{"hpa"}},
+	{group: "batch", name: "cronjobs", kind: "CronJob", shortNames: []string{"cj"}},
+	{group: "batch", name: "jobs", kind: "Job"},
+	{group: "certificates.k8s.io", name: "certificatesigningrequests", kind: "CertificateSigningRequest", shortNames: []string{"csr"}},
+	{group: "coordination.k8s.io", name: "leases", kind: "Lease"},
+	{group: "discovery.k8s.io", name: "endpointslices", kind: "EndpointSlice"},
+	{group: "events.k8s.io", name: "events", kind: "Event", shortNames: []string{"ev"}},
+	{group: "flowcontrol.apiserver.k8s.io", name: "flowschemas", kind: "FlowSchema"},
+	{group: "flowcontrol.apiserver.k8s.io", name: "prioritylevelconfigurations", kind: "PriorityLevelConfiguration"},
+	{group: "networking.k8s.io", name: "ingressclasses", kind: "IngressClass"},
+	{group: "networking.k8s.io", name: "ingresses", kind: "Ingress", shortNames: []string{"ing"}},
+	{group: "networking.k8s.io", name: "networkpolicies", kind: "NetworkPolicy", shortNames: []string{"netpol"}},
+	{group: "node.k8s.io", name: "runtimeclasses", kind: "RuntimeClass"},
+	{group: "policy", name: "poddisruptionbudgets", kind: "PodDisruptionBudget", shortNames: []string{"pdb"}},
+	{group: "policy", name: "podsecuritypolicies", kind: "PodSecurityPolicy", shortNames: []string{"psp"}},
+	{group: "rbac.authorization.k8s.io", name: "clusterrolebindings", kind: "ClusterRoleBinding"},
+	{group: "rbac.authorization.k8s.io", name: "clusterroles", kind: "ClusterRole"},
+	{group: "rbac.authorization.k8s.io", name: "rolebindings", kind: "RoleBinding"},
+	{group: "rbac.authorization.k8s.io", name: "roles", kind: "Role"},
+	{group: "scheduling.k8s.io", name: "priorityclasses", kind: "PriorityClass", shortNames: []string{"pc"}},
+	{group: "storage.k8s.io", name: "csidrivers", kind: "CSIDriver"},
+	{group: "storage.k8s.io", name: "csinodes", kind: "CSINode"},
+	{group: "storage.k8s.io", name: "csistoragecapacities", kind: "CSIStorageCapacity"},
+	{group: "storage.k8s.io", name: "storageclasses", kind: "StorageClass", shortNames: []string{"sc"}},
+	{group: "storage.k8s.io", name: "volumeattachments", kind: "VolumeAttachment"},
+}
+
+// lookupStandardResource returns the GroupResources of the standard resources the given GroupResource refers to, or
+// nil if it is not a standard resource.
+func lookupStandardResource(gr schema.GroupResource) []schema.GroupResource {
+	var grs []schema.GroupResource
+	for _, r := range standardResources {
+		if r.matches(gr) {
+			grs = append(grs, schema.GroupResource{Group: r.group, Resource: r.name})
+		}
+	}
+	return grs
+}
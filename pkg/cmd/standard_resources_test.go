@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestLookupStandardResource(t *testing.T) {
+	data := []struct {
+		scenario string
+		gr       schema.GroupResource
+		expected []schema.GroupResource
+	}{
+		{scenario: "Should match plural name", gr: schema.GroupResource{Resource: "statefulsets"}, expected: []schema.GroupResource{{Group: "apps", Resource: "statefulsets"}}},
+		{scenario: "Should match singular name", gr: schema.GroupResource{Resource: "networkpolicy"}, expected: []schema.GroupResource{{Group: "networking.k8s.io", Resource: "networkpolicies"}}},
+		{scenario: "Should match shortcut in group", gr: schema.GroupResource{Group: "events.k8s.io", Resource: "ev"}, expected: []schema.GroupResource{{Group: "events.k8s.io", Resource: "events"}}},
+		{scenario: "Should match each group serving the resource", gr: schema.GroupResource{Resource: "ev"}, expected: []schema.GroupResource{{Resource: "events"}, {Group: "events.k8s.io", Resource: "events"}}},
+		{scenario: "Should not match another group", gr: schema.GroupResource{Group: "metrics.k8s.io", Resource: "pods"}},
+		{scenario: "Should not match custom resource", gr: schema.GroupResource{Resource: "certificates"}},
+	}
+
+	for _, tt := range data {
+		t.Run(tt.scenario, func(t *testing.T) {
+			assert.Equal(t, tt.expected, lookupStandardResource(tt.gr))
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	rbac "k8s.io/api/rbac/v1"
+)
+
+const (
+	subjectKindFlag      = "subject-kind"
+	subjectFlag          = "subject"
+	excludeSubjectFlag   = "exclude-subject"
+	subjectNamespaceFlag = "subject-namespace"
+	hideSystemFlag       = "hide-system"
+	ignoreFileFlag       = "ignore-file"
+)
+
+// subjectFiltersReason is the reason reported for binding subjects hidden by the subject kind, name and namespace filters.
+const subjectFiltersReason = "Excluded by subject filters"
+
+// SubjectFilter selects the subjects of bindings by kind, name and namespace, and hides the binding subjects matched
+// by ignore rules.
+type SubjectFilter struct {
+	kinds             map[string]bool
+	include           []*regexp.Regexp
+	exclude           []*regexp.Regexp
+	namespaces        map[string]bool
+	excludeNamespaces map[string]bool
+	ignore            []*ignoreMatcher
+}
+
+// HiddenSubjects counts the binding subjects hidden from the results by reason.
+type HiddenSubjects struct {
+	Total   int            `json:"total"`
+	Reasons map[string]int `json:"reasons"`
+}
+
+func (h *HiddenSubjects) add(reason string) {
+	if h.Reasons == nil {
+		h.Reasons = make(map[string]int)
+	}
+	h.Total++
+	h.Reasons[reason]++
+}
+
+// NewSubjectFilter constructs a new SubjectFilter.
+//
+// A subject matches if its kind is one of the specified kinds, its name matches any of the include patterns and none
+// of the exclude patterns, and, for a ServiceAccount, its namespace is one of the specified namespaces, since Users and
+// Groups are not namespaced. A pattern is either a glob, where `*` matches any sequence of characters and `?` any
+// single character, or a regular expression enclosed in slashes, e.g. `/^system:.*$/`. A namespace prefixed with `!`
+// excludes the ServiceAccounts in that namespace instead. Empty lists do not restrict the subjects, so a filter
+// constructed without arguments matches any subject.
+func NewSubjectFilter(kinds, include, exclude, namespaces []string) (*SubjectFilter, error) {
+	f := &SubjectFilter{
+		kinds:             make(map[string]bool),
+		namespaces:        make(map[string]bool),
+		excludeNamespaces: make(map[string]bool),
+	}
+
+	for _, kind := range kinds {
+		switch strings.ToLower(kind) {
+		case "user":
+			f.kinds[rbac.UserKind] = true
+		case "group":
+			f.kinds[rbac.GroupKind] = true
+		case "serviceaccount", "sa":
+			f.kinds[rbac.ServiceAccountKind] = true
+		default:
+			return nil, fmt.Errorf("invalid subject kind: %s: must be one of User, Group or ServiceAccount", kind)
+		}
+	}
+
+	var err error
+	if f.include, err = compilePatterns(include); err != nil {
+		return nil, err
+	}
+	if f.exclude, err = compilePatterns(exclude); err != nil {
+		return nil, err
+	}
+
+	for _, ns := range namespaces {
+		if strings.HasPrefix(ns, "!") {
+			f.excludeNamespaces[strings.TrimPrefix(ns, "!")] = true
+		} else {
+			f.namespaces[ns] = true
+		}
+	}
+
+	return f, nil
+}
+
+// SubjectFilterFrom constructs a new SubjectFilter from the subject flags of the given FlagSet.
+func SubjectFilterFrom(flags *pflag.FlagSet) (*SubjectFilter, error) {
+	kinds, err := flags.GetStringSlice(subjectKindFlag)
+	if err != nil {
+		return nil, err
+	}
+	include, err := flags.GetStringArray(subjectFlag)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := flags.GetStringArray(excludeSubjectFlag)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := flags.GetStringSlice(subjectNamespaceFlag)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := NewSubjectFilter(kinds, include, exclude, namespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	hideSystem, err := flags.GetBool(hideSystemFlag)
+	if err != nil {
+		return nil, err
+	}
+	if hideSystem {
+		if err := filter.Ignore(systemIgnoreRules...); err != nil {
+			return nil, err
+		}
+	}
+
+	ignoreFile, err := flags.GetString(ignoreFileFlag)
+	if err != nil {
+		return nil, err
+	}
+	if ignoreFile != "" {
+		rules, err := ReadIgnoreFile(ignoreFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := filter.Ignore(rules...); err != nil {
+			return nil, err
+		}
+	}
+
+	return filter, nil
+}
+
+// AddSubjectFilterFlags adds the flags read by SubjectFilterFrom to the given FlagSet.
+func AddSubjectFilterFlags(flags *pflag.FlagSet) {
+	flags.StringSlice(subjectKindFlag, nil, "If present, only show subjects of the given kind(s). One of: User, Group, ServiceAccount")
+	flags.StringArray(subjectFlag, nil, "If present, only show subjects whose name matches the glob or /regex/ pattern")
+	flags.StringArray(excludeSubjectFlag, nil, "Hide subjects whose name matches the glob or /regex/ pattern")
+	flags.StringSlice(subjectNamespaceFlag, nil, "If present, only show ServiceAccounts in the given namespace(s), Users and Groups being shown regardless. Prefix a namespace with ! to hide ServiceAccounts in it instead")
+	flags.Bool(hideSystemFlag, false, "If true, hide the built-in bindings of Kubernetes components and kubeadm")
+	flags.String(ignoreFileFlag, "", "Path to the file listing binding subjects to be hidden along with the justification")
+}
+
+// Ignore adds the given rules to the ones hiding binding subjects.
+func (f *SubjectFilter) Ignore(rules ...IgnoreRule) error {
+	for _, rule := range rules {
+		m, err := newIgnoreMatcher(rule)
+		if err != nil {
+			return err
+		}
+		f.ignore = append(f.ignore, m)
+	}
+	return nil
+}
+
+// compilePatterns compiles the given glob or /regex/ patterns into anchored regular expressions.
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		expr := globToRegexp(pattern)
+		if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			expr = pattern[1 : len(pattern)-1]
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid subject pattern: %s: %v", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// globToRegexp converts the given glob pattern into an anchored regular expression.
+func globToRegexp(glob string) string {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	return expr.String()
+}
+
+// Matches returns true if the given subject is selected by this filter.
+func (f *SubjectFilter) Matches(subject rbac.Subject) bool {
+	if len(f.kinds) > 0 && !f.kinds[subject.Kind] {
+		return false
+	}
+	if len(f.include) > 0 && !matchesAny(f.include, subject.Name) {
+		return false
+	}
+	if matchesAny(f.exclude, subject.Name) {
+		return false
+	}
+	if subject.Kind != rbac.ServiceAccountKind {
+		return true
+	}
+	if len(f.namespaces) > 0 && !f.namespaces[subject.Namespace] {
+		return false
+	}
+	if f.excludeNamespaces[subject.Namespace] {
+		return false
+	}
+	return true
+}
+
+func matchesAny(patterns []*regexp.Regexp, name string) bool {
+	for _, re := range patterns {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns copies of the given bindings with only the subjects selected by this filter and not hidden by any
+// ignore rule, along with the number of hidden binding subjects. Bindings none of whose subjects is shown are omitted.
+func (f *SubjectFilter) Filter(roleBindings []rbac.RoleBinding, clusterRoleBindings []rbac.ClusterRoleBinding) ([]rbac.RoleBinding, []rbac.ClusterRoleBinding, HiddenSubjects) {
+	var hidden HiddenSubjects
+
+	var filteredRoleBindings []rbac.RoleBinding
+	for _, rb := range roleBindings {
+		subjects := f.filterSubjects(RoleBindingKind, rb.Name, rb.Namespace, rb.RoleRef, rb.Subjects, &hidden)
+		if len(rb.Subjects) > 0 && len(subjects) == 0 {
+			continue
+		}
+		rb.Subjects = subjects
+		filteredRoleBindings = append(filteredRoleBindings, rb)
+	}
+	var filteredClusterRoleBindings []rbac.ClusterRoleBinding
+	for _, crb := range clusterRoleBindings {
+		subjects := f.filterSubjects(ClusterRoleBindingKind, crb.Name, "", crb.RoleRef, crb.Subjects, &hidden)
+		if len(crb.Subjects) > 0 && len(subjects) == 0 {
+			continue
+		}
+		crb.Subjects = subjects
+		filteredClusterRoleBindings = append(filteredClusterRoleBindings, crb)
+	}
+	return filteredRoleBindings, filteredClusterRoleBindings, hidden
+}
+
+func (f *SubjectFilter) filterSubjects(bindingKind, binding, namespace string, roleRef rbac.RoleRef, subjects []rbac.Subject, hidden *HiddenSubjects) []rbac.Subject {
+	var filtered []rbac.Subject
+	for _, s := range subjects {
+		if !f.Matches(s) {
+			hidden.add(subjectFiltersReason)
+			continue
+		}
+		if reason, ok := f.ignored(bindingSubject{BindingKind: bindingKind, Binding: binding, Namespace: namespace, RoleRef: roleRef, Subject: s}); ok {
+			hidden.add(reason)
+			continue
+		}
+		filtered = append(filtered, s)
+	}
+	return filtered
+}
+
+// ignored returns the justification of the first ignore rule matching the given binding subject, if any.
+func (f *SubjectFilter) ignored(bs bindingSubject) (string, bool) {
+	for _, m := range f.ignore {
+		if m.matches(bs) {
+			return m.rule.Justification, true
+		}
+	}
+	return "", false
+}
+
+// PrintHidden prints the number of binding subjects hidden from the results by reason, if any.
+func (p *Printer) PrintHidden(hidden HiddenSubjects) {
+	if hidden.Total == 0 {
+		return
+	}
+	reasons := make([]string, 0, len(hidden.Reasons))
+	for reason := range hidden.Reasons {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+
+	_, _ = fmt.Fprintf(p.out, "\n%d subject(s) hidden:\n", hidden.Total)
+	for _, reason := range reasons {
+		_, _ = fmt.Fprintf(p.out, "\t%d\t%s\n", hidden.Reasons[reason], reason)
+	}
+}
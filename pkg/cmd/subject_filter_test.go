@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	alice           = rbac.Subject{Kind: rbac.UserKind, Name: "alice@example.com"}
+	bob             = rbac.Subject{Kind: rbac.UserKind, Name: "bob@example.com"}
+	masters         = rbac.Subject{Kind: rbac.GroupKind, Name: "system:masters"}
+	operatorSA      = rbac.Subject{Kind: rbac.ServiceAccountKind, Name: "operator", Namespace: "foo"}
+	kubeProxySA     = rbac.Subject{Kind: rbac.ServiceAccountKind, Name: "kube-proxy", Namespace: "kube-system"}
+	jobControllerSA = rbac.Subject{Kind: rbac.ServiceAccountKind, Name: "job-controller", Namespace: "kube-system"}
+	filterTarget    = []rbac.Subject{alice, bob, masters, operatorSA, kubeProxySA}
+)
+
+func TestSubjectFilter_Matches(t *testing.T) {
+	testCases := []struct {
+		scenario   string
+		kinds      []string
+		include    []string
+		exclude    []string
+		namespaces []string
+
+		expected []rbac.Subject
+	}{
+		{
+			scenario: "Should match any subject when no filters are specified",
+			expected: filterTarget,
+		},
+		{
+			scenario: "Should match subjects by kind",
+			kinds:    []string{"user", "SA"},
+			expected: []rbac.Subject{alice, bob, operatorSA, kubeProxySA},
+		},
+		{
+			scenario: "Should match subjects by glob",
+			include:  []string{"*@example.com", "operator"},
+			expected: []rbac.Subject{alice, bob, operatorSA},
+		},
+		{
+			scenario: "Should match subjects by regular expression",
+			include:  []string{"/^(alice|kube)/"},
+			expected: []rbac.Subject{alice, kubeProxySA},
+		},
+		{
+			scenario: "Should exclude subjects",
+			include:  []string{"*"},
+			exclude:  []string{"system:*", "/^b.b@/"},
+			expected: []rbac.Subject{alice, operatorSA, kubeProxySA},
+		},
+		{
+			scenario:   "Should match subjects by namespace",
+			kinds:      []string{"ServiceAccount"},
+			namespaces: []string{"foo"},
+			expected:   []rbac.Subject{operatorSA},
+		},
+		{
+			scenario:   "Should match users and groups regardless of namespace",
+			namespaces: []string{"foo"},
+			expected:   []rbac.Subject{alice, bob, masters, operatorSA},
+		},
+		{
+			scenario:   "Should exclude subjects by namespace",
+			kinds:      []string{"ServiceAccount"},
+			namespaces: []string{"!kube-system"},
+			expected:   []rbac.Subject{operatorSA},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.scenario, func(t *testing.T) {
+			// given
+			filter, err := NewSubjectFilter(tt.kinds, tt.include, tt.exclude, tt.namespaces)
+			require.NoError(t, err)
+
+			// when
+			var matched []rbac.Subject
+			for _, s := range filterTarget {
+				if filter.Matches(s) {
+					matched = append(matched, s)
+				}
+			}
+
+			// then
+			assert.Equal(t, tt.expected, matched)
+		})
+	}
+}
+
+func TestNewSubjectFilter_Errors(t *testing.T) {
+	_, err := NewSubjectFilter([]string{"Robot"}, nil, nil, nil)
+	assert.Equal(t, errors.New("invalid subject kind: Robot: must be one of User, Group or ServiceAccount"), err)
+
+	_, err = NewSubjectFilter(nil, []string{"/(/"}, nil, nil)
+	assert.EqualError(t, err, "invalid subject pattern: /(/: error parsing regexp: missing closing ): `(`")
+}
+
+func TestSubjectFilter_Filter(t *testing.T) {
+	// given
+	filter, err := NewSubjectFilter([]string{"ServiceAccount"}, nil, nil, nil)
+	require.NoError(t, err)
+	roleBindings := []rbac.RoleBinding{
+		{ObjectMeta: metav1.ObjectMeta{Name: "mixed", Namespace: "foo"}, Subjects: []rbac.Subject{alice, operatorSA}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "users", Namespace: "foo"}, Subjects: []rbac.Subject{alice, bob}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "empty", Namespace: "foo"}},
+	}
+	clusterRoleBindings := []rbac.ClusterRoleBinding{
+		{ObjectMeta: metav1.ObjectMeta{Name: "masters"}, Subjects: []rbac.Subject{masters}},
+	}
+
+	// when
+	filteredRoleBindings, filteredClusterRoleBindings, hidden := filter.Filter(roleBindings, clusterRoleBindings)
+
+	// then
+	assert.Equal(t, []rbac.RoleBinding{
+		{ObjectMeta: metav1.ObjectMeta{Name: "mixed", Namespace: "foo"}, Subjects: []rbac.Subject{operatorSA}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "empty", Namespace: "foo"}},
+	}, filteredRoleBindings)
+	assert.Empty(t, filteredClusterRoleBindings)
+	assert.Equal(t, HiddenSubjects{Total: 4, Reasons: map[string]int{subjectFiltersReason: 4}}, hidden)
+	assert.Equal(t, []rbac.Subject{alice, operatorSA}, roleBindings[0].Subjects, "input must not be modified")
+}
+
+func TestSubjectFilter_Ignore(t *testing.T) {
+	// given
+	filter, err := NewSubjectFilter(nil, nil, []string{"bob@*"}, nil)
+	require.NoError(t, err)
+	require.NoError(t, filter.Ignore(systemIgnoreRules...))
+	require.NoError(t, filter.Ignore(IgnoreRule{
+		BindingKind:   RoleBindingKind,
+		Namespace:     "foo",
+		Role:          "deploy*",
+		Justification: "Deployers of the foo team",
+	}))
+	roleBindings := []rbac.RoleBinding{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "deployers", Namespace: "foo"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "deployer"},
+			Subjects:   []rbac.Subject{alice, operatorSA},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "deployers", Namespace: "bar"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "deployer"},
+			Subjects:   []rbac.Subject{alice, bob},
+		},
+	}
+	clusterRoleBindings := []rbac.ClusterRoleBinding{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "cluster-admin"},
+			Subjects:   []rbac.Subject{masters},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "kubeadm:node-proxier"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "system:node-proxier"},
+			Subjects:   []rbac.Subject{kubeProxySA},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "system:controller:job-controller"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "system:controller:job-controller"},
+			Subjects:   []rbac.Subject{jobControllerSA, operatorSA},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "system:node-proxier"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "cluster-admin"},
+			Subjects:   []rbac.Subject{kubeProxySA},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "break-glass"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "cluster-admin"},
+			Subjects:   []rbac.Subject{masters},
+		},
+	}
+
+	// when
+	filteredRoleBindings, filteredClusterRoleBindings, hidden := filter.Filter(roleBindings, clusterRoleBindings)
+
+	// then
+	assert.Equal(t, []rbac.RoleBinding{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "deployers", Namespace: "bar"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "deployer"},
+			Subjects:   []rbac.Subject{alice},
+		},
+	}, filteredRoleBindings)
+	assert.Equal(t, []rbac.ClusterRoleBinding{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "system:controller:job-controller"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "system:controller:job-controller"},
+			Subjects:   []rbac.Subject{operatorSA},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "system:node-proxier"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "cluster-admin"},
+			Subjects:   []rbac.Subject{kubeProxySA},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "break-glass"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "cluster-admin"},
+			Subjects:   []rbac.Subject{masters},
+		},
+	}, filteredClusterRoleBindings, "built-in bindings must be matched by name, role and subject")
+	assert.Equal(t, HiddenSubjects{Total: 6, Reasons: map[string]int{
+		subjectFiltersReason:                        1,
+		"Deployers of the foo team":                 2,
+		"Built-in binding of Kubernetes components": 2,
+		"Built-in binding created by kubeadm":       1,
+	}}, hidden)
+}
+
+func TestPrinter_PrintHidden(t *testing.T) {
+	var buf bytes.Buffer
+	NewPrinter(&buf, false).PrintHidden(HiddenSubjects{Total: 3, Reasons: map[string]int{
+		"Kubelet node identity":                   2,
+		"Built-in group with unrestricted access": 1,
+	}})
+
+	assert.Equal(t, `
+3 subject(s) hidden:
+	1	Built-in group with unrestricted access
+	2	Kubelet node identity
+`, buf.String())
+
+	buf.Reset()
+	NewPrinter(&buf, false).PrintHidden(HiddenSubjects{})
+	assert.Empty(t, buf.String())
+}
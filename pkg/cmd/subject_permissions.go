@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+
+	rbac "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+)
+
+// SubjectPermission represents the rules granted to a subject through a single RoleBinding or ClusterRoleBinding.
+type SubjectPermission struct {
+	BindingKind string            `json:"bindingKind"`
+	Binding     string            `json:"binding"`
+	Namespace   string            `json:"namespace,omitempty"`
+	RoleRef     rbac.RoleRef      `json:"roleRef"`
+	Rules       []rbac.PolicyRule `json:"rules"`
+}
+
+// PermissionsFor returns the permissions granted to the given subject by RoleBindings in all namespaces and
+// ClusterRoleBindings. A binding which refers to a role that does not exist is reported without rules.
+func (w *WhoCan) PermissionsFor(subject rbac.Subject) ([]SubjectPermission, error) {
+	roleBindings, err := w.rbacSource.ListRoleBindings("", labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("listing RoleBindings: %v", err)
+	}
+	clusterRoleBindings, err := w.rbacSource.ListClusterRoleBindings(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("listing ClusterRoleBindings: %v", err)
+	}
+	roles, err := w.rbacSource.ListRoles("", labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("listing Roles: %v", err)
+	}
+	clusterRoles, err := w.rbacSource.ListClusterRoles(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("listing ClusterRoles: %v", err)
+	}
+
+	roleRules := make(map[string][]rbac.PolicyRule, len(roles))
+	for _, role := range roles {
+		roleRules[role.Namespace+"/"+role.Name] = role.Rules
+	}
+	clusterRoleRules := make(map[string][]rbac.PolicyRule, len(clusterRoles))
+	for _, clusterRole := range clusterRoles {
+		clusterRoleRules[clusterRole.Name] = clusterRole.Rules
+	}
+
+	permissions := []SubjectPermission{}
+	for _, rb := range roleBindings {
+		if !containsSubject(rb.Subjects, subject) {
+			continue
+		}
+		var rules []rbac.PolicyRule
+		var ok bool
+		if rb.RoleRef.Kind == RoleKind {
+			rules, ok = roleRules[rb.Namespace+"/"+rb.RoleRef.Name]
+		} else {
+			rules, ok = clusterRoleRules[rb.RoleRef.Name]
+		}
+		if !ok {
+			klog.V(3).Infof("RoleBinding [%s/%s] refers to missing %s [%s]", rb.Namespace, rb.Name, rb.RoleRef.Kind, rb.RoleRef.Name)
+		}
+		permissions = append(permissions, SubjectPermission{
+			BindingKind: RoleBindingKind,
+			Binding:     rb.Name,
+			Namespace:   rb.Namespace,
+			RoleRef:     rb.RoleRef,
+			Rules:       rules,
+		})
+	}
+	for _, crb := range clusterRoleBindings {
+		if !containsSubject(crb.Subjects, subject) {
+			continue
+		}
+		rules, ok := clusterRoleRules[crb.RoleRef.Name]
+		if !ok {
+			klog.V(3).Infof("ClusterRoleBinding [%s] refers to missing ClusterRole [%s]", crb.Name, crb.RoleRef.Name)
+		}
+		permissions = append(permissions, SubjectPermission{
+			BindingKind: ClusterRoleBindingKind,
+			Binding:     crb.Name,
+			RoleRef:     crb.RoleRef,
+			Rules:       rules,
+		})
+	}
+
+	return permissions, nil
+}
+
+// containsSubject returns `true` if the given subjects contain the specified subject, `false` otherwise.
+// The namespace is only compared for ServiceAccount subjects.
+func containsSubject(subjects []rbac.Subject, subject rbac.Subject) bool {
+	for _, s := range subjects {
+		if s.Kind != subject.Kind || s.Name != subject.Name {
+			continue
+		}
+		if s.Kind == rbac.ServiceAccountKind && s.Namespace != subject.Namespace {
+			continue
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,547 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clioptions "k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	suggestRoleUsage = "suggest-role"
+	suggestRoleLong  = `Generate a least-privilege Role or ClusterRole granting a subject the actions it needs.
+
+The needed actions are read from a file or from apiserver audit logs. An actions file lists one action per line
+written like the arguments of the who-can command, e.g. "get pods/foo -n bar" or "create pods --subresource exec -n
+bar", where actions without a namespace are needed in all namespaces. Lines starting with # are ignored. YAML or JSON
+files in the format of the assert subcommand are accepted as well. Audit logs contribute every request performed by
+the subject.
+
+The command prints a Role and RoleBinding for each namespace, and a ClusterRole and ClusterRoleBinding for the actions
+needed in all namespaces or on non-resource URLs. The output ends with comments listing the rules currently bound to
+the subject which grant none of the needed actions and could therefore be removed, and the needed actions which are
+not currently granted. Only bindings which name the subject directly are compared.`
+	suggestRoleExample = `  # Suggest a Role for the ServiceAccount "operator" in namespace "foo" from the list of actions it needs
+  kubectl who-can suggest-role --subject sa:foo/operator --actions actions.txt
+
+  # Suggest a Role for the ServiceAccount from the requests it performed according to the audit log
+  kubectl who-can suggest-role --subject sa:foo/operator --audit-log /var/log/kubernetes/audit.log`
+)
+
+const (
+	actionsFlag  = "actions"
+	auditLogFlag = "audit-log"
+	roleNameFlag = "name"
+)
+
+// RoleSuggestion holds the least-privilege roles and bindings granting a subject the needed actions, along with the
+// current grants of the subject which are not needed and the needed actions which are not granted.
+type RoleSuggestion struct {
+	Roles               []rbac.Role
+	RoleBindings        []rbac.RoleBinding
+	ClusterRoles        []rbac.ClusterRole
+	ClusterRoleBindings []rbac.ClusterRoleBinding
+	Removable           []RemovableGrant
+	Missing             []Action
+}
+
+// RemovableGrant is a PolicyRule bound to a subject which grants none of the needed actions.
+type RemovableGrant struct {
+	BindingKind string
+	Binding     string
+	Namespace   string
+	RoleRef     rbac.RoleRef
+	Rule        rbac.PolicyRule
+}
+
+// ParseSubjectRef parses a subject written as `kind:name`, or `kind:namespace/name` for ServiceAccounts, where kind is
+// one of user, group, serviceaccount or sa.
+func ParseSubjectRef(ref string) (rbac.Subject, error) {
+	tokens := strings.SplitN(ref, ":", 2)
+	if len(tokens) != 2 || tokens[1] == "" {
+		return rbac.Subject{}, fmt.Errorf("invalid subject: %s: must be kind:name or sa:namespace/name", ref)
+	}
+	kind, name := tokens[0], tokens[1]
+	namespace := noNamespace
+	if k := strings.ToLower(kind); k == "serviceaccount" || k == "sa" {
+		nameTokens := strings.SplitN(name, "/", 2)
+		if len(nameTokens) != 2 {
+			return rbac.Subject{}, fmt.Errorf("invalid subject: %s: the namespace of a ServiceAccount is required", ref)
+		}
+		namespace, name = nameTokens[0], nameTokens[1]
+	}
+	return subjectFrom(kind, namespace, name)
+}
+
+// ReadActionLines reads the actions listed one per line in the file with the specified path. Files with the .yaml,
+// .yml or .json extension are read with ReadActionsFile instead.
+func ReadActionLines(path string) ([]Action, error) {
+	if isManifestFile(path) {
+		return ReadActionsFile(path)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading actions file: %v", err)
+	}
+	return parseActionLines(data)
+}
+
+func parseActionLines(data []byte) ([]Action, error) {
+	var actions []Action
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		action, err := parseActionLine(strings.Fields(text))
+		if err != nil {
+			return nil, fmt.Errorf("parsing actions file: line %d: %v", line, err)
+		}
+		actions = append(actions, action)
+	}
+	if len(actions) == 0 {
+		return nil, errors.New("parsing actions file: no actions specified")
+	}
+	return actions, nil
+}
+
+func parseActionLine(args []string) (action Action, err error) {
+	flags := pflag.NewFlagSet("action", pflag.ContinueOnError)
+	flags.StringP(namespaceFlag, "n", "", "")
+	flags.String(subResourceFlag, "", "")
+	if err = flags.Parse(args); err != nil {
+		return
+	}
+	if flags.NArg() != 2 {
+		err = errors.New("expected verb and resource")
+		return
+	}
+
+	action.Verb = flags.Arg(0)
+	if strings.HasPrefix(flags.Arg(1), "/") {
+		action.NonResourceURL = flags.Arg(1)
+	} else {
+		resourceTokens := strings.SplitN(flags.Arg(1), "/", 2)
+		action.Resource = resourceTokens[0]
+		if len(resourceTokens) > 1 {
+			action.ResourceName = resourceTokens[1]
+		}
+	}
+	action.SubResource, _ = flags.GetString(subResourceFlag)
+	action.Namespace, _ = flags.GetString(namespaceFlag)
+	action.AllNamespaces = action.Namespace == ""
+	return
+}
+
+// actionsPerformedBy returns the distinct actions requested by the given subject according to the audit events.
+func actionsPerformedBy(events []AuditEvent, subject rbac.Subject) []Action {
+	var actions []Action
+	seen := make(map[string]bool)
+	for _, event := range events {
+		if !event.PerformedBy(subject) {
+			continue
+		}
+		action := event.Action()
+		if key := actionKey(action); !seen[key] {
+			seen[key] = true
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+func actionKey(action Action) string {
+	return strings.Join([]string{action.Verb, action.Resource, action.SubResource, action.ResourceName,
+		action.NonResourceURL, action.Namespace}, "|")
+}
+
+// SuggestRole returns the least-privilege roles named after the specified name, and their bindings, which grant the
+// given subject the specified actions. Actions with an empty Namespace are granted in all namespaces.
+func (w *WhoCan) SuggestRole(subject rbac.Subject, name string, actions []Action) (RoleSuggestion, error) {
+	var resolved []resolvedAction
+	seen := make(map[string]bool)
+	for _, action := range actions {
+		key := actionKey(action)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		r, err := w.resolve(action)
+		if err != nil {
+			return RoleSuggestion{}, fmt.Errorf("%s: %v", actionLabel(action), err)
+		}
+		resolved = append(resolved, r)
+	}
+
+	var suggestion RoleSuggestion
+	for _, namespace := range actionNamespaces(resolved) {
+		rules := minimalRules(resolved, namespace)
+		if namespace == "" {
+			suggestion.ClusterRoles = append(suggestion.ClusterRoles, rbac.ClusterRole{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rbac.SchemeGroupVersion.String(), Kind: ClusterRoleKind},
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Rules:      rules,
+			})
+			suggestion.ClusterRoleBindings = append(suggestion.ClusterRoleBindings, rbac.ClusterRoleBinding{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rbac.SchemeGroupVersion.String(), Kind: ClusterRoleBindingKind},
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				RoleRef:    rbac.RoleRef{APIGroup: rbac.GroupName, Kind: ClusterRoleKind, Name: name},
+				Subjects:   []rbac.Subject{subject},
+			})
+			continue
+		}
+		suggestion.Roles = append(suggestion.Roles, rbac.Role{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbac.SchemeGroupVersion.String(), Kind: RoleKind},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Rules:      rules,
+		})
+		suggestion.RoleBindings = append(suggestion.RoleBindings, rbac.RoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbac.SchemeGroupVersion.String(), Kind: RoleBindingKind},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			RoleRef:    rbac.RoleRef{APIGroup: rbac.GroupName, Kind: RoleKind, Name: name},
+			Subjects:   []rbac.Subject{subject},
+		})
+	}
+
+	permissions, err := w.PermissionsFor(subject)
+	if err != nil {
+		return RoleSuggestion{}, err
+	}
+	granted := make([]bool, len(resolved))
+	for _, permission := range permissions {
+		for _, rule := range permission.Rules {
+			used := false
+			for i, action := range resolved {
+				if permission.BindingKind == RoleBindingKind && permission.Namespace != action.Namespace {
+					continue
+				}
+				if len(w.policyRuleMatcher.MatchingRules([]rbac.PolicyRule{rule}, action)) > 0 {
+					used = true
+					granted[i] = true
+				}
+			}
+			if !used {
+				suggestion.Removable = append(suggestion.Removable, RemovableGrant{
+					BindingKind: permission.BindingKind,
+					Binding:     permission.Binding,
+					Namespace:   permission.Namespace,
+					RoleRef:     permission.RoleRef,
+					Rule:        rule,
+				})
+			}
+		}
+	}
+	for i, action := range resolved {
+		if !granted[i] {
+			suggestion.Missing = append(suggestion.Missing, action.Action)
+		}
+	}
+
+	return suggestion, nil
+}
+
+// actionNamespaces returns the sorted namespaces of the given actions, starting with "" for actions needed in all
+// namespaces or on non-resource URLs.
+func actionNamespaces(actions []resolvedAction) []string {
+	set := make(map[string]bool)
+	for _, action := range actions {
+		set[action.Namespace] = true
+	}
+	var namespaces []string
+	for namespace := range set {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// ruleResourceName returns the resource name a rule granting the given action may be restricted to. The API server
+// records the object name of create requests, but create, deletecollection, list and watch requests are not
+// authorized against resourceNames, so they are granted on all objects of the resource.
+func ruleResourceName(action Action) string {
+	if action.SubResource == "" && (containsString(namelessVerbs, action.Verb) ||
+		action.Verb == "list" || action.Verb == "watch") {
+		return ""
+	}
+	return action.ResourceName
+}
+
+// ruleTarget is a resource of an API group, or a non-resource URL.
+type ruleTarget struct {
+	group, resource, url string
+}
+
+// minimalRules returns the fewest PolicyRules granting exactly the actions in the specified namespace. Verbs granted
+// on all objects of a resource are not granted again on named objects of that resource.
+func minimalRules(actions []resolvedAction, namespace string) []rbac.PolicyRule {
+	verbsOf := make(map[ruleTarget]map[string]bool)
+	namedVerbsOf := make(map[ruleTarget]map[string]map[string]bool)
+	for _, action := range actions {
+		if action.Namespace != namespace {
+			continue
+		}
+		t := ruleTarget{url: action.NonResourceURL}
+		if t.url == "" {
+			t = ruleTarget{group: action.gr.Group, resource: action.gr.Resource}
+			if action.SubResource != "" {
+				t.resource += "/" + action.SubResource
+			}
+		}
+		name := ruleResourceName(action.Action)
+		if name == "" {
+			if verbsOf[t] == nil {
+				verbsOf[t] = make(map[string]bool)
+			}
+			verbsOf[t][action.Verb] = true
+			continue
+		}
+		if namedVerbsOf[t] == nil {
+			namedVerbsOf[t] = make(map[string]map[string]bool)
+		}
+		if namedVerbsOf[t][name] == nil {
+			namedVerbsOf[t][name] = make(map[string]bool)
+		}
+		namedVerbsOf[t][name][action.Verb] = true
+	}
+
+	// Rules with the same API group, verbs and resource names are merged into a single rule granting all their
+	// resources or URLs.
+	type ruleKey struct {
+		group, verbs, names string
+		url                 bool
+	}
+	targetsOf := make(map[ruleKey][]string)
+	for t, verbs := range verbsOf {
+		if t.url != "" {
+			key := ruleKey{verbs: joinSet(verbs), url: true}
+			targetsOf[key] = appendUnique(targetsOf[key], t.url)
+		} else {
+			key := ruleKey{group: t.group, verbs: joinSet(verbs)}
+			targetsOf[key] = appendUnique(targetsOf[key], t.resource)
+		}
+	}
+	for t, names := range namedVerbsOf {
+		namesOf := make(map[string][]string)
+		for name, verbs := range names {
+			remaining := make(map[string]bool)
+			for verb := range verbs {
+				if !verbsOf[t][verb] {
+					remaining[verb] = true
+				}
+			}
+			if len(remaining) > 0 {
+				namesOf[joinSet(remaining)] = append(namesOf[joinSet(remaining)], name)
+			}
+		}
+		for verbs, names := range namesOf {
+			sort.Strings(names)
+			key := ruleKey{group: t.group, verbs: verbs, names: strings.Join(names, ",")}
+			targetsOf[key] = appendUnique(targetsOf[key], t.resource)
+		}
+	}
+
+	rules := []rbac.PolicyRule{}
+	for key, targets := range targetsOf {
+		sort.Strings(targets)
+		rule := rbac.PolicyRule{Verbs: strings.Split(key.verbs, ",")}
+		if key.url {
+			rule.NonResourceURLs = targets
+		} else {
+			rule.APIGroups = []string{key.group}
+			rule.Resources = targets
+		}
+		if key.names != "" {
+			rule.ResourceNames = strings.Split(key.names, ",")
+		}
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return ruleString(rules[i]) < ruleString(rules[j])
+	})
+	return rules
+}
+
+// joinSet returns the sorted values of the given set separated by commas.
+func joinSet(set map[string]bool) string {
+	values := make([]string, 0, len(set))
+	for value := range set {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return strings.Join(values, ",")
+}
+
+func appendUnique(values []string, value string) []string {
+	if containsString(values, value) {
+		return values
+	}
+	return append(values, value)
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// defaultRoleName returns the name of the suggested roles for the given subject, e.g. `operator-minimal`.
+func defaultRoleName(subject rbac.Subject) string {
+	name := strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(subject.Name), "-"), "-.")
+	return name + "-minimal"
+}
+
+// PrintRoleSuggestion prints the suggested roles and bindings as YAML documents, followed by comments listing the
+// removable grants and the missing actions.
+func (p *Printer) PrintRoleSuggestion(suggestion RoleSuggestion) {
+	var objects []interface{}
+	for i := range suggestion.ClusterRoles {
+		objects = append(objects, suggestion.ClusterRoles[i], suggestion.ClusterRoleBindings[i])
+	}
+	for i := range suggestion.Roles {
+		objects = append(objects, suggestion.Roles[i], suggestion.RoleBindings[i])
+	}
+	for i, object := range objects {
+		data, err := objectYAML(object)
+		if err != nil {
+			_, _ = fmt.Fprintf(p.out, "# %v\n", err)
+			continue
+		}
+		if i > 0 {
+			_, _ = fmt.Fprintln(p.out, "---")
+		}
+		_, _ = p.out.Write(data)
+	}
+
+	if len(suggestion.Removable) > 0 {
+		_, _ = fmt.Fprintln(p.out, "# Currently bound rules which grant none of the needed actions and could be removed:")
+		for _, grant := range suggestion.Removable {
+			binding := grant.Binding
+			if grant.Namespace != "" {
+				binding = grant.Namespace + "/" + binding
+			}
+			_, _ = fmt.Fprintf(p.out, "#   %s %s (%s %s): %s\n", grant.BindingKind, binding, grant.RoleRef.Kind, grant.RoleRef.Name, ruleString(grant.Rule))
+		}
+	}
+	if len(suggestion.Missing) > 0 {
+		_, _ = fmt.Fprintln(p.out, "# Needed actions which are not currently granted:")
+		for _, action := range suggestion.Missing {
+			_, _ = fmt.Fprintf(p.out, "#   %s\n", actionLabel(action))
+		}
+	}
+}
+
+// objectYAML returns the YAML representation of the given object without the empty creation timestamp.
+func objectYAML(object interface{}) ([]byte, error) {
+	data, err := yaml.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := yaml.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if metadata, ok := fields["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "creationTimestamp")
+	}
+	return yaml.Marshal(fields)
+}
+
+// NewSuggestRoleCommand constructs the suggest-role command with the specified IOStreams and ConfigFlags.
+func NewSuggestRoleCommand(streams clioptions.IOStreams, configFlags *clioptions.ConfigFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          suggestRoleUsage,
+		Short:        "Generate a least-privilege Role for a subject",
+		Long:         suggestRoleLong,
+		Example:      suggestRoleExample,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ref, err := cmd.Flags().GetString(subjectFlag)
+			if err != nil {
+				return err
+			}
+			subject, err := ParseSubjectRef(ref)
+			if err != nil {
+				return err
+			}
+			actionsFile, err := cmd.Flags().GetString(actionsFlag)
+			if err != nil {
+				return err
+			}
+			auditLogs, err := cmd.Flags().GetStringSlice(auditLogFlag)
+			if err != nil {
+				return err
+			}
+			if (actionsFile == "") == (len(auditLogs) == 0) {
+				return fmt.Errorf("exactly one of --%s or --%s is required", actionsFlag, auditLogFlag)
+			}
+			name, err := cmd.Flags().GetString(roleNameFlag)
+			if err != nil {
+				return err
+			}
+			if name == "" {
+				name = defaultRoleName(subject)
+			}
+			files, err := cmd.Flags().GetStringSlice(fromFileFlag)
+			if err != nil {
+				return err
+			}
+
+			var actions []Action
+			if actionsFile != "" {
+				if actions, err = ReadActionLines(actionsFile); err != nil {
+					return err
+				}
+			} else {
+				events, err := ReadAuditLog(auditLogs)
+				if err != nil {
+					return err
+				}
+				if actions = actionsPerformedBy(events, subject); len(actions) == 0 {
+					return fmt.Errorf("no requests performed by %s found in the audit log", subjectString(subject))
+				}
+			}
+
+			var o *WhoCan
+			if len(files) > 0 {
+				manifests, err := LoadManifests(files)
+				if err != nil {
+					return err
+				}
+				if o, err = newManifestsWhoCan(manifests); err != nil {
+					return err
+				}
+			} else {
+				if o, err = NewWhoCan(configFlags, nil); err != nil {
+					return err
+				}
+			}
+
+			suggestion, err := o.SuggestRole(subject, name, actions)
+			if err != nil {
+				return err
+			}
+			NewPrinter(streams.Out, false).PrintRoleSuggestion(suggestion)
+			return nil
+		},
+	}
+
+	cmd.Flags().String(subjectFlag, "", "The subject to suggest a Role for, e.g. sa:namespace/name, user:name or group:name")
+	cmd.Flags().String(actionsFlag, "", "Path to the file listing the actions needed by the subject")
+	cmd.Flags().StringSlice(auditLogFlag, nil, "Path to the audit log file(s) listing the requests performed by the subject")
+	cmd.Flags().String(roleNameFlag, "", "Name of the suggested roles and bindings. Defaults to the subject name followed by -minimal")
+	cmd.Flags().StringSliceP(fromFileFlag, "f", nil, "Compare with RBAC objects defined in manifest files or directories instead of the cluster")
+	_ = cmd.MarkFlagRequired(subjectFlag)
+
+	return cmd
+}
@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseSubjectRef(t *testing.T) {
+	data := []struct {
+		ref string
+
+		expectedSubject rbac.Subject
+		expectedError   error
+	}{
+		{ref: "sa:foo/operator", expectedSubject: rbac.Subject{Kind: rbac.ServiceAccountKind, Namespace: "foo", Name: "operator"}},
+		{ref: "User:alice@example.com", expectedSubject: rbac.Subject{Kind: rbac.UserKind, APIGroup: rbac.GroupName, Name: "alice@example.com"}},
+		{ref: "group:system:masters", expectedSubject: rbac.Subject{Kind: rbac.GroupKind, APIGroup: rbac.GroupName, Name: "system:masters"}},
+		{ref: "alice", expectedError: errors.New("invalid subject: alice: must be kind:name or sa:namespace/name")},
+		{ref: "sa:operator", expectedError: errors.New("invalid subject: sa:operator: the namespace of a ServiceAccount is required")},
+		{ref: "robot:r2d2", expectedError: errors.New("invalid subject kind: robot")},
+	}
+
+	for _, tt := range data {
+		t.Run(tt.ref, func(t *testing.T) {
+			subject, err := ParseSubjectRef(tt.ref)
+			assert.Equal(t, tt.expectedError, err)
+			assert.Equal(t, tt.expectedSubject, subject)
+		})
+	}
+}
+
+func TestParseActionLines(t *testing.T) {
+	// when
+	actions, err := parseActionLines([]byte(`# Needed by the operator
+get configmaps/settings -n foo
+create pods --subresource exec -n foo
+
+list nodes
+get /healthz
+`))
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []Action{
+		{Verb: "get", Resource: "configmaps", ResourceName: "settings", Namespace: "foo"},
+		{Verb: "create", Resource: "pods", SubResource: "exec", Namespace: "foo"},
+		{Verb: "list", Resource: "nodes", AllNamespaces: true},
+		{Verb: "get", NonResourceURL: "/healthz", AllNamespaces: true},
+	}, actions)
+
+	_, err = parseActionLines([]byte("get\n"))
+	assert.EqualError(t, err, "parsing actions file: line 1: expected verb and resource")
+	_, err = parseActionLines([]byte("# nothing\n"))
+	assert.EqualError(t, err, "parsing actions file: no actions specified")
+}
+
+func TestMinimalRules(t *testing.T) {
+	// given
+	wc := NewOfflineWhoCan(nil)
+	var actions []resolvedAction
+	for _, action := range []Action{
+		{Verb: "get", Resource: "configmaps", Namespace: "foo"},
+		{Verb: "list", Resource: "configmaps", Namespace: "foo"},
+		{Verb: "get", Resource: "secrets", Namespace: "foo"},
+		{Verb: "list", Resource: "secrets", Namespace: "foo"},
+		{Verb: "get", Resource: "deployments.apps", Namespace: "foo"},
+		{Verb: "get", Resource: "configmaps", ResourceName: "settings", Namespace: "foo"},
+		{Verb: "update", Resource: "configmaps", ResourceName: "settings", Namespace: "foo"},
+		{Verb: "update", Resource: "secrets", ResourceName: "settings", Namespace: "foo"},
+		{Verb: "create", Resource: "pods", SubResource: "exec", Namespace: "foo"},
+		{Verb: "get", Resource: "pods", Namespace: "bar"},
+		{Verb: "get", NonResourceURL: "/healthz"},
+		{Verb: "get", NonResourceURL: "/version"},
+	} {
+		r, err := wc.resolve(action)
+		require.NoError(t, err)
+		actions = append(actions, r)
+	}
+
+	// when
+	rules := minimalRules(actions, "foo")
+	clusterRules := minimalRules(actions, "")
+
+	// then
+	assert.Equal(t, []rbac.PolicyRule{
+		{Verbs: []string{"create"}, APIGroups: []string{""}, Resources: []string{"pods/exec"}},
+		{Verbs: []string{"get"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+		{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"configmaps", "secrets"}},
+		{Verbs: []string{"update"}, APIGroups: []string{""}, Resources: []string{"configmaps", "secrets"}, ResourceNames: []string{"settings"}},
+	}, rules)
+	assert.Equal(t, []rbac.PolicyRule{
+		{Verbs: []string{"get"}, NonResourceURLs: []string{"/healthz", "/version"}},
+	}, clusterRules)
+}
+
+func TestMinimalRules_NamelessVerbs(t *testing.T) {
+	// given
+	operator := rbac.Subject{Kind: rbac.ServiceAccountKind, Namespace: "foo", Name: "operator"}
+	event := func(verb, name string) AuditEvent {
+		return AuditEvent{
+			Verb:           verb,
+			User:           AuditUser{Username: "system:serviceaccount:foo:operator"},
+			ObjectRef:      &AuditObjectRef{Resource: "configmaps", Namespace: "foo", Name: name},
+			ResponseStatus: &AuditResponseCode{Code: 201},
+		}
+	}
+	wc := NewOfflineWhoCan(nil)
+	var actions []resolvedAction
+	for _, action := range actionsPerformedBy([]AuditEvent{
+		event("create", "settings"),
+		event("update", "settings"),
+	}, operator) {
+		r, err := wc.resolve(action)
+		require.NoError(t, err)
+		actions = append(actions, r)
+	}
+
+	// when
+	rules := minimalRules(actions, "foo")
+
+	// then
+	assert.Equal(t, []rbac.PolicyRule{
+		{Verbs: []string{"create"}, APIGroups: []string{""}, Resources: []string{"configmaps"}},
+		{Verbs: []string{"update"}, APIGroups: []string{""}, Resources: []string{"configmaps"}, ResourceNames: []string{"settings"}},
+	}, rules)
+}
+
+func TestWhoCan_SuggestRole(t *testing.T) {
+	// given
+	operator := rbac.Subject{Kind: rbac.ServiceAccountKind, Namespace: "foo", Name: "operator"}
+	source, err := NewStaticRBACSource(
+		&rbac.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "edit"},
+			Rules: []rbac.PolicyRule{
+				{Verbs: []string{"*"}, APIGroups: []string{""}, Resources: []string{"configmaps", "secrets"}},
+				{Verbs: []string{"*"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+			},
+		},
+		&rbac.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "operator-edit", Namespace: "foo"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "edit"},
+			Subjects:   []rbac.Subject{operator},
+		},
+	)
+	require.NoError(t, err)
+	wc := NewOfflineWhoCan(source)
+
+	// when
+	suggestion, err := wc.SuggestRole(operator, "operator-minimal", []Action{
+		{Verb: "get", Resource: "configmaps", Namespace: "foo"},
+		{Verb: "get", Resource: "configmaps", Namespace: "foo"},
+		{Verb: "list", Resource: "nodes"},
+	})
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []RemovableGrant{
+		{
+			BindingKind: RoleBindingKind,
+			Binding:     "operator-edit",
+			Namespace:   "foo",
+			RoleRef:     rbac.RoleRef{Kind: ClusterRoleKind, Name: "edit"},
+			Rule:        rbac.PolicyRule{Verbs: []string{"*"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+		},
+	}, suggestion.Removable)
+	assert.Equal(t, []Action{{Verb: "list", Resource: "nodes"}}, suggestion.Missing)
+
+	var buf bytes.Buffer
+	NewPrinter(&buf, false).PrintRoleSuggestion(suggestion)
+	assert.Equal(t, `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: operator-minimal
+rules:
+- apiGroups:
+  - ""
+  resources:
+  - nodes
+  verbs:
+  - list
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: operator-minimal
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: operator-minimal
+subjects:
+- kind: ServiceAccount
+  name: operator
+  namespace: foo
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: operator-minimal
+  namespace: foo
+rules:
+- apiGroups:
+  - ""
+  resources:
+  - configmaps
+  verbs:
+  - get
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: operator-minimal
+  namespace: foo
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: operator-minimal
+subjects:
+- kind: ServiceAccount
+  name: operator
+  namespace: foo
+# Currently bound rules which grant none of the needed actions and could be removed:
+#   RoleBinding foo/operator-edit (ClusterRole edit): * deployments.apps
+# Needed actions which are not currently granted:
+#   list nodes
+`, buf.String())
+}
+
+func TestDefaultRoleName(t *testing.T) {
+	assert.Equal(t, "operator-minimal", defaultRoleName(rbac.Subject{Kind: rbac.ServiceAccountKind, Name: "operator"}))
+	assert.Equal(t, "alice-example.com-minimal", defaultRoleName(rbac.Subject{Kind: rbac.UserKind, Name: "Alice@example.com"}))
+	assert.Equal(t, "system-masters-minimal", defaultRoleName(rbac.Subject{Kind: rbac.GroupKind, Name: "system:masters"}))
+}
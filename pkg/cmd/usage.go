@@ -0,0 +1,341 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	rbac "k8s.io/api/rbac/v1"
+	clioptions "k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+const (
+	usageUsage = "usage VERB [TYPE | TYPE/NAME | NONRESOURCEURL]"
+	usageLong  = `Compare who can perform an action with who actually performed it according to apiserver audit logs.
+
+The audit log files are read as JSON lines of audit.k8s.io/v1 Events, and every request which was not rejected as
+unauthenticated or forbidden is matched against the action. A request matches if it has the same verb, resource,
+subresource and, if specified, resource name, and if it was made in one of the checked namespaces. Requests across all
+namespaces and requests for cluster-scoped resources match any namespace.
+
+Each subject is reported with one of the following statuses:
+
+  used         The subject is granted the action and performed it
+  unused       The subject is granted the action but never performed it
+  not-granted  The subject performed the action but is not currently granted it
+
+A Group is reported as used if any of its members performed the action. A RoleBinding only covers requests made in
+its own namespace, and as with the who-can command, only ClusterRoleBindings are checked with --all-namespaces.`
+	usageExample = `  # Compare who can get secrets in namespace "foo" with who got them according to the audit log
+  kubectl who-can usage get secrets -n foo --audit-log /var/log/kubernetes/audit.log
+
+  # Compare who can create pods in any namespace with the requests of several audit log files
+  kubectl who-can usage create pods -A --audit-log audit.log,audit-1.log`
+)
+
+// UsageStatus tells whether a subject is granted an action and whether it performed it.
+type UsageStatus string
+
+const (
+	UsageUsed       UsageStatus = "used"
+	UsageUnused     UsageStatus = "unused"
+	UsageNotGranted UsageStatus = "not-granted"
+)
+
+var usageStatusOrder = map[UsageStatus]int{
+	UsageUsed:       0,
+	UsageUnused:     1,
+	UsageNotGranted: 2,
+}
+
+// SubjectUsage is the usage of an action by a subject along with the number of matching requests it performed.
+type SubjectUsage struct {
+	Subject  rbac.Subject `json:"subject"`
+	Status   UsageStatus  `json:"status"`
+	Requests int          `json:"requests"`
+}
+
+// usageGrant holds the namespaces in which the action is granted to a subject.
+type usageGrant struct {
+	subject     rbac.Subject
+	clusterWide bool
+	namespaces  map[string]bool
+}
+
+// covers returns true if the grant allows the request of the specified event.
+func (g *usageGrant) covers(event AuditEvent) bool {
+	if !event.PerformedBy(g.subject) {
+		return false
+	}
+	if g.clusterWide {
+		return true
+	}
+	namespace := event.Action().Namespace
+	return namespace != "" && g.namespaces[namespace]
+}
+
+// Usage checks who can perform the specified Action and joins the result with the requests matching the Action in
+// the given audit events.
+func (w *WhoCan) Usage(action Action, events []AuditEvent) ([]SubjectUsage, error) {
+	roleBindings, clusterRoleBindings, err := w.Check(action)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := w.resolve(action)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := w.namespacesOf(action)
+	if err != nil {
+		return nil, err
+	}
+
+	var grants []*usageGrant
+	grantOf := make(map[string]*usageGrant)
+	grantFor := func(subject rbac.Subject) *usageGrant {
+		key := subjectString(subject)
+		if g, ok := grantOf[key]; ok {
+			return g
+		}
+		g := &usageGrant{subject: subject, namespaces: make(map[string]bool)}
+		grantOf[key] = g
+		grants = append(grants, g)
+		return g
+	}
+	for _, rb := range roleBindings {
+		for _, subject := range rb.Subjects {
+			grantFor(subject).namespaces[rb.Namespace] = true
+		}
+	}
+	for _, crb := range clusterRoleBindings {
+		for _, subject := range crb.Subjects {
+			grantFor(subject).clusterWide = true
+		}
+	}
+
+	var matching []AuditEvent
+	for _, event := range events {
+		if event.Allowed() && matchesEvent(resolved, namespaces, event) {
+			matching = append(matching, event)
+		}
+	}
+
+	usage := []SubjectUsage{}
+	for _, g := range grants {
+		u := SubjectUsage{Subject: g.subject, Status: UsageUnused}
+		for _, event := range matching {
+			if g.covers(event) {
+				u.Requests++
+			}
+		}
+		if u.Requests > 0 {
+			u.Status = UsageUsed
+		}
+		usage = append(usage, u)
+	}
+
+	notGranted := make(map[string]int)
+	for _, event := range matching {
+		covered := false
+		for _, g := range grants {
+			if g.covers(event) {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			continue
+		}
+		subject := event.SubjectOf()
+		key := subjectString(subject)
+		if i, ok := notGranted[key]; ok {
+			usage[i].Requests++
+			continue
+		}
+		notGranted[key] = len(usage)
+		usage = append(usage, SubjectUsage{Subject: subject, Status: UsageNotGranted, Requests: 1})
+	}
+
+	sort.SliceStable(usage, func(i, j int) bool {
+		if usage[i].Status != usage[j].Status {
+			return usageStatusOrder[usage[i].Status] < usageStatusOrder[usage[j].Status]
+		}
+		return subjectString(usage[i].Subject) < subjectString(usage[j].Subject)
+	})
+	return usage, nil
+}
+
+// matchesEvent returns true if the request of the specified audit event is an instance of the given Action in one
+// of the given namespaces.
+func matchesEvent(action resolvedAction, namespaces []string, event AuditEvent) bool {
+	if action.Verb != rbac.VerbAll && action.Verb != event.Verb {
+		return false
+	}
+
+	ref := event.ObjectRef
+	if action.NonResourceURL != "" {
+		if ref != nil && ref.Resource != "" {
+			return false
+		}
+		url := event.Action().NonResourceURL
+		if strings.HasSuffix(action.NonResourceURL, "*") {
+			return strings.HasPrefix(url, strings.TrimSuffix(action.NonResourceURL, "*"))
+		}
+		return url == action.NonResourceURL
+	}
+
+	if ref == nil || ref.Resource == "" {
+		return false
+	}
+	if !matchesEventResource(action, ref) {
+		return false
+	}
+	if action.SubResource != ref.Subresource {
+		return false
+	}
+	if action.ResourceName != "" && action.ResourceName != ref.Name {
+		return false
+	}
+	if ref.Namespace == "" || (action.Namespace == "" && !action.multiNamespace()) {
+		return true
+	}
+	return containsString(namespaces, ref.Namespace)
+}
+
+// matchesEventResource returns true if the resource of the audited request is one of the GroupResources of the
+// action. All resources, i.e. `*`, match any resource of the API group, or of any API group unless one is specified.
+func matchesEventResource(action resolvedAction, ref *AuditObjectRef) bool {
+	for _, gr := range action.groupResources() {
+		group := gr.Group == rbac.APIGroupAll || gr.Group == ref.APIGroup
+		if gr.Resource == rbac.ResourceAll && (gr.Group == "" || group) {
+			return true
+		}
+		if gr.Resource == ref.Resource && group {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintUsage prints the usage of an action by subjects as a table.
+func (p *Printer) PrintUsage(action Action, usage []SubjectUsage) {
+	if len(usage) == 0 {
+		_, _ = fmt.Fprintf(p.out, "No subjects found with permissions to %s or matching requests in the audit log\n", action)
+		return
+	}
+
+	wr := new(tabwriter.Writer)
+	wr.Init(p.out, 0, 8, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(wr, "SUBJECT\tTYPE\tSA-NAMESPACE\tSTATUS\tREQUESTS")
+	for _, u := range usage {
+		_, _ = fmt.Fprintf(wr, "%s\t%s\t%s\t%s\t%d\n", u.Subject.Name, u.Subject.Kind, u.Subject.Namespace, u.Status, u.Requests)
+	}
+	_ = wr.Flush()
+}
+
+// ExportUsage prints the usage of an action by subjects as JSON.
+func (p *Printer) ExportUsage(usage []SubjectUsage) error {
+	if usage == nil {
+		usage = []SubjectUsage{}
+	}
+	encoder := json.NewEncoder(p.out)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(usage)
+}
+
+// NewUsageCommand constructs the usage command with the specified IOStreams and ConfigFlags.
+func NewUsageCommand(streams clioptions.IOStreams, configFlags *clioptions.ConfigFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          usageUsage,
+		Short:        "Compare who can perform an action with who performed it according to audit logs",
+		Long:         usageLong,
+		Example:      usageExample,
+		Args:         cobra.RangeArgs(2, 3),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			action, err := ActionFrom(configFlags.ToRawKubeConfigLoader(), cmd.Flags(), args)
+			if err != nil {
+				return err
+			}
+			auditLogs, err := cmd.Flags().GetStringSlice(auditLogFlag)
+			if err != nil {
+				return err
+			}
+			files, err := cmd.Flags().GetStringSlice(fromFileFlag)
+			if err != nil {
+				return err
+			}
+			output, err := cmd.Flags().GetString(outputFlag)
+			if err != nil {
+				return err
+			}
+			output = strings.ToLower(output)
+			if output != "" && output != outputJson {
+				return fmt.Errorf("invalid output format: %v", output)
+			}
+
+			events, err := ReadAuditLog(auditLogs)
+			if err != nil {
+				return err
+			}
+
+			printer := NewPrinter(streams.Out, false)
+			var o *WhoCan
+			if len(files) > 0 {
+				manifests, err := LoadManifests(files)
+				if err != nil {
+					return err
+				}
+				if o, err = newManifestsWhoCan(manifests); err != nil {
+					return err
+				}
+			} else {
+				if o, err = NewWhoCan(configFlags, nil); err != nil {
+					return err
+				}
+				warnings, err := o.CheckAPIAccess(action)
+				if err != nil {
+					return err
+				}
+				var notes []string
+				action, notes, err = o.withResolvedResource(action)
+				if err != nil {
+					return err
+				}
+				printer.PrintWarnings(warnings)
+				printer.PrintNotes(notes)
+			}
+
+			usage, err := o.Usage(action, events)
+			if err != nil {
+				return err
+			}
+			if output == outputJson {
+				if err := printer.ExportUsage(usage); err != nil {
+					return fmt.Errorf("exporting usage: %v", err)
+				}
+			} else {
+				printer.PrintUsage(action, usage)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSlice(auditLogFlag, nil, "Path to the audit log file(s) to read the performed requests from")
+	cmd.Flags().String(subResourceFlag, "", "SubResource such as pod/log or deployment/scale")
+	cmd.Flags().String(apiGroupFlag, "", "If present, the API group of the resource, e.g. apps. Use core for the core API group and * for rules granting all API groups")
+	cmd.Flags().Bool(allAPIGroupsFlag, false, "If true, check a resource served by several API groups in each of them instead of in the preferred one")
+	cmd.Flags().BoolP(allNamespacesFlag, "A", false, "If true, check the action in any of the available namespaces")
+	cmd.Flags().String(namespaceSelectorFlag, "", "If present, check the action in each namespace matching the label selector, e.g. env=prod")
+	cmd.Flags().String(roleSelectorFlag, "", "If present, only check Roles and ClusterRoles matching the label selector, e.g. team=payments")
+	cmd.Flags().String(bindingSelectorFlag, "", "If present, only check RoleBindings and ClusterRoleBindings matching the label selector, e.g. team=payments")
+	cmd.Flags().StringSliceP(fromFileFlag, "f", nil, "Check RBAC objects defined in manifest files or directories instead of the cluster")
+	cmd.Flags().StringP(outputFlag, "o", "", "Output format. One of: json.")
+	_ = cmd.MarkFlagRequired(auditLogFlag)
+
+	return cmd
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func secretsEvent(username, namespace, name string, code int, groups ...string) AuditEvent {
+	return AuditEvent{
+		Verb:           "get",
+		User:           AuditUser{Username: username, Groups: groups},
+		ObjectRef:      &AuditObjectRef{Resource: "secrets", Namespace: namespace, Name: name},
+		ResponseStatus: &AuditResponseCode{Code: code},
+	}
+}
+
+func TestWhoCan_Usage(t *testing.T) {
+	// given
+	source, err := NewStaticRBACSource(
+		&rbac.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "secret-reader"},
+			Rules:      []rbac.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}}},
+		},
+		&rbac.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "read-secrets", Namespace: "foo"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "secret-reader"},
+			Subjects: []rbac.Subject{
+				{Kind: rbac.ServiceAccountKind, Namespace: "foo", Name: "operator"},
+				{Kind: rbac.UserKind, Name: "alice"},
+				{Kind: rbac.GroupKind, Name: "developers"},
+			},
+		},
+		&rbac.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "read-secrets"},
+			RoleRef:    rbac.RoleRef{Kind: ClusterRoleKind, Name: "secret-reader"},
+			Subjects:   []rbac.Subject{{Kind: rbac.UserKind, Name: "carol"}},
+		},
+	)
+	require.NoError(t, err)
+	wc := NewOfflineWhoCan(source)
+	events := []AuditEvent{
+		secretsEvent("system:serviceaccount:foo:operator", "foo", "db", 200),
+		secretsEvent("system:serviceaccount:foo:operator", "foo", "api", 200),
+		secretsEvent("bob", "foo", "db", 200, "developers"),
+		secretsEvent("mallory", "foo", "db", 403),
+		secretsEvent("admin", "foo", "db", 200),
+		secretsEvent("carol", "bar", "db", 200),
+		{Verb: "list", User: AuditUser{Username: "alice"}, ObjectRef: &AuditObjectRef{Resource: "secrets", Namespace: "foo"}},
+		{Verb: "get", User: AuditUser{Username: "alice"}, ObjectRef: &AuditObjectRef{Resource: "configmaps", Namespace: "foo"}},
+	}
+
+	// when
+	usage, err := wc.Usage(Action{Verb: "get", Resource: "secrets", Namespace: "foo"}, events)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []SubjectUsage{
+		{Subject: rbac.Subject{Kind: rbac.GroupKind, Name: "developers"}, Status: UsageUsed, Requests: 1},
+		{Subject: rbac.Subject{Kind: rbac.ServiceAccountKind, Namespace: "foo", Name: "operator"}, Status: UsageUsed, Requests: 2},
+		{Subject: rbac.Subject{Kind: rbac.UserKind, Name: "alice"}, Status: UsageUnused},
+		{Subject: rbac.Subject{Kind: rbac.UserKind, Name: "carol"}, Status: UsageUnused},
+		{Subject: rbac.Subject{Kind: rbac.UserKind, APIGroup: rbac.GroupName, Name: "admin"}, Status: UsageNotGranted, Requests: 1},
+	}, usage)
+
+	// when checking all namespaces only ClusterRoleBindings grant the action
+	usage, err = wc.Usage(Action{Verb: "get", Resource: "secrets", ResourceName: "db", AllNamespaces: true}, events)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []SubjectUsage{
+		{Subject: rbac.Subject{Kind: rbac.UserKind, Name: "carol"}, Status: UsageUsed, Requests: 1},
+		{Subject: rbac.Subject{Kind: rbac.ServiceAccountKind, Namespace: "foo", Name: "operator"}, Status: UsageNotGranted, Requests: 1},
+		{Subject: rbac.Subject{Kind: rbac.UserKind, APIGroup: rbac.GroupName, Name: "admin"}, Status: UsageNotGranted, Requests: 1},
+		{Subject: rbac.Subject{Kind: rbac.UserKind, APIGroup: rbac.GroupName, Name: "bob"}, Status: UsageNotGranted, Requests: 1},
+	}, usage)
+}
+
+func TestMatchesEvent(t *testing.T) {
+	wc := NewOfflineWhoCan(nil)
+	resolve := func(action Action) resolvedAction {
+		r, err := wc.resolve(action)
+		require.NoError(t, err)
+		return r
+	}
+	deployments := AuditEvent{Verb: "patch", ObjectRef: &AuditObjectRef{Resource: "deployments", APIGroup: "apps", Namespace: "foo", Name: "web", Subresource: "scale"}}
+	nodes := AuditEvent{Verb: "list", ObjectRef: &AuditObjectRef{Resource: "nodes"}}
+	healthz := AuditEvent{Verb: "get", RequestURI: "/healthz/etcd?verbose"}
+
+	data := []struct {
+		scenario   string
+		action     Action
+		namespaces []string
+		event      AuditEvent
+		matches    bool
+	}{
+		{scenario: "A", action: Action{Verb: "patch", Resource: "deployments.apps", SubResource: "scale", Namespace: "foo"}, namespaces: []string{"foo"}, event: deployments, matches: true},
+		{scenario: "B", action: Action{Verb: "patch", Resource: "deployments.apps", Namespace: "foo"}, namespaces: []string{"foo"}, event: deployments},
+		{scenario: "C", action: Action{Verb: "patch", Resource: "deployments.extensions", SubResource: "scale", Namespace: "foo"}, namespaces: []string{"foo"}, event: deployments},
+		{scenario: "D", action: Action{Verb: "patch", Resource: "deployments.apps", SubResource: "scale", ResourceName: "api", AllNamespaces: true}, namespaces: []string{""}, event: deployments},
+		{scenario: "E", action: Action{Verb: "*", Resource: "deployments.apps", SubResource: "scale", Namespaces: []string{"bar", "foo"}}, namespaces: []string{"bar", "foo"}, event: deployments, matches: true},
+		{scenario: "F", action: Action{Verb: "patch", Resource: "deployments.apps", SubResource: "scale", Namespace: "bar"}, namespaces: []string{"bar"}, event: deployments},
+		{scenario: "G", action: Action{Verb: "list", Resource: "nodes", Namespace: "bar"}, namespaces: []string{"bar"}, event: nodes, matches: true},
+		{scenario: "H", action: Action{Verb: "get", NonResourceURL: "/healthz/*"}, namespaces: []string{""}, event: healthz, matches: true},
+		{scenario: "I", action: Action{Verb: "get", NonResourceURL: "/healthz"}, namespaces: []string{""}, event: healthz},
+		{scenario: "J", action: Action{Verb: "list", NonResourceURL: "/healthz/*"}, namespaces: []string{""}, event: nodes},
+		{scenario: "K", action: Action{Verb: "patch", Resource: "*.apps", SubResource: "scale", Namespace: "foo"}, namespaces: []string{"foo"}, event: deployments, matches: true},
+		{scenario: "L", action: Action{Verb: "list", Resource: "*.apps", Namespace: "bar"}, namespaces: []string{"bar"}, event: nodes},
+		{scenario: "M", action: Action{Verb: "patch", Resource: "deployments", APIGroup: "apps", SubResource: "scale", Namespace: "foo"}, namespaces: []string{"foo"}, event: deployments, matches: true},
+		{scenario: "N", action: Action{Verb: "patch", Resource: "deployments", APIGroup: "*", SubResource: "scale", Namespace: "foo"}, namespaces: []string{"foo"}, event: deployments, matches: true},
+		{scenario: "O", action: Action{Verb: "patch", Resource: "deploy", SubResource: "scale", Namespace: "foo"}, namespaces: []string{"foo"}, event: deployments, matches: true},
+	}
+
+	for _, tt := range data {
+		t.Run(tt.scenario, func(t *testing.T) {
+			assert.Equal(t, tt.matches, matchesEvent(resolve(tt.action), tt.namespaces, tt.event))
+		})
+	}
+}
+
+func TestPrinter_PrintUsage(t *testing.T) {
+	// given
+	var buf bytes.Buffer
+	usage := []SubjectUsage{
+		{Subject: rbac.Subject{Kind: rbac.ServiceAccountKind, Namespace: "foo", Name: "operator"}, Status: UsageUsed, Requests: 2},
+		{Subject: rbac.Subject{Kind: rbac.UserKind, Name: "alice"}, Status: UsageUnused},
+		{Subject: rbac.Subject{Kind: rbac.UserKind, Name: "admin"}, Status: UsageNotGranted, Requests: 1},
+	}
+
+	// when
+	NewPrinter(&buf, false).PrintUsage(Action{Verb: "get", Resource: "secrets", Namespace: "foo"}, usage)
+
+	// then
+	assert.Equal(t, `SUBJECT   TYPE            SA-NAMESPACE  STATUS       REQUESTS
+operator  ServiceAccount  foo           used         2
+alice     User                          unused       0
+admin     User                          not-granted  1
+`, buf.String())
+
+	// when
+	buf.Reset()
+	NewPrinter(&buf, false).PrintUsage(Action{Verb: "get", Resource: "secrets", Namespace: "foo"}, nil)
+
+	// then
+	assert.Equal(t, "No subjects found with permissions to get secrets or matching requests in the audit log\n", buf.String())
+}
+
+func TestPrinter_ExportUsage(t *testing.T) {
+	// given
+	var buf bytes.Buffer
+	usage := []SubjectUsage{
+		{Subject: rbac.Subject{Kind: rbac.ServiceAccountKind, Namespace: "foo", Name: "operator"}, Status: UsageUsed, Requests: 2},
+		{Subject: rbac.Subject{Kind: rbac.UserKind, Name: "alice"}, Status: UsageUnused},
+	}
+
+	// when
+	err := NewPrinter(&buf, false).ExportUsage(usage)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, `[
+    {
+        "subject": {
+            "kind": "ServiceAccount",
+            "name": "operator",
+            "namespace": "foo"
+        },
+        "status": "used",
+        "requests": 2
+    },
+    {
+        "subject": {
+            "kind": "User",
+            "name": "alice"
+        },
+        "status": "unused",
+        "requests": 0
+    }
+]
+`, buf.String())
+
+	// when
+	buf.Reset()
+	err = NewPrinter(&buf, false).ExportUsage(nil)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, "[]\n", buf.String())
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"context"
+
+	rbac "k8s.io/api/rbac/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// WatchEventAdded is the type of WatchEvent emitted when a subject gains permissions.
+	WatchEventAdded = "ADDED"
+	// WatchEventRemoved is the type of WatchEvent emitted when a subject loses permissions.
+	WatchEventRemoved = "REMOVED"
+)
+
+// bindingSubject represents a single subject of a RoleBinding or ClusterRoleBinding.
+type bindingSubject struct {
+	BindingKind string       `json:"bindingKind"`
+	Binding     string       `json:"binding"`
+	Namespace   string       `json:"namespace,omitempty"`
+	RoleRef     rbac.RoleRef `json:"roleRef"`
+	Subject     rbac.Subject `json:"subject"`
+}
+
+// WatchEvent represents a subject which gained or lost permissions to perform the watched Action through a binding.
+type WatchEvent struct {
+	Type string `json:"type"`
+	bindingSubject
+}
+
+// Watcher keeps track of subjects that can perform an Action as RBAC objects change.
+type Watcher struct {
+	whoCan  *WhoCan
+	factory informers.SharedInformerFactory
+	filter  *SubjectFilter
+	printer *Printer
+	json    bool
+}
+
+// NewWatcher constructs a new Watcher with the specified WhoCan checker, the SharedInformerFactory which backs its
+// RBACSource, the SubjectFilter applied to check results, and the Printer used to output results. If json is true,
+// events are printed as newline delimited JSON.
+func NewWatcher(whoCan *WhoCan, factory informers.SharedInformerFactory, filter *SubjectFilter, printer *Printer, json bool) *Watcher {
+	return &Watcher{
+		whoCan:  whoCan,
+		factory: factory,
+		filter:  filter,
+		printer: printer,
+		json:    json,
+	}
+}
+
+// Watch prints the subjects that can perform the specified Action, and then prints an event whenever a subject gains
+// or loses permissions due to a change of a Role, ClusterRole, RoleBinding or ClusterRoleBinding. It returns when the
+// given context is done.
+func (wr *Watcher) Watch(ctx context.Context, action Action) error {
+	changes := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	}
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { notify() },
+		UpdateFunc: func(interface{}, interface{}) { notify() },
+		DeleteFunc: func(interface{}) { notify() },
+	}
+
+	rbacInformers := wr.factory.Rbac().V1()
+	for _, informer := range []cache.SharedIndexInformer{
+		rbacInformers.Roles().Informer(),
+		rbacInformers.ClusterRoles().Informer(),
+		rbacInformers.RoleBindings().Informer(),
+		rbacInformers.ClusterRoleBindings().Informer(),
+	} {
+		informer.AddEventHandler(handler)
+	}
+
+	if err := startInformers(ctx, wr.factory); err != nil {
+		return err
+	}
+
+	roleBindings, clusterRoleBindings, err := wr.whoCan.Check(action)
+	if err != nil {
+		return err
+	}
+	roleBindings, clusterRoleBindings, _ = wr.filter.Filter(roleBindings, clusterRoleBindings)
+	previous := bindingSubjectsOf(roleBindings, clusterRoleBindings)
+
+	if wr.json {
+		wr.printer.ExportWatchEvents(diffBindingSubjects(nil, previous))
+	} else {
+		wr.printer.PrintChecks(action, roleBindings, clusterRoleBindings)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changes:
+			roleBindings, clusterRoleBindings, err := wr.whoCan.Check(action)
+			if err != nil {
+				return err
+			}
+			roleBindings, clusterRoleBindings, _ = wr.filter.Filter(roleBindings, clusterRoleBindings)
+			current := bindingSubjectsOf(roleBindings, clusterRoleBindings)
+			events := diffBindingSubjects(previous, current)
+			klog.V(3).Infof("Re-evaluated %s after RBAC change: %d event(s)", action, len(events))
+			if len(events) > 0 {
+				if wr.json {
+					wr.printer.ExportWatchEvents(events)
+				} else {
+					wr.printer.PrintWatchEvents(events)
+				}
+			}
+			previous = current
+		}
+	}
+}
+
+// bindingSubjectsOf flattens the given bindings into a list of binding subjects.
+func bindingSubjectsOf(roleBindings []rbac.RoleBinding, clusterRoleBindings []rbac.ClusterRoleBinding) []bindingSubject {
+	var subjects []bindingSubject
+	for _, rb := range roleBindings {
+		for _, s := range rb.Subjects {
+			subjects = append(subjects, bindingSubject{
+				BindingKind: RoleBindingKind,
+				Binding:     rb.Name,
+				Namespace:   rb.Namespace,
+				RoleRef:     rb.RoleRef,
+				Subject:     s,
+			})
+		}
+	}
+	for _, crb := range clusterRoleBindings {
+		for _, s := range crb.Subjects {
+			subjects = append(subjects, bindingSubject{
+				BindingKind: ClusterRoleBindingKind,
+				Binding:     crb.Name,
+				RoleRef:     crb.RoleRef,
+				Subject:     s,
+			})
+		}
+	}
+	return subjects
+}
+
+// diffBindingSubjects returns ADDED events for binding subjects which are present in current but not in previous,
+// followed by REMOVED events for binding subjects which are present in previous but not in current. A binding subject
+// listed more than once, i.e. a binding listing the same subject twice, is reported once.
+func diffBindingSubjects(previous, current []bindingSubject) []WatchEvent {
+	previousSet := make(map[bindingSubject]struct{}, len(previous))
+	for _, s := range previous {
+		previousSet[s] = struct{}{}
+	}
+	currentSet := make(map[bindingSubject]struct{}, len(current))
+	for _, s := range current {
+		currentSet[s] = struct{}{}
+	}
+
+	var events []WatchEvent
+	for _, s := range current {
+		if _, ok := previousSet[s]; !ok {
+			events = append(events, WatchEvent{Type: WatchEventAdded, bindingSubject: s})
+			previousSet[s] = struct{}{}
+		}
+	}
+	for _, s := range previous {
+		if _, ok := currentSet[s]; !ok {
+			events = append(events, WatchEvent{Type: WatchEventRemoved, bindingSubject: s})
+			currentSet[s] = struct{}{}
+		}
+	}
+	return events
+}
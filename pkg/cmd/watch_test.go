@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbac "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestDiffBindingSubjects(t *testing.T) {
+	alice := bindingSubject{BindingKind: "RoleBinding", Binding: "alice-bnd", Namespace: "foo",
+		Subject: rbac.Subject{Kind: rbac.UserKind, Name: "Alice"}}
+	bob := bindingSubject{BindingKind: "ClusterRoleBinding", Binding: "bob-bnd",
+		Subject: rbac.Subject{Kind: rbac.UserKind, Name: "Bob"}}
+	eve := bindingSubject{BindingKind: "ClusterRoleBinding", Binding: "bob-bnd",
+		Subject: rbac.Subject{Kind: rbac.UserKind, Name: "Eve"}}
+
+	events := diffBindingSubjects([]bindingSubject{alice, bob}, []bindingSubject{bob, eve})
+
+	assert.Equal(t, []WatchEvent{
+		{Type: WatchEventAdded, bindingSubject: eve},
+		{Type: WatchEventRemoved, bindingSubject: alice},
+	}, events)
+	assert.Empty(t, diffBindingSubjects([]bindingSubject{alice}, []bindingSubject{alice}))
+
+	// when a binding lists the same subject twice
+	events = diffBindingSubjects([]bindingSubject{alice, alice}, []bindingSubject{eve, eve})
+
+	assert.Equal(t, []WatchEvent{
+		{Type: WatchEventAdded, bindingSubject: eve},
+		{Type: WatchEventRemoved, bindingSubject: alice},
+	}, events)
+}
+
+func TestWatcher_Watch(t *testing.T) {
+	// given
+	const namespace = "foo"
+	readSecrets := &rbac.Role{
+		ObjectMeta: metav1.ObjectMeta{Name: "read-secrets", Namespace: namespace},
+		Rules: []rbac.PolicyRule{
+			{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}},
+		},
+	}
+	client := fake.NewSimpleClientset(readSecrets)
+	factory := informers.NewSharedInformerFactory(client, 0)
+
+	namespaceValidator := new(namespaceValidatorMock)
+	namespaceValidator.On("Validate", namespace).Return(nil)
+	resourceResolver := new(resourceResolverMock)
+	resourceResolver.On("Resolve", "get", "secrets", "", false).Return([]schema.GroupResource{{Resource: "secrets"}}, nil, nil)
+
+	wc := &WhoCan{
+		rbacSource:         NewInformerRBACSource(factory),
+		namespaceValidator: namespaceValidator,
+		resourceResolver:   resourceResolver,
+		policyRuleMatcher:  NewPolicyRuleMatcher(),
+	}
+	action := Action{Verb: "get", Resource: "secrets", Namespace: namespace}
+	filter, err := NewSubjectFilter(nil, nil, []string{"system:*"}, nil)
+	require.NoError(t, err)
+
+	var out syncBuffer
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	// when
+	go func() {
+		done <- NewWatcher(wc, factory, filter, NewPrinter(&out, false), true).Watch(ctx, action)
+	}()
+
+	binding := &rbac.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "alice-can-read-secrets", Namespace: namespace},
+		RoleRef:    rbac.RoleRef{Kind: RoleKind, Name: "read-secrets"},
+		Subjects: []rbac.Subject{
+			{Kind: rbac.UserKind, Name: "Alice"},
+			{Kind: rbac.GroupKind, Name: "system:masters"},
+		},
+	}
+	_, err = client.RbacV1().RoleBindings(namespace).Create(ctx, binding, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// then
+	added := `{"type":"ADDED","bindingKind":"RoleBinding","binding":"alice-can-read-secrets","namespace":"foo","roleRef":{"apiGroup":"","kind":"Role","name":"read-secrets"},"subject":{"kind":"User","name":"Alice"}}`
+	require.Eventually(t, func() bool {
+		return strings.Contains(out.String(), added)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	err = client.RbacV1().RoleBindings(namespace).Delete(ctx, binding.Name, metav1.DeleteOptions{})
+	require.NoError(t, err)
+
+	removed := strings.Replace(added, "ADDED", "REMOVED", 1)
+	require.Eventually(t, func() bool {
+		return strings.Contains(out.String(), removed)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+	assert.Equal(t, 1, strings.Count(out.String(), added))
+	assert.NotContains(t, out.String(), "system:masters")
+}